@@ -0,0 +1,58 @@
+document.addEventListener('DOMContentLoaded', function() {
+    // Форма контракта и форма заявки на оплату отправляются одинаково
+    const contractForm = document.getElementById('contractForm') || document.getElementById('requestForm');
+    const formMessage = document.getElementById('formMessage');
+    if (!contractForm) return;
+
+    // Отправка формы контракта с выводом ошибок рядом с полями
+    contractForm.addEventListener('submit', function(event) {
+        event.preventDefault();
+        clearErrors();
+
+        fetch(contractForm.action, { method: 'POST', body: new FormData(contractForm) })
+            .then(response => response.json().then(data => ({ ok: response.ok, data })))
+            .then(({ ok, data }) => {
+                if (!ok) {
+                    showErrors(data.fields || {});
+                    showMessage(data.error || 'Ошибка сохранения формы', true);
+                    return;
+                }
+                contractForm.reset();
+                showMessage([data.message, data.warning].filter(Boolean).join(' '), Boolean(data.warning));
+            })
+            .catch(error => {
+                console.error('Ошибка отправки формы:', error);
+                showMessage('Не удалось отправить форму. Попробуйте ещё раз.', true);
+            });
+    });
+
+    contractForm.addEventListener('reset', clearErrors);
+
+    // Показывает ошибки под соответствующими полями формы
+    function showErrors(fields) {
+        Object.entries(fields).forEach(([field, message]) => {
+            const container = contractForm.querySelector(`[data-error-for="${field.replace('[]', '')}"]`);
+            if (container) {
+                container.textContent = message;
+            }
+            const input = contractForm.querySelector(`[name="${field}"], [name="${field}[]"]`);
+            if (input) {
+                input.classList.add('invalid');
+            }
+        });
+    }
+
+    // Убирает ошибки и сообщение о результате
+    function clearErrors() {
+        contractForm.querySelectorAll('.field-error').forEach(el => el.textContent = '');
+        contractForm.querySelectorAll('.invalid').forEach(el => el.classList.remove('invalid'));
+        formMessage.classList.add('hidden');
+    }
+
+    // Показывает сообщение о результате отправки
+    function showMessage(text, isError) {
+        formMessage.textContent = text;
+        formMessage.classList.toggle('error', isError);
+        formMessage.classList.remove('hidden');
+    }
+});
@@ -1,16 +1,62 @@
+// Выбор контрагента с поиском: список подгружается с сервера по введённому ИНН или части наименования,
+// поэтому форма не зависит от общего количества контрагентов
 document.addEventListener('DOMContentLoaded', function() {
-    fetch('/api/counterparties')
-        .then(response => response.json())
-        .then(data => {
-            const select = document.getElementById('counterparty');
-            data.forEach(counterparty => {
-                const option = document.createElement('option');
-                option.value = counterparty.id;
-                option.textContent = counterparty.name;
-                select.appendChild(option);
+    const select = document.getElementById('counterparty');
+    if (!select) return;
+
+    const pageSize = 50;
+    let requestId = 0;
+    let timer = null;
+
+    const search = document.createElement('input');
+    search.type = 'search';
+    search.id = 'counterpartySearch';
+    search.placeholder = 'Поиск по ИНН или наименованию';
+    search.autocomplete = 'off';
+    select.parentNode.insertBefore(search, select);
+
+    function loadCounterparties(query) {
+        const current = requestId += 1;
+        const params = new URLSearchParams({ page_size: pageSize });
+        if (query) params.set('q', query);
+
+        fetch('/api/v1/counterparties?' + params.toString())
+            .then(response => response.json())
+            .then(data => {
+                // Ответ на устаревший запрос не должен затирать результаты более позднего
+                if (current !== requestId) return;
+
+                const selected = select.options[select.selectedIndex];
+                select.innerHTML = '';
+                if (selected && selected.value) {
+                    select.appendChild(selected);
+                }
+
+                data.items.forEach(counterparty => {
+                    if (selected && String(counterparty.id) === selected.value) return;
+                    const option = document.createElement('option');
+                    option.value = counterparty.id;
+                    option.textContent = counterparty.name + ' (ИНН ' + counterparty.inn + ')';
+                    select.appendChild(option);
+                });
+
+                if (data.total > data.items.length) {
+                    const hint = document.createElement('option');
+                    hint.disabled = true;
+                    hint.value = '';
+                    hint.textContent = 'Показаны ' + data.items.length + ' из ' + data.total + ' — уточните поиск';
+                    select.appendChild(hint);
+                }
+            })
+            .catch(error => {
+                console.error('Ошибка загрузки контрагентов:', error);
             });
-        })
-        .catch(error => {
-            console.error('Ошибка загрузки контрагентов:', error);
-        });
-});
\ No newline at end of file
+    }
+
+    search.addEventListener('input', function() {
+        clearTimeout(timer);
+        timer = setTimeout(() => loadCounterparties(search.value.trim()), 300);
+    });
+
+    loadCounterparties('');
+});
@@ -43,6 +43,9 @@ document.addEventListener('DOMContentLoaded', function() {
         xhr.onload = function() {
             if (xhr.status === 200) {
                 updateProgress(100, 'Файлы успешно загружены!');
+            } else if (xhr.status === 413 || xhr.status === 415) {
+                // Сервер перечисляет файлы, не прошедшие проверку размера или типа
+                updateProgress(100, 'Ошибка загрузки! ' + describeRejectedFiles(xhr.responseText));
             } else {
                 updateProgress(100, 'Ошибка загрузки!');
             }
@@ -55,6 +58,17 @@ document.addEventListener('DOMContentLoaded', function() {
         xhr.send(formData);
     }
 
+    // Формирует текст о файлах, отклонённых сервером
+    function describeRejectedFiles(responseText) {
+        try {
+            const data = JSON.parse(responseText);
+            if (!data.files) return data.error || '';
+            return data.files.map(f => f.file ? `${f.file}: ${f.error}` : f.error).join('; ');
+        } catch (e) {
+            return '';
+        }
+    }
+
     // Функция обновления прогресса
     function updateProgress(percent, status) {
         progressBar.style.width = `${percent}%`;
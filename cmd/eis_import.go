@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"statements/internal/config"
+	"statements/internal/database"
+	"statements/internal/registry"
+	"strings"
+)
+
+// runEISImportCommand загружает карточки контрактов 44-ФЗ/223-ФЗ из файлов XML и каталогов:
+//
+//	statements eis-import [-dry-run] [-contract-type ТИП] [-user ИМЯ] КАТАЛОГ|ФАЙЛ...
+//
+// Каталоги просматриваются рекурсивно, загружаются файлы с расширением .xml
+func runEISImportCommand(args []string) {
+	flags := flag.NewFlagSet("eis-import", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "только проверить файлы, ничего не сохраняя")
+	contractType := flags.String("contract-type", "", "тип контракта для новых контрактов")
+	user := flags.String("user", "eis-import", "пользователь, от имени которого сохраняются изменения")
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "Использование: statements eis-import [-dry-run] [-contract-type ТИП] [-user ИМЯ] КАТАЛОГ|ФАЙЛ...")
+		os.Exit(2)
+	}
+
+	files, err := eisFiles(flags.Args())
+	if err != nil {
+		log.Fatalf("Ошибка поиска файлов: %v", err)
+	}
+	if len(files) == 0 {
+		log.Fatalf("Файлы XML не найдены")
+	}
+
+	cfg, err := config.LoadConfig("config.yaml")
+	if err != nil {
+		log.Fatalf("Ошибка загрузки конфигурации: %v", err)
+	}
+	if err := database.ConnectDB(cfg); err != nil {
+		log.Fatalf("Ошибка подключения к базе данных: %v", err)
+	}
+	defer database.CloseDB()
+
+	ctx := context.Background()
+	opts := registry.Options{DryRun: *dryRun, ContractType: strings.TrimSpace(*contractType), User: *user}
+	for _, path := range files {
+		f, err := os.Open(path)
+		if err != nil {
+			log.Printf("Ошибка открытия файла %s: %v", path, err)
+			continue
+		}
+		items, err := registry.ParseEIS(f)
+		f.Close()
+		if err != nil {
+			log.Printf("Ошибка разбора файла %s: %v", path, err)
+			continue
+		}
+
+		report, err := registry.ImportEIS(ctx, database.DB, path, items, opts)
+		if err != nil {
+			log.Printf("Ошибка загрузки контрактов из %s: %v", path, err)
+			continue
+		}
+		printEISReport(report)
+	}
+}
+
+// eisFiles раскрывает каталоги в список файлов .xml
+func eisFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && strings.EqualFold(filepath.Ext(p), ".xml") {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// printEISReport выводит итоги загрузки файла, ошибки, предупреждения и расхождения с сохранёнными контрактами
+func printEISReport(report registry.Report) {
+	fmt.Printf("%s: всего %d, создано %d, обновлено %d, без изменений %d, с ошибками %d\n",
+		report.File, report.Total, report.Created, report.Updated, report.Unchanged, report.Failed)
+	for _, row := range report.Rows {
+		if len(row.Errors) == 0 && len(row.Warnings) == 0 && len(row.Differences) == 0 {
+			continue
+		}
+		fmt.Printf("  #%d %s от %s (%s): %s\n", row.Line, row.ContractNumber, row.ContractDate, row.RegistryNumber, row.Action)
+		fields := make([]string, 0, len(row.Errors))
+		for field := range row.Errors {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		for _, field := range fields {
+			fmt.Printf("    ошибка %s: %s\n", field, row.Errors[field])
+		}
+		for _, w := range row.Warnings {
+			fmt.Printf("    внимание: %s\n", w)
+		}
+		for _, d := range row.Differences {
+			fmt.Printf("    %s: было %q, в ЕИС %q\n", d.Title, d.Stored, d.Imported)
+		}
+	}
+	if report.DryRun {
+		fmt.Println("  режим проверки: изменения не сохранены")
+	}
+}
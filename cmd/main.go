@@ -1,16 +1,31 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
 	"statements/internal/config"
 	"statements/internal/database"
+	"statements/internal/matching"
 	"statements/internal/middleware"
 	"statements/internal/router"
+	"statements/internal/storage"
+	"statements/internal/transactions"
+	"time"
 )
 
 func main() {
+	// Служебные команды выполняются вместо запуска сервера
+	if len(os.Args) > 1 && os.Args[1] == "partitions" {
+		runPartitionsCommand(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "eis-import" {
+		runEISImportCommand(os.Args[2:])
+		return
+	}
+
 	startApp()
 }
 
@@ -46,13 +61,35 @@ func startApp() {
 	// Выполнение миграций базы данных
 	database.RunMigrations(cfg)
 
+	// Заранее создаём партиции contracts на текущий и следующие годы
+	currentYear := time.Now().Year()
+	if _, err := database.EnsureContractPartitions(context.Background(), currentYear, currentYear+cfg.Database.PartitionYearsAhead); err != nil {
+		log.Printf("Ошибка создания партиций contracts: %v", err)
+	}
+
+	// Привязываем к контрагентам ранее загруженные транзакции
+	if err := transactions.LinkCounterparties(context.Background()); err != nil {
+		log.Printf("Ошибка привязки транзакций к контрагентам: %v", err)
+	}
+
+	// Сопоставляем с контрактами транзакции, загруженные до появления сопоставления
+	if _, err := matching.MatchPending(context.Background(), database.DB); err != nil {
+		log.Printf("Ошибка сопоставления транзакций с контрактами: %v", err)
+	}
+
 	// Создаем директорию для загрузки файлов, если её нет
 	if err := os.MkdirAll(cfg.FileUpload.UploadDir, os.ModePerm); err != nil {
 		log.Fatalf("Ошибка создания директории для загрузки файлов: %v", err)
 	}
 
+	// Хранилище документов: локальный каталог или S3-совместимое хранилище
+	store, err := storage.New(cfg.Storage, cfg.FileUpload.UploadDir)
+	if err != nil {
+		log.Fatalf("Ошибка инициализации хранилища документов: %v", err)
+	}
+
 	// Регистрация маршрутов с использованием нового пакета router
-	r := router.RegisterRoutes(cfg)
+	r := router.RegisterRoutes(cfg, store)
 
 	// Добавляем CORS middleware
 	r.Use(middleware.CORSMiddleware())
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"statements/internal/config"
+	"statements/internal/database"
+	"time"
+)
+
+// runPartitionsCommand выполняет команды управления партициями contracts:
+//
+//	statements partitions list
+//	statements partitions create -from 2025 -to 2030
+func runPartitionsCommand(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Использование: statements partitions list | create [-from ГОД] [-to ГОД]")
+		os.Exit(2)
+	}
+
+	cfg, err := config.LoadConfig("config.yaml")
+	if err != nil {
+		log.Fatalf("Ошибка загрузки конфигурации: %v", err)
+	}
+	if err := database.ConnectDB(cfg); err != nil {
+		log.Fatalf("Ошибка подключения к базе данных: %v", err)
+	}
+	defer database.CloseDB()
+
+	ctx := context.Background()
+	switch args[0] {
+	case "list":
+		partitions, err := database.ListContractPartitions(ctx)
+		if err != nil {
+			log.Fatalf("Ошибка получения партиций: %v", err)
+		}
+		for _, p := range partitions {
+			fmt.Printf("%-20s %-60s %d\n", p.Name, p.Bound, p.Rows)
+		}
+
+	case "create":
+		currentYear := time.Now().Year()
+		flags := flag.NewFlagSet("partitions create", flag.ExitOnError)
+		from := flags.Int("from", currentYear, "первый год")
+		to := flags.Int("to", currentYear+cfg.Database.PartitionYearsAhead, "последний год (включительно)")
+		flags.Parse(args[1:])
+
+		if *from > *to {
+			log.Fatalf("Первый год %d больше последнего %d", *from, *to)
+		}
+
+		created, err := database.EnsureContractPartitions(ctx, *from, *to)
+		if err != nil {
+			log.Fatalf("Ошибка создания партиций: %v", err)
+		}
+		fmt.Printf("Создано партиций: %d %v\n", len(created), created)
+
+	default:
+		log.Fatalf("Неизвестная команда partitions %s", args[0])
+	}
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/spf13/viper"
+)
+
+// ApprovalConfig описывает цепочку согласования заявок на оплату
+type ApprovalConfig struct {
+	Steps []ApprovalStep `mapstructure:"steps"` // Этапы в порядке прохождения
+}
+
+// ApprovalStep — этап согласования
+type ApprovalStep struct {
+	Code      string  `mapstructure:"code"`       // Уникальный код этапа
+	Title     string  `mapstructure:"title"`      // Название для пользователя
+	Role      string  `mapstructure:"role"`       // Роль из JWT (claim roles/role), которой разрешено решение; пусто — любой пользователь
+	MinAmount float64 `mapstructure:"min_amount"` // Этап нужен только для заявок на сумму не меньше указанной; 0 — для всех
+}
+
+// StepsFor возвращает этапы, которые проходит заявка на указанную сумму
+func (c ApprovalConfig) StepsFor(amount float64) []ApprovalStep {
+	steps := make([]ApprovalStep, 0, len(c.Steps))
+	for _, step := range c.Steps {
+		if amount >= step.MinAmount {
+			steps = append(steps, step)
+		}
+	}
+	return steps
+}
+
+// validate проверяет, что цепочка не пуста, а коды этапов заданы и не повторяются
+func (c ApprovalConfig) validate() error {
+	if len(c.Steps) == 0 {
+		return fmt.Errorf("approval chain has no steps")
+	}
+	codes := make(map[string]bool, len(c.Steps))
+	for i, step := range c.Steps {
+		if step.Code == "" {
+			return fmt.Errorf("approval step %d has no code", i+1)
+		}
+		if codes[step.Code] {
+			return fmt.Errorf("approval step code %q is duplicated", step.Code)
+		}
+		codes[step.Code] = true
+	}
+	return nil
+}
+
+// LoadApprovalConfig загружает конфигурацию согласования заявок
+func LoadApprovalConfig(v *viper.Viper) (ApprovalConfig, error) {
+	var config ApprovalConfig
+	if err := v.UnmarshalKey("approval", &config); err != nil {
+		return config, err
+	}
+	return config, nil
+}
@@ -11,10 +11,12 @@ type Config struct {
 	Server       ServerConfig       `mapstructure:"server"`
 	Database     DatabaseConfig     `mapstructure:"database"`
 	FileUpload   FileUploadConfig   `mapstructure:"file_upload"`
+	Storage      StorageConfig      `mapstructure:"storage"`
 	Logging      LoggingConfig      `mapstructure:"logging"`
 	Python       PythonConfig       `mapstructure:"python"`
 	Auth         AuthConfig         `mapstructure:"auth"`
 	Organization OrganizationConfig `mapstructure:"organization"`
+	Approval     ApprovalConfig     `mapstructure:"approval"`
 }
 
 // LoadConfig загружает конфигурацию из файла и переменных окружения
@@ -57,6 +59,9 @@ func validateConfig(config *Config) error {
 	if config.Server.Port == 0 {
 		return fmt.Errorf("server port is not set")
 	}
+	if err := config.Approval.validate(); err != nil {
+		return err
+	}
 	// Можно добавить другие проверки для важных параметров
 	return nil
 }
@@ -9,6 +9,8 @@ type DatabaseConfig struct {
 	MaxIdleConnections int    `mapstructure:"max_idle_connections"`
 	MigrationsDir      string `mapstructure:"migrations_dir"`
 	DatabaseName       string `mapstructure:"database_name"`
+	// Количество лет вперёд, на которые при запуске заранее создаются партиции contracts
+	PartitionYearsAhead int `mapstructure:"partition_years_ahead"`
 }
 
 // LoadDatabaseConfig загружает конфигурацию базы данных
@@ -4,8 +4,44 @@ import "github.com/spf13/viper"
 
 // FileUploadConfig конфигурация для загрузки файлов
 type FileUploadConfig struct {
-	UploadDir string `mapstructure:"upload_dir"`
-	StaticDir string `mapstructure:"static_dir"`
+	UploadDir string                  `mapstructure:"upload_dir"`
+	StaticDir string                  `mapstructure:"static_dir"`
+	Limits    map[string]UploadLimits `mapstructure:"limits"` // Ограничения по видам загрузки: statements, contracts, payment_requests, egrul, registry, eis
+}
+
+// UploadLimits ограничения на файлы, загружаемые через один endpoint
+type UploadLimits struct {
+	MaxFileSizeMB int64    `mapstructure:"max_file_size_mb"` // Максимальный размер одного файла в мегабайтах
+	MaxFiles      int      `mapstructure:"max_files"`        // Максимальное количество файлов в запросе
+	AllowedTypes  []string `mapstructure:"allowed_types"`    // Допустимые MIME-типы, определяемые по содержимому файла
+}
+
+// defaultUploadLimits применяется к видам загрузки, не описанным в конфигурации
+var defaultUploadLimits = UploadLimits{MaxFileSizeMB: 10, MaxFiles: 10}
+
+// LimitsFor возвращает ограничения для вида загрузки, подставляя значения по умолчанию
+func (c FileUploadConfig) LimitsFor(kind string) UploadLimits {
+	limits, ok := c.Limits[kind]
+	if !ok {
+		return defaultUploadLimits
+	}
+	if limits.MaxFileSizeMB <= 0 {
+		limits.MaxFileSizeMB = defaultUploadLimits.MaxFileSizeMB
+	}
+	if limits.MaxFiles <= 0 {
+		limits.MaxFiles = defaultUploadLimits.MaxFiles
+	}
+	return limits
+}
+
+// MaxFileSize возвращает максимальный размер одного файла в байтах
+func (l UploadLimits) MaxFileSize() int64 {
+	return l.MaxFileSizeMB << 20
+}
+
+// MaxRequestSize возвращает максимальный размер всего запроса: все файлы и запас на поля формы
+func (l UploadLimits) MaxRequestSize() int64 {
+	return int64(l.MaxFiles)*l.MaxFileSize() + 1<<20
 }
 
 // LoadFileUploadConfig загружает конфигурацию загрузки файлов
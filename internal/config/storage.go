@@ -0,0 +1,28 @@
+package config
+
+import "github.com/spf13/viper"
+
+// StorageConfig конфигурация хранилища документов
+type StorageConfig struct {
+	Backend string   `mapstructure:"backend"` // local — каталог file_upload.upload_dir, s3 — S3-совместимое хранилище
+	S3      S3Config `mapstructure:"s3"`
+}
+
+// S3Config параметры подключения к S3-совместимому хранилищу (MinIO, Yandex Object Storage и т.п.)
+type S3Config struct {
+	Endpoint  string `mapstructure:"endpoint"`
+	Region    string `mapstructure:"region"`
+	Bucket    string `mapstructure:"bucket"`
+	AccessKey string `mapstructure:"access_key"`
+	SecretKey string `mapstructure:"secret_key"`
+	PathStyle bool   `mapstructure:"path_style"` // Адресация bucket в пути URL, а не в имени хоста (нужна для MinIO)
+}
+
+// LoadStorageConfig загружает конфигурацию хранилища документов
+func LoadStorageConfig(v *viper.Viper) (StorageConfig, error) {
+	var config StorageConfig
+	if err := v.UnmarshalKey("storage", &config); err != nil {
+		return config, err
+	}
+	return config, nil
+}
@@ -0,0 +1,223 @@
+package contracts
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"statements/internal/database"
+	"statements/internal/models"
+	"strings"
+	"time"
+)
+
+// termsJoin — условия контракта, изменённые дополнительными соглашениями, вступившими в силу к дате $1
+const termsJoin = `CROSS JOIN LATERAL contract_amended_terms(c.uid, $1::date) tm`
+
+// Действующие сумма и срок исполнения контракта в выборке с termsJoin
+const (
+	effectiveAmountSQL          = `COALESCE(tm.amount, c.amount)`
+	effectiveExecutionPeriodSQL = `COALESCE(tm.execution_period, c.execution_period)`
+)
+
+// amendmentColumns — список колонок соглашения в порядке, ожидаемом Amendments
+const amendmentColumns = `a.id, a.contract_uid::text, a.amendment_number, a.amendment_date::text, a.effective_date::text,
+	a.amount::float8, a.execution_period::text, a.validity_period::text, a.subject, a.payment_days,
+	COALESCE(a.comment, ''), a.document_id, COALESCE(a.created_by, ''), a.created_at::text`
+
+// Amendments возвращает дополнительные соглашения контракта в порядке вступления в силу.
+// Для каждого соглашения рассчитываются изменённые условия с прежними значениями
+func Amendments(ctx context.Context, db database.DBTX, id string) ([]models.ContractAmendment, error) {
+	ct, err := Get(ctx, db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx,
+		`SELECT `+amendmentColumns+`
+		FROM contract_amendments a
+		WHERE a.contract_uid = $1
+		ORDER BY a.effective_date, a.amendment_date, a.id`, ct.ID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения соглашений контракта %s: %w", id, err)
+	}
+	defer rows.Close()
+
+	amendments := make([]models.ContractAmendment, 0)
+	terms := ct.Initial
+	for rows.Next() {
+		var a models.ContractAmendment
+		if err := rows.Scan(&a.ID, &a.ContractID, &a.Number, &a.Date, &a.EffectiveDate,
+			&a.Amount, &a.ExecutionPeriod, &a.ValidityPeriod, &a.Subject, &a.PaymentDays,
+			&a.Comment, &a.DocumentID, &a.CreatedBy, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("ошибка чтения соглашения: %w", err)
+		}
+		a.Changes = applyAmendment(&terms, a)
+		amendments = append(amendments, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения соглашений контракта %s: %w", id, err)
+	}
+	return amendments, nil
+}
+
+// AddAmendment сохраняет дополнительное соглашение к контракту. Если дата вступления в силу не указана,
+// изменения действуют с даты соглашения. Ошибки полей возвращаются как *ValidationError
+func AddAmendment(ctx context.Context, db database.DBTX, a models.ContractAmendment) (models.ContractAmendment, error) {
+	ct, err := Get(ctx, db, a.ContractID)
+	if err != nil {
+		return a, err
+	}
+
+	a.Number = strings.TrimSpace(a.Number)
+	a.Date = strings.TrimSpace(a.Date)
+	a.EffectiveDate = strings.TrimSpace(a.EffectiveDate)
+	if a.EffectiveDate == "" {
+		a.EffectiveDate = a.Date
+	}
+	if a.Subject != nil {
+		subject := strings.TrimSpace(*a.Subject)
+		a.Subject = &subject
+	}
+
+	verr := &ValidationError{}
+	validateAmendment(verr, a, ct.ContractDate)
+	if _, failed := verr.Fields["number"]; !failed {
+		var taken bool
+		if err := db.QueryRowContext(ctx,
+			`SELECT EXISTS (SELECT 1 FROM contract_amendments WHERE contract_uid = $1 AND amendment_number = $2)`,
+			ct.ID, a.Number).Scan(&taken); err != nil {
+			return a, fmt.Errorf("ошибка проверки номера соглашения %s: %w", a.Number, err)
+		}
+		if taken {
+			verr.Add("number", "Соглашение с таким номером уже добавлено")
+		}
+	}
+	if a.DocumentID != nil {
+		var exists bool
+		if err := db.QueryRowContext(ctx,
+			`SELECT EXISTS (SELECT 1 FROM contract_documents WHERE id = $1 AND root_id = id AND contract_uid = $2)`,
+			*a.DocumentID, ct.ID).Scan(&exists); err != nil {
+			return a, fmt.Errorf("ошибка проверки документа %d: %w", *a.DocumentID, err)
+		}
+		if !exists {
+			verr.Add("document_id", "Документ не найден среди документов контракта")
+		}
+	}
+	if err := verr.Err(); err != nil {
+		return a, err
+	}
+
+	var amount interface{}
+	if a.Amount != nil {
+		amount = math.Round(*a.Amount*100) / 100
+	}
+	err = db.QueryRowContext(ctx,
+		`INSERT INTO contract_amendments
+			(contract_uid, amendment_number, amendment_date, effective_date, amount, execution_period, validity_period,
+			 subject, payment_days, comment, document_id, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6::date, $7::date, $8, $9, NULLIF($10, ''), $11, NULLIF($12, ''))
+		RETURNING id`,
+		ct.ID, a.Number, a.Date, a.EffectiveDate, amount, a.ExecutionPeriod, a.ValidityPeriod,
+		a.Subject, a.PaymentDays, strings.TrimSpace(a.Comment), a.DocumentID, a.CreatedBy).Scan(&a.ID)
+	if database.IsUniqueViolation(err) {
+		verr.Add("number", "Соглашение с таким номером уже добавлено")
+		return a, verr
+	}
+	if err != nil {
+		return a, fmt.Errorf("ошибка сохранения соглашения к контракту %s: %w", ct.ID, err)
+	}
+
+	amendments, err := Amendments(ctx, db, ct.ID)
+	if err != nil {
+		return a, err
+	}
+	for _, saved := range amendments {
+		if saved.ID == a.ID {
+			return saved, nil
+		}
+	}
+	return a, nil
+}
+
+// validateAmendment дописывает в verr ошибки полей соглашения к контракту, заключённому contractDate
+func validateAmendment(verr *ValidationError, a models.ContractAmendment, contractDate string) {
+	switch {
+	case a.Number == "":
+		verr.Add("number", "Укажите номер соглашения")
+	case len([]rune(a.Number)) > 50:
+		verr.Add("number", "Номер соглашения не может быть длиннее 50 символов")
+	}
+
+	signed, _ := time.Parse(time.DateOnly, contractDate)
+	date, dateOK := parseDateField(verr, "date", a.Date, true)
+	switch {
+	case !dateOK:
+	case date.After(time.Now()):
+		verr.Add("date", "Дата соглашения не может быть в будущем")
+	case date.Before(signed):
+		verr.Add("date", "Дата соглашения не может быть раньше даты заключения контракта")
+	}
+	if effective, ok := parseDateField(verr, "effective_date", a.EffectiveDate, true); ok && effective.Before(signed) {
+		verr.Add("effective_date", "Изменения не могут вступить в силу раньше заключения контракта")
+	}
+
+	if a.Amount == nil && a.ExecutionPeriod == nil && a.ValidityPeriod == nil && a.Subject == nil && a.PaymentDays == nil {
+		verr.Add("changes", "Укажите хотя бы одно изменяемое условие контракта")
+	}
+	if a.Amount != nil {
+		switch amount := *a.Amount; {
+		case math.IsNaN(amount) || math.IsInf(amount, 0):
+			verr.Add("amount", "Сумма должна быть числом")
+		case amount < 0:
+			verr.Add("amount", "Сумма контракта не может быть отрицательной")
+		case amount >= maxAmount:
+			verr.Add("amount", "Сумма контракта слишком велика")
+		}
+	}
+	if a.ExecutionPeriod != nil {
+		if period, ok := parseDateField(verr, "execution_period", *a.ExecutionPeriod, true); ok && period.Before(signed) {
+			verr.Add("execution_period", "Срок исполнения не может быть раньше даты заключения")
+		}
+	}
+	if a.ValidityPeriod != nil {
+		if period, ok := parseDateField(verr, "validity_period", *a.ValidityPeriod, true); ok && period.Before(signed) {
+			verr.Add("validity_period", "Срок действия не может быть раньше даты заключения")
+		}
+	}
+	if a.Subject != nil && *a.Subject == "" {
+		verr.Add("subject", "Предмет контракта не может быть пустым")
+	}
+	if a.PaymentDays != nil && *a.PaymentDays < 0 {
+		verr.Add("payment_days", "Количество дней на оплату не может быть отрицательным")
+	}
+}
+
+// applyAmendment применяет соглашение к условиям terms и возвращает список изменений
+func applyAmendment(terms *models.ContractTerms, a models.ContractAmendment) []models.AmendmentChange {
+	changes := make([]models.AmendmentChange, 0)
+	if a.Amount != nil {
+		changes = append(changes, models.AmendmentChange{Field: "amount", Title: "Сумма контракта", Old: terms.Amount, New: *a.Amount})
+		terms.Amount = *a.Amount
+	}
+	if a.ExecutionPeriod != nil {
+		changes = append(changes, models.AmendmentChange{Field: "execution_period", Title: "Срок исполнения", Old: terms.ExecutionPeriod, New: *a.ExecutionPeriod})
+		terms.ExecutionPeriod = *a.ExecutionPeriod
+	}
+	if a.ValidityPeriod != nil {
+		changes = append(changes, models.AmendmentChange{Field: "validity_period", Title: "Срок действия", Old: terms.ValidityPeriod, New: *a.ValidityPeriod})
+		terms.ValidityPeriod = *a.ValidityPeriod
+	}
+	if a.Subject != nil {
+		changes = append(changes, models.AmendmentChange{Field: "subject", Title: "Предмет контракта", Old: terms.Subject, New: *a.Subject})
+		terms.Subject = *a.Subject
+	}
+	if a.PaymentDays != nil {
+		var old interface{}
+		if terms.PaymentDays != nil {
+			old = *terms.PaymentDays
+		}
+		changes = append(changes, models.AmendmentChange{Field: "payment_days", Title: "Дней на оплату", Old: old, New: *a.PaymentDays})
+		terms.PaymentDays = a.PaymentDays
+	}
+	return changes
+}
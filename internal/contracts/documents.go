@@ -0,0 +1,187 @@
+package contracts
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"statements/internal/database"
+	"statements/internal/models"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// ErrDocumentNotFound возвращается, если у контракта нет документа с указанным идентификатором
+	ErrDocumentNotFound = errors.New("документ контракта не найден")
+	// ErrInvalidDocumentType возвращается для пустого или слишком длинного вида документа
+	ErrInvalidDocumentType = errors.New("недопустимый вид документа")
+)
+
+// maxDocumentTypeLength — максимальная длина вида документа (contract_documents.document_type)
+const maxDocumentTypeLength = 100
+
+// documentTitles — названия известных видов документов контракта
+var documentTitles = map[string]string{
+	"contract":       "Контракт",
+	"memo":           "Служебная записка",
+	"ecp":            "ЭЦП",
+	"technical_task": "Техническое задание",
+	"additional":     "Дополнительный документ",
+	"amendment":      "Дополнительное соглашение",
+}
+
+// documentColumns — список колонок версии документа в порядке, ожидаемом scanDocument
+const documentColumns = `d.root_id, d.id, d.document_type, d.version, d.is_current, d.storage_key,
+	COALESCE(f.original_name, regexp_replace(d.storage_key, '^.*/', '')), COALESCE(f.content_type, ''),
+	COALESCE(f.size, 0), COALESCE(d.sha256, ''), COALESCE(d.uploaded_by, ''), d.uploaded_at::text`
+
+// documentFrom — источник выборки версий документов с метаданными файлов; $1 — идентификатор контракта
+const documentFrom = `FROM contract_documents d
+	LEFT JOIN stored_files f ON f.storage_key = d.storage_key
+	WHERE d.contract_uid = $1`
+
+// DocumentTitle возвращает название вида документа; для неизвестных видов — сам вид
+func DocumentTitle(documentType string) string {
+	if title, ok := documentTitles[documentType]; ok {
+		return title
+	}
+	return documentType
+}
+
+// NormalizeDocumentType проверяет вид документа и приводит его к хранимому виду
+func NormalizeDocumentType(documentType string) (string, error) {
+	documentType = strings.TrimSpace(documentType)
+	if documentType == "" || utf8.RuneCountInString(documentType) > maxDocumentTypeLength {
+		return "", ErrInvalidDocumentType
+	}
+	return documentType, nil
+}
+
+// Documents возвращает текущие версии документов контракта
+func Documents(ctx context.Context, db database.DBTX, id string) ([]models.ContractDocument, error) {
+	if _, err := Get(ctx, db, id); err != nil {
+		return nil, err
+	}
+	return queryDocuments(ctx, db,
+		"SELECT "+documentColumns+" "+documentFrom+" AND d.is_current ORDER BY d.root_id", id)
+}
+
+// Document возвращает текущую версию документа контракта
+func Document(ctx context.Context, db database.DBTX, id string, documentID int) (models.ContractDocument, error) {
+	return DocumentVersion(ctx, db, id, documentID, 0)
+}
+
+// DocumentVersion возвращает указанную версию документа контракта; version 0 означает текущую
+func DocumentVersion(ctx context.Context, db database.DBTX, id string, documentID, version int) (models.ContractDocument, error) {
+	if _, err := Get(ctx, db, id); err != nil {
+		return models.ContractDocument{}, err
+	}
+	doc, err := scanDocument(db.QueryRowContext(ctx,
+		"SELECT "+documentColumns+" "+documentFrom+` AND d.root_id = $2
+		AND (d.version = $3 OR $3 = 0 AND d.is_current)`, id, documentID, version))
+	if errors.Is(err, sql.ErrNoRows) {
+		return doc, ErrDocumentNotFound
+	}
+	if err != nil {
+		return doc, fmt.Errorf("ошибка получения документа %d контракта %s: %w", documentID, id, err)
+	}
+	return doc, nil
+}
+
+// DocumentVersions возвращает все версии документа контракта, начиная с последней
+func DocumentVersions(ctx context.Context, db database.DBTX, id string, documentID int) ([]models.ContractDocument, error) {
+	if _, err := Get(ctx, db, id); err != nil {
+		return nil, err
+	}
+	docs, err := queryDocuments(ctx, db,
+		"SELECT "+documentColumns+" "+documentFrom+" AND d.root_id = $2 ORDER BY d.version DESC", id, documentID)
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, ErrDocumentNotFound
+	}
+	return docs, nil
+}
+
+// AddDocument добавляет к контракту новый документ (первую версию) из сохранённого файла.
+// Метаданные файла должны быть уже записаны в stored_files
+func AddDocument(ctx context.Context, db database.DBTX, id, documentType string, f models.StoredFile) (models.ContractDocument, error) {
+	documentType, err := NormalizeDocumentType(documentType)
+	if err != nil {
+		return models.ContractDocument{}, err
+	}
+
+	var documentID int
+	err = db.QueryRowContext(ctx,
+		`WITH new_id AS (SELECT nextval(pg_get_serial_sequence('contract_documents', 'id')) AS id)
+		INSERT INTO contract_documents (id, root_id, contract_uid, document_type, version, storage_key, sha256, uploaded_by)
+		SELECT id, id, $1, $2, 1, $3, NULLIF($4, ''), NULLIF($5, '') FROM new_id
+		RETURNING id`,
+		id, documentType, f.Key, f.SHA256, f.UploadedBy).Scan(&documentID)
+	if err != nil {
+		return models.ContractDocument{}, fmt.Errorf("ошибка добавления документа контракта %s: %w", id, err)
+	}
+	return DocumentVersion(ctx, db, id, documentID, 1)
+}
+
+// ReplaceDocument сохраняет файл как новую версию документа. Предыдущие версии остаются в истории.
+// Вызывать в транзакции: блокировка текущей версии исключает параллельную замену
+func ReplaceDocument(ctx context.Context, db database.DBTX, id string, documentID int, f models.StoredFile) (models.ContractDocument, error) {
+	var current models.ContractDocument
+	err := db.QueryRowContext(ctx,
+		`SELECT id, document_type, version FROM contract_documents
+		WHERE contract_uid = $1 AND root_id = $2 AND is_current
+		FOR UPDATE`, id, documentID).Scan(&current.VersionID, &current.Type, &current.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return current, ErrDocumentNotFound
+	}
+	if err != nil {
+		return current, fmt.Errorf("ошибка получения документа %d контракта %s: %w", documentID, id, err)
+	}
+
+	if _, err := db.ExecContext(ctx,
+		`UPDATE contract_documents SET is_current = FALSE WHERE id = $1`, current.VersionID); err != nil {
+		return current, fmt.Errorf("ошибка замены документа %d контракта %s: %w", documentID, id, err)
+	}
+	_, err = db.ExecContext(ctx,
+		`INSERT INTO contract_documents (root_id, contract_uid, document_type, version, storage_key, sha256, uploaded_by)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''))`,
+		documentID, id, current.Type, current.Version+1, f.Key, f.SHA256, f.UploadedBy)
+	if err != nil {
+		return current, fmt.Errorf("ошибка сохранения новой версии документа %d контракта %s: %w", documentID, id, err)
+	}
+	return DocumentVersion(ctx, db, id, documentID, current.Version+1)
+}
+
+// queryDocuments выполняет запрос, выбирающий версии документов по documentColumns
+func queryDocuments(ctx context.Context, db database.DBTX, query string, args ...interface{}) ([]models.ContractDocument, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения документов контракта: %w", err)
+	}
+	defer rows.Close()
+
+	docs := make([]models.ContractDocument, 0)
+	for rows.Next() {
+		doc, err := scanDocument(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения документа контракта: %w", err)
+		}
+		docs = append(docs, doc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения документов контракта: %w", err)
+	}
+	return docs, nil
+}
+
+// scanDocument читает версию документа из строки, выбранной по documentColumns
+func scanDocument(row database.RowScanner) (models.ContractDocument, error) {
+	var doc models.ContractDocument
+	err := row.Scan(&doc.ID, &doc.VersionID, &doc.Type, &doc.Version, &doc.Current, &doc.Key,
+		&doc.FileName, &doc.ContentType, &doc.Size, &doc.SHA256, &doc.UploadedBy, &doc.UploadedAt)
+	doc.Title = DocumentTitle(doc.Type)
+	return doc, err
+}
@@ -0,0 +1,153 @@
+package contracts
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"statements/internal/database"
+	"statements/internal/models"
+	"strings"
+	"unicode/utf8"
+)
+
+// Статусы контракта в ЕАИСТ (значения eaist_status_enum)
+const (
+	EaistActive    = "Активный"
+	EaistCompleted = "Завершен"
+	EaistCancelled = "Аннулирован"
+)
+
+// maxEaistReasonLength — максимальная длина причины смены статуса ЕАИСТ
+const maxEaistReasonLength = 2000
+
+// eaistTransitions — допустимые переходы между статусами ЕАИСТ; пустая строка — статус не задан.
+// Аннулированный контракт больше не меняет статус
+var eaistTransitions = map[string][]string{
+	"":             {EaistActive, EaistCompleted, EaistCancelled},
+	EaistActive:    {EaistCompleted, EaistCancelled},
+	EaistCompleted: {EaistActive},
+	EaistCancelled: {},
+}
+
+var (
+	// ErrEaistReasonRequired возвращается, если для смены статуса не указана обязательная причина
+	ErrEaistReasonRequired = errors.New("укажите причину смены статуса ЕАИСТ")
+	// ErrEaistReasonTooLong возвращается для слишком длинной причины смены статуса
+	ErrEaistReasonTooLong = errors.New("причина смены статуса ЕАИСТ слишком длинная")
+)
+
+// EaistTransitionError возвращается при недопустимой смене статуса ЕАИСТ
+type EaistTransitionError struct {
+	From    string
+	To      string
+	Allowed []string // Статусы, в которые можно перейти из From
+}
+
+func (e *EaistTransitionError) Error() string {
+	from := e.From
+	if from == "" {
+		from = "не задан"
+	}
+	return fmt.Sprintf("недопустимая смена статуса ЕАИСТ: %s → %s", from, e.To)
+}
+
+// AllowedEaistTransitions возвращает статусы, в которые можно перевести контракт из статуса from
+func AllowedEaistTransitions(from string) []string {
+	allowed := eaistTransitions[from]
+	if allowed == nil {
+		return []string{}
+	}
+	return allowed
+}
+
+// eaistReasonRequired сообщает, нужна ли причина для смены статуса: аннулирование и возобновление
+// завершённого контракта без объяснения не выполняются
+func eaistReasonRequired(from, to string) bool {
+	return to == EaistCancelled || from == EaistCompleted
+}
+
+// ChangeEaistStatus переводит контракт в новый статус ЕАИСТ с проверкой допустимости перехода
+// и записью в историю. Вызывать нужно в транзакции
+func ChangeEaistStatus(ctx context.Context, db database.DBTX, id, status, reason, changedBy string) (models.Contract, error) {
+	if !IsID(id) {
+		return models.Contract{}, ErrNotFound
+	}
+	status = strings.TrimSpace(status)
+	reason = strings.TrimSpace(reason)
+
+	var current string
+	err := db.QueryRowContext(ctx,
+		`SELECT COALESCE(eaist_status::text, '') FROM contracts WHERE uid = $1 AND deleted_at IS NULL FOR UPDATE`,
+		id).Scan(&current)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Contract{}, ErrNotFound
+	}
+	if err != nil {
+		return models.Contract{}, fmt.Errorf("ошибка блокировки контракта %s: %w", id, err)
+	}
+
+	allowed := AllowedEaistTransitions(current)
+	permitted := false
+	for _, s := range allowed {
+		permitted = permitted || s == status
+	}
+	switch {
+	case !permitted:
+		return models.Contract{}, &EaistTransitionError{From: current, To: status, Allowed: allowed}
+	case reason == "" && eaistReasonRequired(current, status):
+		return models.Contract{}, ErrEaistReasonRequired
+	case utf8.RuneCountInString(reason) > maxEaistReasonLength:
+		return models.Contract{}, ErrEaistReasonTooLong
+	}
+
+	if _, err := db.ExecContext(ctx,
+		`UPDATE contracts SET eaist_status = $2::eaist_status_enum, updated_at = now() WHERE uid = $1 AND deleted_at IS NULL`,
+		id, status); err != nil {
+		return models.Contract{}, fmt.Errorf("ошибка смены статуса ЕАИСТ контракта %s: %w", id, err)
+	}
+	if err := recordEaistStatus(ctx, db, id, current, status, reason, changedBy); err != nil {
+		return models.Contract{}, err
+	}
+	return Get(ctx, db, id)
+}
+
+// EaistHistory возвращает историю статусов ЕАИСТ контракта, начиная с последней смены
+func EaistHistory(ctx context.Context, db database.DBTX, id string) ([]models.EaistStatusChange, error) {
+	if _, err := Get(ctx, db, id); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx,
+		`SELECT id, COALESCE(old_status::text, ''), new_status::text, COALESCE(reason, ''),
+		        COALESCE(changed_by, ''), changed_at::text
+		FROM contract_eaist_status_history
+		WHERE contract_uid = $1
+		ORDER BY changed_at DESC, id DESC`, id)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения истории статусов контракта %s: %w", id, err)
+	}
+	defer rows.Close()
+
+	history := make([]models.EaistStatusChange, 0)
+	for rows.Next() {
+		var h models.EaistStatusChange
+		if err := rows.Scan(&h.ID, &h.OldStatus, &h.NewStatus, &h.Reason, &h.ChangedBy, &h.ChangedAt); err != nil {
+			return nil, fmt.Errorf("ошибка чтения истории статусов: %w", err)
+		}
+		history = append(history, h)
+	}
+	return history, rows.Err()
+}
+
+// recordEaistStatus добавляет запись в историю статусов ЕАИСТ контракта
+func recordEaistStatus(ctx context.Context, db database.DBTX, id, oldStatus, newStatus, reason, changedBy string) error {
+	_, err := db.ExecContext(ctx,
+		`INSERT INTO contract_eaist_status_history (contract_uid, old_status, new_status, reason, changed_by)
+		VALUES ($1, NULLIF($2, '')::eaist_status_enum, $3::eaist_status_enum, NULLIF($4, ''), NULLIF($5, ''))`,
+		id, oldStatus, newStatus, reason, changedBy)
+	if err != nil {
+		return fmt.Errorf("ошибка записи истории статусов контракта %s: %w", id, err)
+	}
+	return nil
+}
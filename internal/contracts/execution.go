@@ -0,0 +1,98 @@
+package contracts
+
+import (
+	"math"
+	"statements/internal/models"
+	"time"
+)
+
+// Статусы исполнения контракта по связанным платежам
+const (
+	ExecutionNotPaid  = "not_paid" // Платежей нет, срок исполнения не прошёл
+	ExecutionPartial  = "partial"  // Оплачен частично, срок исполнения не прошёл
+	ExecutionPaid     = "paid"     // Оплачен полностью
+	ExecutionOverpaid = "overpaid" // Оплачено больше суммы контракта
+	ExecutionOverdue  = "overdue"  // Срок исполнения прошёл, а контракт оплачен не полностью
+)
+
+// ExecutionStatuses — допустимые значения фильтра по статусу исполнения
+var ExecutionStatuses = []string{ExecutionNotPaid, ExecutionPartial, ExecutionPaid, ExecutionOverpaid, ExecutionOverdue}
+
+// ExecutionTitles — названия статусов исполнения для реестра
+var ExecutionTitles = map[string]string{
+	ExecutionNotPaid:  "Не оплачен",
+	ExecutionPartial:  "Оплачен частично",
+	ExecutionPaid:     "Оплачен",
+	ExecutionOverpaid: "Переплата",
+	ExecutionOverdue:  "Просрочен",
+}
+
+// executionJoin — сумма, количество и дата последнего из платежей, относящихся к контракту, по дату $1.
+// Распределённые платежи учитываются по распределению (представление contract_payment_amounts)
+const executionJoin = `LEFT JOIN LATERAL (
+		SELECT SUM(p.amount) AS paid, COUNT(*) AS payments, MAX(t.date) AS last_payment_date
+		FROM contract_payment_amounts p
+		JOIN transactions t ON t.id = p.transaction_id
+		WHERE p.contract_uid = c.uid AND t.date <= $1::date
+	) ex ON TRUE`
+
+// executionPaidSQL — оплаченная сумма контракта в выборке с executionJoin
+const executionPaidSQL = `COALESCE(ex.paid, 0)`
+
+// executionConditions — условия отбора контрактов по статусу исполнения; должны совпадать с executionStatus
+var executionConditions = map[string]string{
+	ExecutionNotPaid:  executionPaidSQL + " = 0 AND " + effectiveAmountSQL + " > 0 AND " + effectiveExecutionPeriodSQL + " >= $1::date",
+	ExecutionPartial:  executionPaidSQL + " > 0 AND " + executionPaidSQL + " < " + effectiveAmountSQL + " AND " + effectiveExecutionPeriodSQL + " >= $1::date",
+	ExecutionPaid:     executionPaidSQL + " = " + effectiveAmountSQL,
+	ExecutionOverpaid: executionPaidSQL + " > " + effectiveAmountSQL,
+	ExecutionOverdue:  executionPaidSQL + " < " + effectiveAmountSQL + " AND " + effectiveExecutionPeriodSQL + " < $1::date",
+}
+
+// IsExecutionStatus сообщает, является ли значение допустимым статусом исполнения
+func IsExecutionStatus(status string) bool {
+	_, ok := executionConditions[status]
+	return ok
+}
+
+// fillExecution рассчитывает показатели исполнения контракта на дату today
+func fillExecution(ct *models.Contract, paid float64, payments int, lastPaymentDate string, today time.Time) {
+	amountCents := math.Round(ct.Amount * 100)
+	paidCents := math.Round(paid * 100)
+
+	ex := models.ContractExecution{
+		Paid:            paidCents / 100,
+		Remaining:       max(amountCents-paidCents, 0) / 100,
+		Overpaid:        max(paidCents-amountCents, 0) / 100,
+		PaymentCount:    payments,
+		LastPaymentDate: lastPaymentDate,
+	}
+	switch {
+	case amountCents > 0:
+		ex.PercentExecuted = math.Round(paidCents/amountCents*10000) / 100
+	case paidCents > 0:
+		ex.PercentExecuted = 100
+	}
+
+	// Даты в формате ГГГГ-ММ-ДД сравниваются как строки
+	expired := ct.ExecutionPeriod != "" && ct.ExecutionPeriod < today.Format(time.DateOnly)
+	ex.IsOverpaid = paidCents > amountCents
+	ex.IsOverdue = expired && paidCents < amountCents
+	ex.Status = executionStatus(amountCents, paidCents, expired)
+	ct.Execution = ex
+}
+
+// executionStatus определяет статус исполнения по суммам в копейках и истечению срока исполнения
+func executionStatus(amountCents, paidCents float64, expired bool) string {
+	switch {
+	case paidCents > amountCents:
+		return ExecutionOverpaid
+	case paidCents == amountCents:
+		return ExecutionPaid
+	case expired:
+		return ExecutionOverdue
+	case paidCents > 0:
+		return ExecutionPartial
+	default:
+		return ExecutionNotPaid
+	}
+}
@@ -0,0 +1,105 @@
+package contracts
+
+import (
+	"statements/internal/models"
+	"testing"
+	"time"
+)
+
+func TestFillExecution(t *testing.T) {
+	today := time.Date(2024, 6, 15, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		amount float64
+		paid   float64
+		period string
+		want   models.ContractExecution
+	}{
+		{
+			name: "нулевая сумма без платежей считается оплаченной", amount: 0, paid: 0, period: "2024-12-31",
+			want: models.ContractExecution{Status: ExecutionPaid},
+		},
+		{
+			name: "платёж по контракту с нулевой суммой — переплата", amount: 0, paid: 10, period: "2024-12-31",
+			want: models.ContractExecution{Paid: 10, Overpaid: 10, PercentExecuted: 100, Status: ExecutionOverpaid, IsOverpaid: true},
+		},
+		{
+			name: "нет платежей", amount: 1000, paid: 0, period: "2024-12-31",
+			want: models.ContractExecution{Remaining: 1000, Status: ExecutionNotPaid},
+		},
+		{
+			name: "частичная оплата", amount: 1000, paid: 250, period: "2024-12-31",
+			want: models.ContractExecution{Paid: 250, Remaining: 750, PercentExecuted: 25, Status: ExecutionPartial},
+		},
+		{
+			name: "точная оплата", amount: 1000, paid: 1000, period: "2024-12-31",
+			want: models.ContractExecution{Paid: 1000, PercentExecuted: 100, Status: ExecutionPaid},
+		},
+		{
+			name: "точная оплата после срока не просрочка", amount: 1000, paid: 1000, period: "2024-01-31",
+			want: models.ContractExecution{Paid: 1000, PercentExecuted: 100, Status: ExecutionPaid},
+		},
+		{
+			name: "переплата на копейку", amount: 1000, paid: 1000.01, period: "2024-12-31",
+			want: models.ContractExecution{Paid: 1000.01, Overpaid: 0.01, PercentExecuted: 100, Status: ExecutionOverpaid, IsOverpaid: true},
+		},
+		{
+			name: "переплата после срока", amount: 1000, paid: 1500, period: "2024-01-31",
+			want: models.ContractExecution{Paid: 1500, Overpaid: 500, PercentExecuted: 150, Status: ExecutionOverpaid, IsOverpaid: true},
+		},
+		{
+			name: "срок прошёл, не хватает копейки", amount: 1000, paid: 999.99, period: "2024-06-14",
+			want: models.ContractExecution{Paid: 999.99, Remaining: 0.01, PercentExecuted: 100, Status: ExecutionOverdue, IsOverdue: true},
+		},
+		{
+			name: "срок прошёл, платежей нет", amount: 1000, paid: 0, period: "2024-06-14",
+			want: models.ContractExecution{Remaining: 1000, Status: ExecutionOverdue, IsOverdue: true},
+		},
+		{
+			// Срок исполнения включает сам день
+			name: "срок истекает сегодня", amount: 1000, paid: 400, period: "2024-06-15",
+			want: models.ContractExecution{Paid: 400, Remaining: 600, PercentExecuted: 40, Status: ExecutionPartial},
+		},
+		{
+			// 0.1 + 0.2 = 0.30000000000000004: суммы сравниваются в копейках
+			name: "сумма платежей с погрешностью float", amount: 0.3, paid: 0.1 + 0.2, period: "2024-12-31",
+			want: models.ContractExecution{Paid: 0.3, PercentExecuted: 100, Status: ExecutionPaid},
+		},
+		{
+			name: "доли копейки округляются до оплаты", amount: 100, paid: 100.004, period: "2024-06-14",
+			want: models.ContractExecution{Paid: 100, PercentExecuted: 100, Status: ExecutionPaid},
+		},
+		{
+			name: "доли копейки округляются до недоплаты", amount: 100, paid: 99.994, period: "2024-06-14",
+			want: models.ContractExecution{Paid: 99.99, Remaining: 0.01, PercentExecuted: 99.99, Status: ExecutionOverdue, IsOverdue: true},
+		},
+		{
+			name: "процент округляется до сотых", amount: 3, paid: 1, period: "2024-12-31",
+			want: models.ContractExecution{Paid: 1, Remaining: 2, PercentExecuted: 33.33, Status: ExecutionPartial},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := models.Contract{Amount: tt.amount, ExecutionPeriod: tt.period}
+			fillExecution(&ct, tt.paid, 2, "2024-05-20", today)
+
+			want := tt.want
+			want.PaymentCount, want.LastPaymentDate = 2, "2024-05-20"
+			if ct.Execution != want {
+				t.Errorf("fillExecution() =\n%+v\nожидалось\n%+v", ct.Execution, want)
+			}
+		})
+	}
+}
+
+// Каждому статусу исполнения соответствуют условие отбора в реестре и название
+func TestExecutionStatuses(t *testing.T) {
+	if len(executionConditions) != len(ExecutionStatuses) || len(ExecutionTitles) != len(ExecutionStatuses) {
+		t.Fatalf("статусов %d, условий %d, названий %d", len(ExecutionStatuses), len(executionConditions), len(ExecutionTitles))
+	}
+	for _, status := range ExecutionStatuses {
+		if !IsExecutionStatus(status) || ExecutionTitles[status] == "" {
+			t.Errorf("для статуса %q нет условия отбора или названия", status)
+		}
+	}
+}
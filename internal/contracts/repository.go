@@ -0,0 +1,410 @@
+package contracts
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"statements/internal/database"
+	"statements/internal/models"
+	"strings"
+	"time"
+)
+
+// contractColumns — список колонок контракта в порядке, ожидаемом scanContract
+const contractColumns = `c.uid::text, c.counterparty_id, cp.name, cp.inn,
+	c.contract_number, c.contract_date::text, ` + effectiveExecutionPeriodSQL + `::text, ` + effectiveAmountSQL + `::float8,
+	COALESCE(c.eaist_registry_number, ''), COALESCE(tm.payment_days, c.payment_days),
+	COALESCE(COALESCE(tm.validity_period, c.validity_period)::text, ''),
+	COALESCE(tm.subject, c.subject), c.contract_type, COALESCE(c.work_type, ''), COALESCE(c.conclusion_basis, ''),
+	COALESCE(c.procurement_type, ''), COALESCE(c.initiator, ''), COALESCE(c.eaist_status::text, ''),
+	COALESCE(c.eaist_link, ''), c.created_at::text, c.updated_at::text,
+	COALESCE((SELECT s.status FROM contract_signature_checks s WHERE s.contract_uid = c.uid ORDER BY s.id DESC LIMIT 1), ''),
+	` + executionPaidSQL + `::float8, ex.payments, COALESCE(ex.last_payment_date::text, ''),
+	$1::date::text, tm.amendments, c.amount::float8, c.execution_period::text, COALESCE(c.validity_period::text, ''),
+	c.subject, c.payment_days`
+
+// contractFrom — источник выборки контрактов с наименованием контрагента, условиями с учётом дополнительных
+// соглашений и итогами платежей, без удалённых записей. $1 — дата, на которую рассчитывается состояние контракта
+const contractFrom = `FROM contracts c
+	JOIN counterparties cp ON cp.id = c.counterparty_id
+	` + termsJoin + `
+	` + executionJoin + `
+	WHERE c.deleted_at IS NULL`
+
+// EaistStatuses — допустимые значения статуса ЕАИСТ (тип eaist_status_enum)
+var EaistStatuses = []string{EaistActive, EaistCompleted, EaistCancelled}
+
+// EaistStatusNone — значение фильтра для контрактов без статуса ЕАИСТ
+const EaistStatusNone = "none"
+
+// sortColumns сопоставляет допустимые значения параметра сортировки колонкам запроса
+var sortColumns = map[string]string{
+	"contract_date":     "c.contract_date",
+	"contract_number":   "c.contract_number",
+	"amount":            effectiveAmountSQL,
+	"execution_period":  effectiveExecutionPeriodSQL,
+	"counterparty_name": "cp.name",
+	"created_at":        "c.created_at",
+	"updated_at":        "c.updated_at",
+	"paid":              executionPaidSQL,
+	"remaining":         effectiveAmountSQL + " - " + executionPaidSQL,
+}
+
+var (
+	// ErrNotFound возвращается, если контракт не найден или удалён
+	ErrNotFound = errors.New("контракт не найден")
+	// ErrDuplicate возвращается, если контракт с таким номером и датой уже существует
+	ErrDuplicate = errors.New("контракт с таким номером и датой уже существует")
+	// ErrCounterpartyNotFound возвращается, если указан несуществующий контрагент
+	ErrCounterpartyNotFound = errors.New("контрагент не найден")
+	// ErrInvalidSort возвращается при сортировке по неизвестному полю
+	ErrInvalidSort = errors.New("недопустимое поле сортировки")
+	// ErrInvalidExecutionStatus возвращается при отборе по неизвестному статусу исполнения
+	ErrInvalidExecutionStatus = errors.New("недопустимый статус исполнения")
+)
+
+// ListFilter описывает параметры отбора, сортировки и постраничного вывода контрактов
+type ListFilter struct {
+	CounterpartyID int
+	DateFrom       string // Дата заключения не раньше, ГГГГ-ММ-ДД
+	DateTo         string // Дата заключения не позже, ГГГГ-ММ-ДД
+	ContractType   string
+	EaistStatus    string    // Статус ЕАИСТ из EaistStatuses или EaistStatusNone
+	Query          string    // Часть номера контракта или реестрового номера ЕАИСТ
+	Execution      string    // Статус исполнения из ExecutionStatuses
+	AsOf           time.Time // Дата, на которую показываются условия и исполнение; нулевая — текущая
+	Sort           string    // Поле сортировки из sortColumns
+	Desc           bool
+	Limit          int // 0 — без ограничения
+	Offset         int
+}
+
+// IsEaistStatus сообщает, является ли значение допустимым статусом ЕАИСТ
+func IsEaistStatus(status string) bool {
+	for _, s := range EaistStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// List возвращает страницу контрактов по фильтру и общее количество найденных записей
+func List(ctx context.Context, db database.DBTX, filter ListFilter) ([]models.Contract, int, error) {
+	orderBy := "c.contract_date"
+	if filter.Sort != "" {
+		column, ok := sortColumns[filter.Sort]
+		if !ok {
+			return nil, 0, ErrInvalidSort
+		}
+		orderBy = column
+	}
+	direction := "ASC"
+	if filter.Desc {
+		direction = "DESC"
+	}
+
+	where := ""
+	args := []interface{}{asOfArg(filter.AsOf)}
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		where += fmt.Sprintf(" AND "+condition, len(args))
+	}
+	if filter.CounterpartyID > 0 {
+		addCondition("c.counterparty_id = $%d", filter.CounterpartyID)
+	}
+	if filter.DateFrom != "" {
+		addCondition("c.contract_date >= $%d::date", filter.DateFrom)
+	}
+	if filter.DateTo != "" {
+		addCondition("c.contract_date <= $%d::date", filter.DateTo)
+	}
+	if filter.ContractType != "" {
+		addCondition("c.contract_type = $%d", filter.ContractType)
+	}
+	switch filter.EaistStatus {
+	case "":
+	case EaistStatusNone:
+		where += " AND c.eaist_status IS NULL"
+	default:
+		addCondition("c.eaist_status::text = $%d", filter.EaistStatus)
+	}
+	if filter.Execution != "" {
+		condition, ok := executionConditions[filter.Execution]
+		if !ok {
+			return nil, 0, ErrInvalidExecutionStatus
+		}
+		where += " AND " + condition
+	}
+	if q := strings.TrimSpace(filter.Query); q != "" {
+		args = append(args, database.EscapeLike(q))
+		where += fmt.Sprintf(" AND (c.contract_number ILIKE '%%' || $%[1]d || '%%' OR c.eaist_registry_number LIKE $%[1]d || '%%')", len(args))
+	}
+
+	var total int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) "+contractFrom+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("ошибка подсчёта контрактов: %w", err)
+	}
+
+	query := fmt.Sprintf(
+		`SELECT %s
+		%s%s
+		ORDER BY %s %s, c.contract_number, c.uid
+		LIMIT $%d OFFSET $%d`,
+		contractColumns, contractFrom, where, orderBy, direction, len(args)+1, len(args)+2)
+	var limit interface{}
+	if filter.Limit > 0 {
+		limit = filter.Limit
+	}
+	rows, err := db.QueryContext(ctx, query, append(args, limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ошибка получения списка контрактов: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]models.Contract, 0)
+	for rows.Next() {
+		ct, err := scanContract(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("ошибка чтения контракта: %w", err)
+		}
+		items = append(items, ct)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("ошибка чтения списка контрактов: %w", err)
+	}
+
+	return items, total, nil
+}
+
+// Get возвращает контракт по публичному идентификатору в текущем состоянии
+func Get(ctx context.Context, db database.DBTX, id string) (models.Contract, error) {
+	return GetAsOf(ctx, db, id, time.Time{})
+}
+
+// GetAsOf возвращает контракт с условиями и исполнением на дату asOf; нулевая дата означает текущую
+func GetAsOf(ctx context.Context, db database.DBTX, id string, asOf time.Time) (models.Contract, error) {
+	if !IsID(id) {
+		return models.Contract{}, ErrNotFound
+	}
+	ct, err := scanContract(db.QueryRowContext(ctx,
+		"SELECT "+contractColumns+" "+contractFrom+" AND c.uid = $2", asOfArg(asOf), id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return ct, ErrNotFound
+	}
+	if err != nil {
+		return ct, fmt.Errorf("ошибка получения контракта %s: %w", id, err)
+	}
+	return ct, nil
+}
+
+// FindByNumber возвращает действующий контракт по номеру и дате заключения
+func FindByNumber(ctx context.Context, db database.DBTX, number, date string) (models.Contract, error) {
+	ct, err := scanContract(db.QueryRowContext(ctx,
+		"SELECT "+contractColumns+" "+contractFrom+" AND c.contract_number = $2 AND c.contract_date = $3::date",
+		asOfArg(time.Time{}), strings.TrimSpace(number), date))
+	if errors.Is(err, sql.ErrNoRows) {
+		return ct, ErrNotFound
+	}
+	if err != nil {
+		return ct, fmt.Errorf("ошибка поиска контракта %s от %s: %w", number, date, err)
+	}
+	return ct, nil
+}
+
+// FindByRegistryNumber возвращает действующий контракт по реестровому номеру ЕАИСТ
+func FindByRegistryNumber(ctx context.Context, db database.DBTX, number string) (models.Contract, error) {
+	ct, err := scanContract(db.QueryRowContext(ctx,
+		"SELECT "+contractColumns+" "+contractFrom+" AND c.eaist_registry_number = $2 ORDER BY c.contract_date DESC LIMIT 1",
+		asOfArg(time.Time{}), strings.TrimSpace(number)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return ct, ErrNotFound
+	}
+	if err != nil {
+		return ct, fmt.Errorf("ошибка поиска контракта с реестровым номером %s: %w", number, err)
+	}
+	return ct, nil
+}
+
+// NewID генерирует публичный идентификатор контракта (UUID версии 4).
+// Нужен, когда идентификатор требуется до вставки записи, например для каталога с файлами
+func NewID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("ошибка генерации идентификатора контракта: %w", err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// Create сохраняет новый контракт и возвращает его публичный идентификатор.
+// Если ct.ID задан, контракт сохраняется с ним, иначе идентификатор генерирует база.
+// Начальный статус ЕАИСТ записывается в историю статусов от имени createdBy
+func Create(ctx context.Context, db database.DBTX, ct models.Contract, createdBy string) (string, error) {
+	if err := ensurePartition(ctx, db, ct.ContractDate); err != nil {
+		return "", err
+	}
+
+	var id string
+	err := db.QueryRowContext(ctx,
+		`INSERT INTO contracts
+			(counterparty_id, contract_number, contract_date, execution_period, amount, eaist_registry_number,
+			 payment_days, validity_period, subject, contract_type, work_type, conclusion_basis,
+			 procurement_type, initiator, eaist_status, eaist_link, uid)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, NULLIF($8, '')::date, $9, $10, NULLIF($11, ''),
+		        NULLIF($12, ''), NULLIF($13, ''), NULLIF($14, ''), NULLIF($15, '')::eaist_status_enum, NULLIF($16, ''),
+		        COALESCE(NULLIF($17, '')::uuid, gen_random_uuid()))
+		RETURNING uid::text`,
+		ct.CounterpartyID, ct.ContractNumber, ct.ContractDate, ct.ExecutionPeriod, ct.Amount, ct.EaistRegistryNumber,
+		ct.PaymentDays, ct.ValidityPeriod, ct.Subject, ct.ContractType, ct.WorkType, ct.ConclusionBasis,
+		ct.ProcurementType, ct.Initiator, ct.EaistStatus, ct.EaistLink, ct.ID).Scan(&id)
+	if err != nil {
+		return "", translateError(err, "ошибка создания контракта")
+	}
+	if ct.EaistStatus != "" {
+		if err := recordEaistStatus(ctx, db, id, "", ct.EaistStatus, "Статус указан при создании контракта", createdBy); err != nil {
+			return "", err
+		}
+	}
+	return id, nil
+}
+
+// Update изменяет реквизиты и первоначальные условия контракта. Номер и дата тоже могут меняться — публичный идентификатор при этом сохраняется.
+// Статус ЕАИСТ не меняется: для этого служит ChangeEaistStatus
+func Update(ctx context.Context, db database.DBTX, ct models.Contract) error {
+	if !IsID(ct.ID) {
+		return ErrNotFound
+	}
+	if err := ensurePartition(ctx, db, ct.ContractDate); err != nil {
+		return err
+	}
+
+	res, err := db.ExecContext(ctx,
+		`UPDATE contracts
+		SET counterparty_id = $2, contract_number = $3, contract_date = $4, execution_period = $5, amount = $6,
+		    eaist_registry_number = NULLIF($7, ''), payment_days = $8, validity_period = NULLIF($9, '')::date,
+		    subject = $10, contract_type = $11, work_type = NULLIF($12, ''), conclusion_basis = NULLIF($13, ''),
+		    procurement_type = NULLIF($14, ''), initiator = NULLIF($15, ''), eaist_link = NULLIF($16, ''),
+		    updated_at = now()
+		WHERE uid = $1 AND deleted_at IS NULL`,
+		ct.ID, ct.CounterpartyID, ct.ContractNumber, ct.ContractDate, ct.ExecutionPeriod, ct.Amount,
+		ct.EaistRegistryNumber, ct.PaymentDays, ct.ValidityPeriod, ct.Subject, ct.ContractType, ct.WorkType,
+		ct.ConclusionBasis, ct.ProcurementType, ct.Initiator, ct.EaistLink)
+	if err != nil {
+		return translateError(err, "ошибка обновления контракта "+ct.ID)
+	}
+	return checkAffected(res)
+}
+
+// Delete помечает контракт удалённым. Запись остаётся в базе, чтобы не терять связанные платежи и документы
+func Delete(ctx context.Context, db database.DBTX, id, deletedBy string) error {
+	if !IsID(id) {
+		return ErrNotFound
+	}
+	res, err := db.ExecContext(ctx,
+		`UPDATE contracts
+		SET deleted_at = now(), deleted_by = NULLIF($2, ''), updated_at = now()
+		WHERE uid = $1 AND deleted_at IS NULL`, id, deletedBy)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления контракта %s: %w", id, err)
+	}
+	return checkAffected(res)
+}
+
+// IsID проверяет, что строка похожа на публичный идентификатор контракта (UUID)
+func IsID(id string) bool {
+	if len(id) != 36 {
+		return false
+	}
+	for i, r := range id {
+		switch i {
+		case 8, 13, 18, 23:
+			if r != '-' {
+				return false
+			}
+		default:
+			if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// scanContract читает контракт из строки, выбранной по contractColumns
+func scanContract(row database.RowScanner) (models.Contract, error) {
+	var ct models.Contract
+	var paymentDays, initialPaymentDays sql.NullInt64
+	var paid float64
+	var payments int
+	var lastPaymentDate string
+	err := row.Scan(&ct.ID, &ct.CounterpartyID, &ct.CounterpartyName, &ct.CounterpartyInn,
+		&ct.ContractNumber, &ct.ContractDate, &ct.ExecutionPeriod, &ct.Amount,
+		&ct.EaistRegistryNumber, &paymentDays, &ct.ValidityPeriod,
+		&ct.Subject, &ct.ContractType, &ct.WorkType, &ct.ConclusionBasis,
+		&ct.ProcurementType, &ct.Initiator, &ct.EaistStatus,
+		&ct.EaistLink, &ct.CreatedAt, &ct.UpdatedAt, &ct.SignatureStatus,
+		&paid, &payments, &lastPaymentDate,
+		&ct.AsOf, &ct.AmendmentCount, &ct.Initial.Amount, &ct.Initial.ExecutionPeriod, &ct.Initial.ValidityPeriod,
+		&ct.Initial.Subject, &initialPaymentDays)
+	asOf, parseErr := time.Parse(time.DateOnly, ct.AsOf)
+	if parseErr != nil {
+		asOf = time.Now()
+	}
+	fillExecution(&ct, paid, payments, lastPaymentDate, asOf)
+	ct.PaymentDays = nullableInt(paymentDays)
+	ct.Initial.PaymentDays = nullableInt(initialPaymentDays)
+	return ct, err
+}
+
+// nullableInt преобразует NULL в nil
+func nullableInt(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+	n := int(v.Int64)
+	return &n
+}
+
+// asOfArg возвращает дату состояния контракта для параметра $1; нулевая дата заменяется текущей
+func asOfArg(asOf time.Time) string {
+	if asOf.IsZero() {
+		asOf = time.Now()
+	}
+	return asOf.Format(time.DateOnly)
+}
+
+// ensurePartition создаёт партицию contracts для года даты контракта, если её ещё нет
+func ensurePartition(ctx context.Context, db database.DBTX, contractDate string) error {
+	date, err := time.Parse(time.DateOnly, contractDate)
+	if err != nil {
+		return fmt.Errorf("некорректная дата контракта %q: %w", contractDate, err)
+	}
+	return database.EnsureContractPartitionFor(ctx, db, date)
+}
+
+// translateError преобразует ошибки ограничений базы данных в ошибки пакета
+func translateError(err error, message string) error {
+	switch {
+	case database.IsUniqueViolation(err):
+		return ErrDuplicate
+	case database.IsForeignKeyViolation(err):
+		return ErrCounterpartyNotFound
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
+
+// checkAffected возвращает ErrNotFound, если запрос не затронул ни одной строки
+func checkAffected(res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,337 @@
+package contracts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"statements/internal/database"
+	"statements/internal/models"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Статусы этапа графика платежей
+const (
+	StagePlanned = "planned" // Срок оплаты не наступил, оплаты нет
+	StagePartial = "partial" // Оплачен частично, срок не прошёл
+	StagePaid    = "paid"    // Оплачен полностью
+	StageOverdue = "overdue" // Срок прошёл, а этап оплачен не полностью
+)
+
+// Периоды группировки прогноза выплат
+const (
+	ForecastWeek  = "week"
+	ForecastMonth = "month"
+)
+
+// maxStageTitleLength — максимальная длина описания этапа графика
+const maxStageTitleLength = 500
+
+// maxForecastDays — максимальная длина периода прогноза
+const maxForecastDays = 2 * 366
+
+var (
+	// ErrInvalidForecastPeriod возвращается для неизвестного периода группировки прогноза
+	ErrInvalidForecastPeriod = errors.New("недопустимый период группировки прогноза")
+	// ErrInvalidForecastRange возвращается, если конец периода прогноза раньше начала или период слишком длинный
+	ErrInvalidForecastRange = errors.New("недопустимый период прогноза")
+)
+
+// ForecastFilter описывает параметры прогноза выплат
+type ForecastFilter struct {
+	Period string    // ForecastWeek или ForecastMonth
+	From   time.Time // Начало прогноза; этапы с более ранней плановой датой считаются просроченными
+	To     time.Time // Конец прогноза включительно
+}
+
+// Validate проверяет период группировки и границы прогноза
+func (f ForecastFilter) Validate() error {
+	if f.Period != ForecastWeek && f.Period != ForecastMonth {
+		return ErrInvalidForecastPeriod
+	}
+	if f.To.Before(f.From) || f.To.Sub(f.From) > maxForecastDays*24*time.Hour {
+		return ErrInvalidForecastRange
+	}
+	return nil
+}
+
+// Schedule возвращает график платежей контракта с фактической оплатой по этапам
+func Schedule(ctx context.Context, db database.DBTX, id string) (models.PaymentSchedule, error) {
+	ct, err := Get(ctx, db, id)
+	if err != nil {
+		return models.PaymentSchedule{}, err
+	}
+
+	rows, err := db.QueryContext(ctx,
+		`SELECT stage_number, COALESCE(title, ''), planned_date::text, amount::float8
+		FROM contract_payment_stages
+		WHERE contract_uid = $1
+		ORDER BY planned_date, stage_number`, ct.ID)
+	if err != nil {
+		return models.PaymentSchedule{}, fmt.Errorf("ошибка получения графика платежей контракта %s: %w", id, err)
+	}
+	defer rows.Close()
+
+	stages := make([]models.PaymentStage, 0)
+	for rows.Next() {
+		var s models.PaymentStage
+		if err := rows.Scan(&s.Number, &s.Title, &s.PlannedDate, &s.Amount); err != nil {
+			return models.PaymentSchedule{}, fmt.Errorf("ошибка чтения этапа графика: %w", err)
+		}
+		stages = append(stages, s)
+	}
+	if err := rows.Err(); err != nil {
+		return models.PaymentSchedule{}, fmt.Errorf("ошибка чтения графика платежей контракта %s: %w", id, err)
+	}
+
+	applyPayments(stages, ct.Execution.Paid, time.Now())
+	var plannedCents float64
+	for _, s := range stages {
+		plannedCents += math.Round(s.Amount * 100)
+	}
+	return models.PaymentSchedule{
+		ContractID:     ct.ID,
+		ContractAmount: ct.Amount,
+		Planned:        plannedCents / 100,
+		Unscheduled:    max(math.Round(ct.Amount*100)-plannedCents, 0) / 100,
+		Paid:           ct.Execution.Paid,
+		Stages:         stages,
+	}, nil
+}
+
+// SaveSchedule заменяет график платежей контракта. Этапы нумеруются по возрастанию плановой даты,
+// сумма этапов не может превышать действующую сумму контракта. Вызывать нужно в транзакции;
+// ошибки полей возвращаются как *ValidationError
+func SaveSchedule(ctx context.Context, db database.DBTX, id string, stages []models.PaymentStage, createdBy string) (models.PaymentSchedule, error) {
+	ct, err := Get(ctx, db, id)
+	if err != nil {
+		return models.PaymentSchedule{}, err
+	}
+	// Блокировка контракта исключает параллельную замену графика
+	if _, err := db.ExecContext(ctx, `SELECT 1 FROM contracts WHERE uid = $1 FOR UPDATE`, ct.ID); err != nil {
+		return models.PaymentSchedule{}, fmt.Errorf("ошибка блокировки контракта %s: %w", ct.ID, err)
+	}
+
+	if err := validateStages(stages, ct); err != nil {
+		return models.PaymentSchedule{}, err
+	}
+	sort.SliceStable(stages, func(i, j int) bool { return stages[i].PlannedDate < stages[j].PlannedDate })
+
+	if _, err := db.ExecContext(ctx, `DELETE FROM contract_payment_stages WHERE contract_uid = $1`, ct.ID); err != nil {
+		return models.PaymentSchedule{}, fmt.Errorf("ошибка замены графика платежей контракта %s: %w", ct.ID, err)
+	}
+	for i, s := range stages {
+		_, err := db.ExecContext(ctx,
+			`INSERT INTO contract_payment_stages (contract_uid, stage_number, title, planned_date, amount, created_by)
+			VALUES ($1, $2, NULLIF($3, ''), $4, $5, NULLIF($6, ''))`,
+			ct.ID, i+1, s.Title, s.PlannedDate, math.Round(s.Amount*100)/100, createdBy)
+		if err != nil {
+			return models.PaymentSchedule{}, fmt.Errorf("ошибка сохранения этапа графика контракта %s: %w", ct.ID, err)
+		}
+	}
+
+	return Schedule(ctx, db, ct.ID)
+}
+
+// validateStages проверяет этапы графика платежей контракта ct и обрезает пробелы в описаниях
+func validateStages(stages []models.PaymentStage, ct models.Contract) error {
+	verr := &ValidationError{}
+	signed, _ := time.Parse(time.DateOnly, ct.ContractDate)
+	var totalCents float64
+	for i := range stages {
+		s := &stages[i]
+		field := func(name string) string { return fmt.Sprintf("stages[%d].%s", i, name) }
+
+		s.Title = strings.TrimSpace(s.Title)
+		s.PlannedDate = strings.TrimSpace(s.PlannedDate)
+		if utf8.RuneCountInString(s.Title) > maxStageTitleLength {
+			verr.Add(field("title"), fmt.Sprintf("Описание этапа не может быть длиннее %d символов", maxStageTitleLength))
+		}
+		if date, ok := parseDateField(verr, field("planned_date"), s.PlannedDate, true); ok && date.Before(signed) {
+			verr.Add(field("planned_date"), "Плановая дата не может быть раньше даты заключения контракта")
+		}
+		switch {
+		case math.IsNaN(s.Amount) || math.IsInf(s.Amount, 0) || s.Amount <= 0:
+			verr.Add(field("amount"), "Сумма этапа должна быть больше нуля")
+		case s.Amount >= maxAmount:
+			verr.Add(field("amount"), "Сумма этапа слишком велика")
+		case math.Abs(s.Amount*100-math.Round(s.Amount*100)) > 1e-6:
+			verr.Add(field("amount"), "Сумма указывается с точностью до копеек")
+		default:
+			totalCents += math.Round(s.Amount * 100)
+		}
+	}
+	if amountCents := math.Round(ct.Amount * 100); totalCents > amountCents {
+		verr.Add("stages", fmt.Sprintf("Сумма этапов %.2f превышает сумму контракта %.2f", totalCents/100, amountCents/100))
+	}
+	return verr.Err()
+}
+
+// applyPayments относит оплаченную сумму на этапы по порядку и рассчитывает их статусы на дату today.
+// Этапы должны быть упорядочены по плановой дате
+func applyPayments(stages []models.PaymentStage, paid float64, today time.Time) {
+	rest := math.Round(paid * 100)
+	todayDate := today.Format(time.DateOnly)
+	for i := range stages {
+		s := &stages[i]
+		amountCents := math.Round(s.Amount * 100)
+		paidCents := min(amountCents, max(rest, 0))
+		rest -= paidCents
+
+		s.Paid = paidCents / 100
+		s.Remaining = (amountCents - paidCents) / 100
+		// Даты в формате ГГГГ-ММ-ДД сравниваются как строки
+		switch {
+		case paidCents == amountCents:
+			s.Status = StagePaid
+		case s.PlannedDate < todayDate:
+			s.Status = StageOverdue
+		case paidCents > 0:
+			s.Status = StagePartial
+		default:
+			s.Status = StagePlanned
+		}
+	}
+}
+
+// Forecast возвращает прогноз выплат по графикам платежей действующих контрактов с группировкой по неделям
+// или месяцам. Неоплаченные этапы с плановой датой раньше начала прогноза относятся на первый период
+func Forecast(ctx context.Context, db database.DBTX, filter ForecastFilter) (models.PaymentForecast, error) {
+	if err := filter.Validate(); err != nil {
+		return models.PaymentForecast{}, err
+	}
+
+	builder := newForecastBuilder(filter)
+
+	// Завершённые и аннулированные в ЕАИСТ контракты в прогноз не входят
+	rows, err := db.QueryContext(ctx,
+		`SELECT c.uid::text, c.contract_number, c.contract_date::text, cp.name,
+		        s.stage_number, COALESCE(s.title, ''), s.planned_date::text, s.amount::float8, COALESCE(pay.paid, 0)::float8
+		FROM contract_payment_stages s
+		JOIN contracts c ON c.uid = s.contract_uid AND c.deleted_at IS NULL
+		JOIN counterparties cp ON cp.id = c.counterparty_id
+		LEFT JOIN LATERAL (
+			SELECT SUM(p.amount) AS paid FROM contract_payment_amounts p WHERE p.contract_uid = c.uid
+		) pay ON TRUE
+		WHERE COALESCE(c.eaist_status::text, '') NOT IN ('Завершен', 'Аннулирован')
+		  AND s.planned_date <= $1::date
+		ORDER BY c.uid, s.planned_date, s.stage_number`, builder.forecast.To)
+	if err != nil {
+		return models.PaymentForecast{}, fmt.Errorf("ошибка получения графиков платежей: %w", err)
+	}
+	defer rows.Close()
+
+	var items []models.ForecastItem
+	var stages []models.PaymentStage
+	var paid float64
+	// flush передаёт в прогноз этапы очередного контракта
+	flush := func() {
+		builder.add(items, stages, paid)
+		items, stages = items[:0], stages[:0]
+	}
+	for rows.Next() {
+		var item models.ForecastItem
+		var stage models.PaymentStage
+		var contractPaid float64
+		if err := rows.Scan(&item.ContractID, &item.ContractNumber, &item.ContractDate, &item.CounterpartyName,
+			&item.StageNumber, &item.StageTitle, &item.PlannedDate, &stage.Amount, &contractPaid); err != nil {
+			return models.PaymentForecast{}, fmt.Errorf("ошибка чтения этапа графика: %w", err)
+		}
+		if len(items) > 0 && items[0].ContractID != item.ContractID {
+			flush()
+		}
+		stage.PlannedDate = item.PlannedDate
+		items, stages, paid = append(items, item), append(stages, stage), contractPaid
+	}
+	if err := rows.Err(); err != nil {
+		return models.PaymentForecast{}, fmt.Errorf("ошибка чтения графиков платежей: %w", err)
+	}
+	flush()
+	return builder.result(), nil
+}
+
+// forecastBuilder распределяет неоплаченные части этапов по периодам прогноза
+type forecastBuilder struct {
+	filter   ForecastFilter
+	forecast models.PaymentForecast
+	index    map[string]int // Начало периода → индекс в forecast.Buckets
+}
+
+// newForecastBuilder создаёт пустые периоды прогноза от периода, в который попадает filter.From, до filter.To
+func newForecastBuilder(filter ForecastFilter) *forecastBuilder {
+	b := &forecastBuilder{
+		filter: filter,
+		forecast: models.PaymentForecast{
+			Period:  filter.Period,
+			From:    filter.From.Format(time.DateOnly),
+			To:      filter.To.Format(time.DateOnly),
+			Buckets: make([]models.ForecastBucket, 0),
+		},
+		index: make(map[string]int),
+	}
+	for start := periodStart(filter.From, filter.Period); !start.After(filter.To); start = nextPeriod(start, filter.Period) {
+		b.index[start.Format(time.DateOnly)] = len(b.forecast.Buckets)
+		b.forecast.Buckets = append(b.forecast.Buckets, models.ForecastBucket{
+			Start: start.Format(time.DateOnly),
+			End:   nextPeriod(start, filter.Period).AddDate(0, 0, -1).Format(time.DateOnly),
+			Items: make([]models.ForecastItem, 0),
+		})
+	}
+	return b
+}
+
+// add относит неоплаченные части этапов одного контракта на периоды прогноза. items соответствуют stages,
+// этапы упорядочены по плановой дате и не позже конца прогноза, paid — оплаченная сумма контракта.
+// Просроченные на начало прогноза этапы относятся на первый период
+func (b *forecastBuilder) add(items []models.ForecastItem, stages []models.PaymentStage, paid float64) {
+	applyPayments(stages, paid, b.filter.From)
+	for i, s := range stages {
+		if s.Remaining <= 0 {
+			continue
+		}
+		item := items[i]
+		item.Amount = s.Remaining
+		date, _ := time.Parse(time.DateOnly, s.PlannedDate)
+		if date.Before(b.filter.From) {
+			item.Overdue = true
+			date = b.filter.From
+		}
+		bucket := &b.forecast.Buckets[b.index[periodStart(date, b.filter.Period).Format(time.DateOnly)]]
+		bucket.Items = append(bucket.Items, item)
+		bucket.Amount = math.Round((bucket.Amount+item.Amount)*100) / 100
+		b.forecast.Total = math.Round((b.forecast.Total+item.Amount)*100) / 100
+		if item.Overdue {
+			bucket.Overdue = math.Round((bucket.Overdue+item.Amount)*100) / 100
+			b.forecast.Overdue = math.Round((b.forecast.Overdue+item.Amount)*100) / 100
+		}
+	}
+}
+
+// result возвращает прогноз; этапы внутри периода упорядочены по плановой дате
+func (b *forecastBuilder) result() models.PaymentForecast {
+	for i := range b.forecast.Buckets {
+		bucket := &b.forecast.Buckets[i]
+		sort.SliceStable(bucket.Items, func(i, j int) bool { return bucket.Items[i].PlannedDate < bucket.Items[j].PlannedDate })
+	}
+	return b.forecast
+}
+
+// periodStart возвращает начало недели (понедельник) или месяца, в который попадает дата
+func periodStart(date time.Time, period string) time.Time {
+	date = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	if period == ForecastMonth {
+		return date.AddDate(0, 0, 1-date.Day())
+	}
+	return date.AddDate(0, 0, -(int(date.Weekday())+6)%7)
+}
+
+// nextPeriod возвращает начало следующей недели или месяца после начала периода start
+func nextPeriod(start time.Time, period string) time.Time {
+	if period == ForecastMonth {
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 7)
+}
@@ -0,0 +1,183 @@
+package contracts
+
+import (
+	"reflect"
+	"statements/internal/models"
+	"testing"
+	"time"
+)
+
+func TestApplyPayments(t *testing.T) {
+	today := time.Date(2024, 7, 15, 0, 0, 0, 0, time.UTC)
+	stage := func(date string, amount, paid, remaining float64, status string) models.PaymentStage {
+		return models.PaymentStage{PlannedDate: date, Amount: amount, Paid: paid, Remaining: remaining, Status: status}
+	}
+	tests := []struct {
+		name string
+		paid float64
+		want []models.PaymentStage
+	}{
+		{"нет оплаты", 0, []models.PaymentStage{
+			stage("2024-03-31", 100, 0, 100, StageOverdue),
+			stage("2024-06-30", 200, 0, 200, StageOverdue),
+			stage("2024-07-15", 300, 0, 300, StagePlanned),
+		}},
+		// Оплата относится на этапы по порядку: сначала полностью закрывается первый
+		{"оплачен первый этап и часть второго", 150, []models.PaymentStage{
+			stage("2024-03-31", 100, 100, 0, StagePaid),
+			stage("2024-06-30", 200, 50, 150, StageOverdue),
+			stage("2024-07-15", 300, 0, 300, StagePlanned),
+		}},
+		{"частично оплачен этап со сроком сегодня", 350, []models.PaymentStage{
+			stage("2024-03-31", 100, 100, 0, StagePaid),
+			stage("2024-06-30", 200, 200, 0, StagePaid),
+			stage("2024-07-15", 300, 50, 250, StagePartial),
+		}},
+		{"переплата не переносится на этапы", 700, []models.PaymentStage{
+			stage("2024-03-31", 100, 100, 0, StagePaid),
+			stage("2024-06-30", 200, 200, 0, StagePaid),
+			stage("2024-07-15", 300, 300, 0, StagePaid),
+		}},
+		{"суммы сравниваются в копейках", 100 + 199.999, []models.PaymentStage{
+			stage("2024-03-31", 100, 100, 0, StagePaid),
+			stage("2024-06-30", 200, 200, 0, StagePaid),
+			stage("2024-07-15", 300, 0, 300, StagePlanned),
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stages := []models.PaymentStage{
+				{PlannedDate: "2024-03-31", Amount: 100},
+				{PlannedDate: "2024-06-30", Amount: 200},
+				{PlannedDate: "2024-07-15", Amount: 300},
+			}
+			applyPayments(stages, tt.paid, today)
+			if !reflect.DeepEqual(stages, tt.want) {
+				t.Errorf("applyPayments(%v) =\n%+v\nожидалось\n%+v", tt.paid, stages, tt.want)
+			}
+		})
+	}
+}
+
+func TestPeriodStart(t *testing.T) {
+	msk := time.FixedZone("MSK", 3*60*60)
+	tests := []struct {
+		date   time.Time
+		period string
+		want   string
+	}{
+		{time.Date(2024, 7, 17, 0, 0, 0, 0, time.UTC), ForecastWeek, "2024-07-15"},
+		{time.Date(2024, 7, 15, 0, 0, 0, 0, time.UTC), ForecastWeek, "2024-07-15"},
+		// Воскресенье относится к неделе, начавшейся в предыдущий понедельник
+		{time.Date(2024, 7, 21, 0, 0, 0, 0, time.UTC), ForecastWeek, "2024-07-15"},
+		// Берётся календарная дата в часовом поясе значения, время суток отбрасывается
+		{time.Date(2024, 7, 21, 23, 30, 0, 0, msk), ForecastWeek, "2024-07-15"},
+		{time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), ForecastWeek, "2024-12-30"},
+		{time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC), ForecastMonth, "2024-12-01"},
+		{time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC), ForecastMonth, "2024-02-01"},
+		{time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), ForecastMonth, "2025-01-01"},
+	}
+	for _, tt := range tests {
+		if got := periodStart(tt.date, tt.period).Format(time.DateOnly); got != tt.want {
+			t.Errorf("periodStart(%s, %s) = %s, ожидалось %s", tt.date, tt.period, got, tt.want)
+		}
+	}
+}
+
+func TestNextPeriod(t *testing.T) {
+	tests := []struct {
+		start  string
+		period string
+		want   string
+	}{
+		{"2024-07-15", ForecastWeek, "2024-07-22"},
+		{"2024-12-30", ForecastWeek, "2025-01-06"},
+		{"2024-01-01", ForecastMonth, "2024-02-01"},
+		{"2024-12-01", ForecastMonth, "2025-01-01"},
+	}
+	for _, tt := range tests {
+		start, _ := time.Parse(time.DateOnly, tt.start)
+		if got := nextPeriod(start, tt.period).Format(time.DateOnly); got != tt.want {
+			t.Errorf("nextPeriod(%s, %s) = %s, ожидалось %s", tt.start, tt.period, got, tt.want)
+		}
+	}
+}
+
+func TestForecastBuilder(t *testing.T) {
+	type stage struct {
+		number int
+		date   string
+		amount float64
+	}
+	// add принимает этапы одного контракта так же, как их передаёт Forecast
+	add := func(b *forecastBuilder, contractID string, paid float64, stages ...stage) {
+		var items []models.ForecastItem
+		var planned []models.PaymentStage
+		for _, s := range stages {
+			items = append(items, models.ForecastItem{ContractID: contractID, StageNumber: s.number, PlannedDate: s.date})
+			planned = append(planned, models.PaymentStage{PlannedDate: s.date, Amount: s.amount})
+		}
+		b.add(items, planned, paid)
+	}
+	item := func(contractID string, number int, date string, amount float64, overdue bool) models.ForecastItem {
+		return models.ForecastItem{ContractID: contractID, StageNumber: number, PlannedDate: date, Amount: amount, Overdue: overdue}
+	}
+
+	t.Run("по неделям", func(t *testing.T) {
+		// Прогноз со среды: первая неделя начинается с понедельника 23.12.2024
+		b := newForecastBuilder(ForecastFilter{
+			Period: ForecastWeek,
+			From:   time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC),
+			To:     time.Date(2025, 1, 8, 0, 0, 0, 0, time.UTC),
+		})
+		add(b, "1", 120, stage{1, "2024-11-30", 100}, stage{2, "2024-12-20", 50}, stage{3, "2025-01-02", 200})
+		add(b, "2", 0, stage{1, "2024-12-24", 10.1}, stage{2, "2025-01-08", 5})
+		add(b, "3", 100, stage{1, "2024-12-01", 100})
+
+		want := models.PaymentForecast{
+			Period: ForecastWeek, From: "2024-12-25", To: "2025-01-08", Total: 245.1, Overdue: 40.1,
+			Buckets: []models.ForecastBucket{
+				// Неоплаченные части этапов с датой до начала прогноза относятся на первый период
+				{Start: "2024-12-23", End: "2024-12-29", Amount: 40.1, Overdue: 40.1, Items: []models.ForecastItem{
+					item("1", 2, "2024-12-20", 30, true),
+					item("2", 1, "2024-12-24", 10.1, true),
+				}},
+				{Start: "2024-12-30", End: "2025-01-05", Amount: 200, Items: []models.ForecastItem{
+					item("1", 3, "2025-01-02", 200, false),
+				}},
+				{Start: "2025-01-06", End: "2025-01-12", Amount: 5, Items: []models.ForecastItem{
+					item("2", 2, "2025-01-08", 5, false),
+				}},
+			},
+		}
+		if got := b.result(); !reflect.DeepEqual(got, want) {
+			t.Errorf("прогноз =\n%+v\nожидалось\n%+v", got, want)
+		}
+	})
+
+	t.Run("по месяцам", func(t *testing.T) {
+		b := newForecastBuilder(ForecastFilter{
+			Period: ForecastMonth,
+			From:   time.Date(2024, 12, 15, 0, 0, 0, 0, time.UTC),
+			To:     time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC),
+		})
+		add(b, "1", 0, stage{1, "2024-10-01", 300}, stage{2, "2024-12-31", 100}, stage{3, "2025-02-10", 50})
+
+		want := models.PaymentForecast{
+			Period: ForecastMonth, From: "2024-12-15", To: "2025-02-10", Total: 450, Overdue: 300,
+			Buckets: []models.ForecastBucket{
+				{Start: "2024-12-01", End: "2024-12-31", Amount: 400, Overdue: 300, Items: []models.ForecastItem{
+					item("1", 1, "2024-10-01", 300, true),
+					item("1", 2, "2024-12-31", 100, false),
+				}},
+				{Start: "2025-01-01", End: "2025-01-31", Items: []models.ForecastItem{}},
+				{Start: "2025-02-01", End: "2025-02-28", Amount: 50, Items: []models.ForecastItem{
+					item("1", 3, "2025-02-10", 50, false),
+				}},
+			},
+		}
+		if got := b.result(); !reflect.DeepEqual(got, want) {
+			t.Errorf("прогноз =\n%+v\nожидалось\n%+v", got, want)
+		}
+	})
+}
@@ -0,0 +1,213 @@
+package contracts
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"statements/internal/database"
+	"statements/internal/models"
+	"statements/internal/signature"
+	"statements/internal/storage"
+	"time"
+)
+
+// maxSignatureSize — максимальный размер файла подписи, читаемого в память для проверки
+const maxSignatureSize = 10 << 20
+
+var (
+	// ErrNoSignature возвращается, если у контракта нет файла контракта или файла подписи
+	ErrNoSignature = errors.New("у контракта нет файла контракта или файла подписи")
+	// ErrSignatureNotChecked возвращается, если подпись контракта ещё не проверялась
+	ErrSignatureNotChecked = errors.New("подпись контракта не проверялась")
+)
+
+// signatureColumns — список колонок проверки подписи в порядке, ожидаемом scanSignatureCheck
+const signatureColumns = `id, contract_uid::text, signature_document_id, content_document_id, status,
+	COALESCE(message, ''), COALESCE(checked_by, ''), checked_at::text`
+
+// VerifySignature проверяет текущую версию файла подписи (ecp) относительно текущей версии файла контракта.
+// Результат не сохраняется — для этого служит SaveSignatureCheck
+func VerifySignature(ctx context.Context, db database.DBTX, store storage.BlobStore, id string) (models.SignatureCheck, error) {
+	docs, err := Documents(ctx, db, id)
+	if err != nil {
+		return models.SignatureCheck{}, err
+	}
+	var content, sig *models.ContractDocument
+	for i := range docs {
+		switch {
+		case docs[i].Type == "contract" && content == nil:
+			content = &docs[i]
+		case docs[i].Type == "ecp" && sig == nil:
+			sig = &docs[i]
+		}
+	}
+	if content == nil || sig == nil {
+		return models.SignatureCheck{}, ErrNoSignature
+	}
+
+	sigData, err := readObject(ctx, store, sig.Key, maxSignatureSize)
+	if err != nil {
+		return models.SignatureCheck{}, err
+	}
+	file, err := store.Get(ctx, content.Key)
+	if err != nil {
+		return models.SignatureCheck{}, fmt.Errorf("ошибка чтения файла контракта %s: %w", content.Key, err)
+	}
+	defer file.Close()
+
+	result, err := signature.Verify(sigData, file, time.Now())
+	if err != nil {
+		return models.SignatureCheck{}, err
+	}
+
+	check := models.SignatureCheck{
+		ContractID:          id,
+		SignatureDocumentID: sig.VersionID,
+		ContentDocumentID:   content.VersionID,
+		Status:              string(result.Status),
+		Message:             result.Message,
+		Signers:             make([]models.SignatureSigner, 0, len(result.Signers)),
+	}
+	for _, s := range result.Signers {
+		check.Signers = append(check.Signers, signerModel(s))
+	}
+	return check, nil
+}
+
+// SaveSignatureCheck сохраняет результат проверки подписи вместе с подписантами. Вызывать в транзакции
+func SaveSignatureCheck(ctx context.Context, db database.DBTX, check *models.SignatureCheck) error {
+	err := db.QueryRowContext(ctx,
+		`INSERT INTO contract_signature_checks
+			(contract_uid, signature_document_id, content_document_id, status, message, checked_by)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''))
+		RETURNING id, checked_at::text`,
+		check.ContractID, check.SignatureDocumentID, check.ContentDocumentID, check.Status, check.Message, check.CheckedBy).
+		Scan(&check.ID, &check.CheckedAt)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения проверки подписи контракта %s: %w", check.ContractID, err)
+	}
+
+	for _, s := range check.Signers {
+		_, err := db.ExecContext(ctx,
+			`INSERT INTO contract_signature_signers
+				(check_id, status, message, content_match, signature_valid, certificate_expired,
+				 subject, common_name, organization, inn, ogrn, serial_number, issuer,
+				 valid_from, valid_to, signing_time, digest_algorithm, signature_algorithm)
+			VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, NULLIF($7, ''), NULLIF($8, ''), NULLIF($9, ''),
+			        NULLIF($10, ''), NULLIF($11, ''), NULLIF($12, ''), NULLIF($13, ''),
+			        NULLIF($14, '')::timestamptz, NULLIF($15, '')::timestamptz, NULLIF($16, '')::timestamptz,
+			        NULLIF($17, ''), NULLIF($18, ''))`,
+			check.ID, s.Status, s.Message, s.ContentMatch, s.SignatureValid, s.CertificateExpired,
+			s.Subject, s.CommonName, s.Organization, s.Inn, s.Ogrn, s.SerialNumber, s.Issuer,
+			s.ValidFrom, s.ValidTo, s.SigningTime, s.DigestAlgorithm, s.SignatureAlgorithm)
+		if err != nil {
+			return fmt.Errorf("ошибка сохранения подписанта проверки %d: %w", check.ID, err)
+		}
+	}
+	return nil
+}
+
+// LatestSignatureCheck возвращает последний результат проверки подписи контракта
+func LatestSignatureCheck(ctx context.Context, db database.DBTX, id string) (models.SignatureCheck, error) {
+	if _, err := Get(ctx, db, id); err != nil {
+		return models.SignatureCheck{}, err
+	}
+
+	check, err := scanSignatureCheck(db.QueryRowContext(ctx,
+		`SELECT `+signatureColumns+`
+		FROM contract_signature_checks
+		WHERE contract_uid = $1
+		ORDER BY id DESC
+		LIMIT 1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return check, ErrSignatureNotChecked
+	}
+	if err != nil {
+		return check, fmt.Errorf("ошибка получения проверки подписи контракта %s: %w", id, err)
+	}
+
+	rows, err := db.QueryContext(ctx,
+		`SELECT status, COALESCE(message, ''), content_match, signature_valid, certificate_expired,
+		        COALESCE(subject, ''), COALESCE(common_name, ''), COALESCE(organization, ''),
+		        COALESCE(inn, ''), COALESCE(ogrn, ''), COALESCE(serial_number, ''), COALESCE(issuer, ''),
+		        COALESCE(valid_from::text, ''), COALESCE(valid_to::text, ''), COALESCE(signing_time::text, ''),
+		        COALESCE(digest_algorithm, ''), COALESCE(signature_algorithm, '')
+		FROM contract_signature_signers
+		WHERE check_id = $1
+		ORDER BY id`, check.ID)
+	if err != nil {
+		return check, fmt.Errorf("ошибка получения подписантов проверки %d: %w", check.ID, err)
+	}
+	defer rows.Close()
+
+	check.Signers = make([]models.SignatureSigner, 0)
+	for rows.Next() {
+		var s models.SignatureSigner
+		if err := rows.Scan(&s.Status, &s.Message, &s.ContentMatch, &s.SignatureValid, &s.CertificateExpired,
+			&s.Subject, &s.CommonName, &s.Organization, &s.Inn, &s.Ogrn, &s.SerialNumber, &s.Issuer,
+			&s.ValidFrom, &s.ValidTo, &s.SigningTime, &s.DigestAlgorithm, &s.SignatureAlgorithm); err != nil {
+			return check, fmt.Errorf("ошибка чтения подписанта: %w", err)
+		}
+		check.Signers = append(check.Signers, s)
+	}
+	if err := rows.Err(); err != nil {
+		return check, fmt.Errorf("ошибка чтения подписантов проверки %d: %w", check.ID, err)
+	}
+	return check, nil
+}
+
+// scanSignatureCheck читает проверку подписи из строки, выбранной по signatureColumns
+func scanSignatureCheck(row database.RowScanner) (models.SignatureCheck, error) {
+	var check models.SignatureCheck
+	err := row.Scan(&check.ID, &check.ContractID, &check.SignatureDocumentID, &check.ContentDocumentID,
+		&check.Status, &check.Message, &check.CheckedBy, &check.CheckedAt)
+	return check, err
+}
+
+// signerModel преобразует результат проверки подписанта в модель для сохранения
+func signerModel(s signature.SignerResult) models.SignatureSigner {
+	m := models.SignatureSigner{
+		Status:             string(s.Status),
+		Message:            s.Message,
+		ContentMatch:       s.ContentMatch,
+		SignatureValid:     s.SignatureValid,
+		CertificateExpired: s.CertificateExpired,
+		DigestAlgorithm:    s.DigestAlgorithm,
+		SignatureAlgorithm: s.SignatureAlgorithm,
+	}
+	if !s.SigningTime.IsZero() {
+		m.SigningTime = s.SigningTime.Format(time.RFC3339)
+	}
+	if cert := s.Certificate; cert != nil {
+		m.Subject = cert.Subject
+		m.CommonName = cert.CommonName
+		m.Organization = cert.Organization
+		m.Inn = cert.INN
+		m.Ogrn = cert.OGRN
+		m.SerialNumber = cert.SerialNumber
+		m.Issuer = cert.Issuer
+		m.ValidFrom = cert.NotBefore.Format(time.RFC3339)
+		m.ValidTo = cert.NotAfter.Format(time.RFC3339)
+	}
+	return m
+}
+
+// readObject читает объект хранилища целиком, но не больше limit байт
+func readObject(ctx context.Context, store storage.BlobStore, key string, limit int64) ([]byte, error) {
+	file, err := store.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения %s: %w", key, err)
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения %s: %w", key, err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("файл %s больше %d МБ", key, limit>>20)
+	}
+	return data, nil
+}
@@ -0,0 +1,160 @@
+package contracts
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"statements/internal/database"
+	"statements/internal/models"
+	"strings"
+	"time"
+)
+
+// maxAmount — граница суммы, которую вмещает колонка amount NUMERIC(15, 2)
+const maxAmount = 1e13
+
+// ValidationError содержит ошибки по отдельным полям контракта
+type ValidationError struct {
+	database.FieldErrors
+}
+
+func (e *ValidationError) Error() string {
+	return "некорректные данные контракта: " + e.Describe()
+}
+
+// Err возвращает nil, если ошибок нет, иначе саму ошибку
+func (e *ValidationError) Err() error {
+	if e.Empty() {
+		return nil
+	}
+	return e
+}
+
+// Validate проверяет обязательные поля, форматы дат и допустимые значения контракта.
+// Возвращает *ValidationError со всеми найденными ошибками
+func Validate(ct models.Contract) error {
+	verr := &ValidationError{}
+	validateInto(verr, ct)
+	return verr.Err()
+}
+
+// validateInto дописывает в verr ошибки полей контракта
+func validateInto(verr *ValidationError, ct models.Contract) {
+	if ct.CounterpartyID <= 0 {
+		verr.Add("counterparty_id", "Выберите контрагента")
+	}
+	switch number := strings.TrimSpace(ct.ContractNumber); {
+	case number == "":
+		verr.Add("contract_number", "Укажите номер контракта")
+	case len([]rune(number)) > 50:
+		verr.Add("contract_number", "Номер контракта не может быть длиннее 50 символов")
+	}
+	if strings.TrimSpace(ct.Subject) == "" {
+		verr.Add("subject", "Укажите предмет контракта")
+	}
+	switch contractType := strings.TrimSpace(ct.ContractType); {
+	case contractType == "":
+		verr.Add("contract_type", "Укажите тип контракта")
+	case len([]rune(contractType)) > 50:
+		verr.Add("contract_type", "Тип контракта не может быть длиннее 50 символов")
+	}
+	switch {
+	case math.IsNaN(ct.Amount) || math.IsInf(ct.Amount, 0):
+		verr.Add("amount", "Сумма должна быть числом")
+	case ct.Amount < 0:
+		verr.Add("amount", "Сумма контракта не может быть отрицательной")
+	case ct.Amount >= maxAmount:
+		verr.Add("amount", "Сумма контракта слишком велика")
+	}
+	if ct.PaymentDays != nil && *ct.PaymentDays < 0 {
+		verr.Add("payment_days", "Количество дней на оплату не может быть отрицательным")
+	}
+
+	contractDate, dateOK := parseDateField(verr, "contract_date", ct.ContractDate, true)
+	if dateOK && contractDate.After(time.Now()) {
+		verr.Add("contract_date", "Дата заключения не может быть в будущем")
+	}
+	executionPeriod, executionOK := parseDateField(verr, "execution_period", ct.ExecutionPeriod, true)
+	if dateOK && executionOK && executionPeriod.Before(contractDate) {
+		verr.Add("execution_period", "Срок исполнения не может быть раньше даты заключения")
+	}
+	validityPeriod, validityOK := parseDateField(verr, "validity_period", ct.ValidityPeriod, false)
+	if dateOK && validityOK && !validityPeriod.IsZero() && validityPeriod.Before(contractDate) {
+		verr.Add("validity_period", "Срок действия не может быть раньше даты заключения")
+	}
+
+	if ct.EaistStatus != "" && !IsEaistStatus(ct.EaistStatus) {
+		verr.Add("eaist_status", "Недопустимый статус ЕАИСТ")
+	}
+}
+
+// ValidateReferences проверяет контракт вместе со ссылками на данные в базе:
+// контрагент должен существовать, номер и дата не должны быть заняты другим контрактом,
+// а статус ЕАИСТ существующего контракта — совпадать с текущим или быть пустым
+func ValidateReferences(ctx context.Context, db database.DBTX, ct models.Contract) error {
+	verr := &ValidationError{}
+	validateInto(verr, ct)
+
+	if _, failed := verr.Fields["counterparty_id"]; !failed {
+		var exists bool
+		if err := db.QueryRowContext(ctx,
+			"SELECT EXISTS (SELECT 1 FROM counterparties WHERE id = $1)", ct.CounterpartyID).Scan(&exists); err != nil {
+			return fmt.Errorf("ошибка проверки контрагента %d: %w", ct.CounterpartyID, err)
+		}
+		if !exists {
+			verr.Add("counterparty_id", "Контрагент не найден")
+		}
+	}
+
+	_, numberFailed := verr.Fields["contract_number"]
+	_, dateFailed := verr.Fields["contract_date"]
+	if !numberFailed && !dateFailed {
+		var taken bool
+		// Удалённые контракты тоже занимают номер и дату, так как входят в первичный ключ
+		if err := db.QueryRowContext(ctx,
+			`SELECT EXISTS (
+				SELECT 1 FROM contracts
+				WHERE contract_number = $1 AND contract_date = $2 AND uid IS DISTINCT FROM NULLIF($3, '')::uuid
+			)`, ct.ContractNumber, ct.ContractDate, ct.ID).Scan(&taken); err != nil {
+			return fmt.Errorf("ошибка проверки номера контракта %s: %w", ct.ContractNumber, err)
+		}
+		if taken {
+			verr.Add("contract_number", "Контракт с таким номером и датой уже существует")
+		}
+	}
+
+	// Статус ЕАИСТ существующего контракта меняется только через ChangeEaistStatus
+	if _, failed := verr.Fields["eaist_status"]; !failed && ct.ID != "" && ct.EaistStatus != "" {
+		var current string
+		err := db.QueryRowContext(ctx,
+			"SELECT COALESCE(eaist_status::text, '') FROM contracts WHERE uid = $1", ct.ID).Scan(&current)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("ошибка проверки статуса ЕАИСТ контракта %s: %w", ct.ID, err)
+		}
+		if err == nil && current != ct.EaistStatus {
+			verr.Add("eaist_status", "Статус ЕАИСТ меняется отдельно, с проверкой допустимых переходов")
+		}
+	}
+
+	return verr.Err()
+}
+
+// parseDateField разбирает дату в формате ГГГГ-ММ-ДД и записывает ошибку поля, если дата некорректна.
+// Для необязательного пустого поля возвращает нулевую дату и true
+func parseDateField(verr *ValidationError, field, value string, required bool) (time.Time, bool) {
+	if value == "" {
+		if required {
+			verr.Add(field, "Укажите дату")
+			return time.Time{}, false
+		}
+		return time.Time{}, true
+	}
+	date, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		verr.Add(field, "Дата должна быть в формате ГГГГ-ММ-ДД")
+		return time.Time{}, false
+	}
+	return date, true
+}
@@ -0,0 +1,161 @@
+package counterparties
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"statements/internal/database"
+	"statements/internal/models"
+)
+
+// ErrAlertNotFound возвращается, если оповещение не найдено или уже закрыто
+var ErrAlertNotFound = errors.New("оповещение не найдено или уже закрыто")
+
+// AccountUsage описывает платёж, в котором встретился счёт контрагента
+type AccountUsage struct {
+	CounterpartyID int
+	Account        string
+	Bik            string
+	TransactionID  int
+	Date           string
+	Amount         string // Сумма платежа в пользу контрагента, пусто для поступлений
+	Outgoing       bool   // Платёж перечислен контрагенту
+}
+
+// RecordAccount сохраняет счёт контрагента из платежа. Если контрагенту, у которого уже есть другие счета,
+// перечислены деньги на новый счёт и detectNew включён, создаётся оповещение; возвращается его идентификатор.
+func RecordAccount(ctx context.Context, db database.DBTX, u AccountUsage, detectNew bool) (int, error) {
+	var accountID int
+	err := db.QueryRowContext(ctx,
+		`INSERT INTO counterparty_accounts (counterparty_id, account, bik, first_transaction_id, first_seen_date, last_seen_date)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, 0), NULLIF($5, '')::date, NULLIF($5, '')::date)
+		ON CONFLICT (counterparty_id, account) DO NOTHING
+		RETURNING id`,
+		u.CounterpartyID, u.Account, u.Bik, u.TransactionID, u.Date).Scan(&accountID)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Счёт уже известен — обновляем статистику использования
+		_, err = db.ExecContext(ctx,
+			`UPDATE counterparty_accounts
+			SET occurrences = occurrences + 1,
+			    bik = COALESCE(bik, NULLIF($3, '')),
+			    first_seen_date = LEAST(first_seen_date, NULLIF($4, '')::date),
+			    last_seen_date = GREATEST(last_seen_date, NULLIF($4, '')::date)
+			WHERE counterparty_id = $1 AND account = $2`,
+			u.CounterpartyID, u.Account, u.Bik, u.Date)
+		if err != nil {
+			return 0, fmt.Errorf("ошибка обновления счёта %s контрагента %d: %w", u.Account, u.CounterpartyID, err)
+		}
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("ошибка сохранения счёта %s контрагента %d: %w", u.Account, u.CounterpartyID, err)
+	}
+
+	if !detectNew || !u.Outgoing {
+		return 0, nil
+	}
+
+	// Первый счёт нового контрагента оповещением не считается
+	var otherAccounts int
+	err = db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM counterparty_accounts WHERE counterparty_id = $1 AND account <> $2",
+		u.CounterpartyID, u.Account).Scan(&otherAccounts)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка проверки счетов контрагента %d: %w", u.CounterpartyID, err)
+	}
+	if otherAccounts == 0 {
+		return 0, nil
+	}
+
+	var alertID int
+	err = db.QueryRowContext(ctx,
+		`INSERT INTO counterparty_account_alerts (counterparty_id, account_id, transaction_id, amount, payment_date)
+		VALUES ($1, $2, NULLIF($3, 0), COALESCE(NULLIF($4, '')::numeric, 0), NULLIF($5, '')::date)
+		RETURNING id`,
+		u.CounterpartyID, accountID, u.TransactionID, u.Amount, u.Date).Scan(&alertID)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка создания оповещения о новом счёте контрагента %d: %w", u.CounterpartyID, err)
+	}
+	return alertID, nil
+}
+
+// Accounts возвращает известные счета контрагента
+func Accounts(ctx context.Context, db database.DBTX, counterpartyID int) ([]models.CounterpartyAccount, error) {
+	rows, err := db.QueryContext(ctx,
+		`SELECT id, counterparty_id, account, COALESCE(bik, ''), COALESCE(first_seen_date::text, ''),
+		        COALESCE(last_seen_date::text, ''), occurrences
+		FROM counterparty_accounts
+		WHERE counterparty_id = $1
+		ORDER BY first_seen_date NULLS LAST, id`, counterpartyID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения счетов контрагента %d: %w", counterpartyID, err)
+	}
+	defer rows.Close()
+
+	accounts := make([]models.CounterpartyAccount, 0)
+	for rows.Next() {
+		var a models.CounterpartyAccount
+		if err := rows.Scan(&a.ID, &a.CounterpartyID, &a.Account, &a.Bik, &a.FirstSeenDate, &a.LastSeenDate, &a.Occurrences); err != nil {
+			return nil, fmt.Errorf("ошибка чтения счёта контрагента: %w", err)
+		}
+		accounts = append(accounts, a)
+	}
+	return accounts, rows.Err()
+}
+
+// Alerts возвращает оповещения о новых счетах; onlyOpen ограничивает выборку непроверенными
+func Alerts(ctx context.Context, db database.DBTX, onlyOpen bool, limit, offset int) ([]models.AccountAlert, int, error) {
+	where := ""
+	if onlyOpen {
+		where = "WHERE al.resolved_at IS NULL"
+	}
+
+	var total int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM counterparty_account_alerts al "+where).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("ошибка подсчёта оповещений: %w", err)
+	}
+
+	rows, err := db.QueryContext(ctx,
+		`SELECT al.id, al.counterparty_id, cp.name, cp.inn, a.account, COALESCE(a.bik, ''),
+		        COALESCE(al.transaction_id, 0), al.amount::float8, COALESCE(al.payment_date::text, ''), al.created_at::text,
+		        COALESCE(al.resolved_at::text, ''), COALESCE(al.resolved_by, ''), COALESCE(al.resolution_comment, '')
+		FROM counterparty_account_alerts al
+		JOIN counterparties cp ON cp.id = al.counterparty_id
+		JOIN counterparty_accounts a ON a.id = al.account_id
+		`+where+`
+		ORDER BY al.created_at DESC, al.id DESC
+		LIMIT $1 OFFSET $2`, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ошибка получения оповещений: %w", err)
+	}
+	defer rows.Close()
+
+	alerts := make([]models.AccountAlert, 0)
+	for rows.Next() {
+		var a models.AccountAlert
+		if err := rows.Scan(&a.ID, &a.CounterpartyID, &a.CounterpartyName, &a.CounterpartyInn, &a.Account, &a.Bik,
+			&a.TransactionID, &a.Amount, &a.PaymentDate, &a.CreatedAt,
+			&a.ResolvedAt, &a.ResolvedBy, &a.ResolutionComment); err != nil {
+			return nil, 0, fmt.Errorf("ошибка чтения оповещения: %w", err)
+		}
+		alerts = append(alerts, a)
+	}
+	return alerts, total, rows.Err()
+}
+
+// ResolveAlert отмечает оповещение как проверенное
+func ResolveAlert(ctx context.Context, db database.DBTX, alertID int, resolvedBy, comment string) error {
+	res, err := db.ExecContext(ctx,
+		`UPDATE counterparty_account_alerts
+		SET resolved_at = now(), resolved_by = NULLIF($2, ''), resolution_comment = NULLIF($3, '')
+		WHERE id = $1 AND resolved_at IS NULL`,
+		alertID, resolvedBy, comment)
+	if err != nil {
+		return fmt.Errorf("ошибка закрытия оповещения %d: %w", alertID, err)
+	}
+	if rowsAffected(res) == 0 {
+		return ErrAlertNotFound
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package counterparties
+
+import (
+	"context"
+	"fmt"
+	"statements/internal/database"
+	"strings"
+)
+
+// Upsert находит контрагента по паре (ИНН, КПП) или создаёт нового и возвращает его идентификатор.
+// Пустой КПП сохраняется как NULL. Если передано наименование, оно фиксируется в истории вариантов.
+func Upsert(ctx context.Context, db database.DBTX, inn, kpp, name string) (int, error) {
+	inn = strings.TrimSpace(inn)
+	kpp = strings.TrimSpace(kpp)
+	name = strings.TrimSpace(name)
+
+	// Новый контрагент получает нормализованное наименование; для неизвестного имени используем ИНН
+	displayName := NormalizeName(name)
+	if displayName == "" {
+		displayName = inn
+	}
+
+	var id int
+	err := db.QueryRowContext(ctx,
+		`INSERT INTO counterparties (name, inn, kpp)
+		VALUES ($1, $2, NULLIF($3, ''))
+		ON CONFLICT (inn, kpp) DO UPDATE SET inn = EXCLUDED.inn
+		RETURNING id`,
+		displayName, inn, kpp).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка сохранения контрагента с ИНН %s: %w", inn, err)
+	}
+
+	if name != "" {
+		if err := RecordName(ctx, db, id, name); err != nil {
+			return id, err
+		}
+	}
+
+	return id, nil
+}
+
+// RecordName фиксирует вариант наименования контрагента и обновляет счётчик его появлений
+func RecordName(ctx context.Context, db database.DBTX, counterpartyID int, name string) error {
+	_, err := db.ExecContext(ctx,
+		`INSERT INTO counterparty_names (counterparty_id, name)
+		VALUES ($1, $2)
+		ON CONFLICT (counterparty_id, name) DO UPDATE
+		SET last_seen_at = now(), occurrences = counterparty_names.occurrences + 1`,
+		counterpartyID, name)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения наименования контрагента %d: %w", counterpartyID, err)
+	}
+	return nil
+}
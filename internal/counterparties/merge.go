@@ -0,0 +1,302 @@
+package counterparties
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"statements/internal/database"
+	"statements/internal/models"
+)
+
+// Причины, по которым контрагенты попадают в группу возможных дублей
+const (
+	DuplicateByInn  = "inn"
+	DuplicateByName = "name"
+)
+
+// DuplicateGroup описывает группу контрагентов, которые могут оказаться одним лицом
+type DuplicateGroup struct {
+	Reason         string                `json:"reason"`
+	Key            string                `json:"key"`
+	Counterparties []models.Counterparty `json:"counterparties"`
+}
+
+// MergeRecord описывает запись журнала объединения контрагентов
+type MergeRecord struct {
+	ID                   int    `json:"id"`
+	TargetID             int    `json:"target_id"`
+	SourceID             int    `json:"source_id"`
+	SourceName           string `json:"source_name"`
+	SourceInn            string `json:"source_inn"`
+	SourceKpp            string `json:"source_kpp,omitempty"`
+	SourceAddress        string `json:"source_address,omitempty"`
+	ContractsMoved       int    `json:"contracts_moved"`
+	TransactionsMoved    int    `json:"transactions_moved"`
+	PaymentRequestsMoved int    `json:"payment_requests_moved"`
+	MergedBy             string `json:"merged_by,omitempty"`
+	MergedAt             string `json:"merged_at"`
+}
+
+var (
+	// ErrMergeIntoSelf возвращается при попытке объединить контрагента с самим собой
+	ErrMergeIntoSelf = errors.New("нельзя объединить контрагента с самим собой")
+	// ErrMergePaymentRequestConflict возвращается, если один и тот же документ на оплату заведён у обоих контрагентов
+	ErrMergePaymentRequestConflict = errors.New("у объединяемых контрагентов есть заявки на оплату по одному и тому же документу")
+)
+
+// FindDuplicates возвращает группы возможных дублей: контрагентов с одинаковым ИНН
+// и контрагентов с разными ИНН, но совпадающим нормализованным наименованием
+func FindDuplicates(ctx context.Context, db database.DBTX) ([]DuplicateGroup, error) {
+	rows, err := db.QueryContext(ctx, "SELECT "+counterpartyColumns+" FROM counterparties ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения контрагентов: %w", err)
+	}
+	defer rows.Close()
+
+	byInn := make(map[string][]models.Counterparty)
+	byName := make(map[string][]models.Counterparty)
+	for rows.Next() {
+		cp, err := scanCounterparty(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения контрагента: %w", err)
+		}
+		byInn[cp.Inn] = append(byInn[cp.Inn], cp)
+		if key := NameKey(cp.Name); key != "" {
+			byName[key] = append(byName[key], cp)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения контрагентов: %w", err)
+	}
+
+	groups := make([]DuplicateGroup, 0)
+	for inn, items := range byInn {
+		if len(items) > 1 {
+			groups = append(groups, DuplicateGroup{Reason: DuplicateByInn, Key: inn, Counterparties: items})
+		}
+	}
+	for key, items := range byName {
+		// Группы с единственным ИНН уже попали в отчёт по ИНН
+		if len(items) > 1 && !sameInn(items) {
+			groups = append(groups, DuplicateGroup{Reason: DuplicateByName, Key: key, Counterparties: items})
+		}
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Reason != groups[j].Reason {
+			return groups[i].Reason < groups[j].Reason
+		}
+		return groups[i].Key < groups[j].Key
+	})
+	return groups, nil
+}
+
+// Merge переносит контракты, заявки на оплату, транзакции, счета и варианты наименований дублей на сохраняемого контрагента,
+// удаляет дубли и записывает каждое объединение в журнал. Все изменения выполняются в одной транзакции.
+func Merge(ctx context.Context, db *sql.DB, targetID int, sourceIDs []int, mergedBy string) ([]MergeRecord, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := lockCounterparty(ctx, tx, targetID); err != nil {
+		return nil, err
+	}
+
+	records := make([]MergeRecord, 0, len(sourceIDs))
+	for _, sourceID := range sourceIDs {
+		if sourceID == targetID {
+			return nil, ErrMergeIntoSelf
+		}
+
+		record, err := mergeOne(ctx, tx, targetID, sourceID, mergedBy)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка фиксации объединения контрагентов: %w", err)
+	}
+	return records, nil
+}
+
+// MergeHistory возвращает журнал объединений, в которых контрагент был сохранён
+func MergeHistory(ctx context.Context, db database.DBTX, targetID int) ([]MergeRecord, error) {
+	rows, err := db.QueryContext(ctx,
+		`SELECT id, target_id, source_id, source_name, source_inn, COALESCE(source_kpp, ''), COALESCE(source_address, ''),
+		        contracts_moved, transactions_moved, payment_requests_moved, COALESCE(merged_by, ''), merged_at::text
+		FROM counterparty_merges
+		WHERE target_id = $1
+		ORDER BY merged_at DESC, id DESC`, targetID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения журнала объединений: %w", err)
+	}
+	defer rows.Close()
+
+	records := make([]MergeRecord, 0)
+	for rows.Next() {
+		var r MergeRecord
+		if err := rows.Scan(&r.ID, &r.TargetID, &r.SourceID, &r.SourceName, &r.SourceInn, &r.SourceKpp, &r.SourceAddress,
+			&r.ContractsMoved, &r.TransactionsMoved, &r.PaymentRequestsMoved, &r.MergedBy, &r.MergedAt); err != nil {
+			return nil, fmt.Errorf("ошибка чтения журнала объединений: %w", err)
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+// mergeOne переносит данные одного дубля на сохраняемого контрагента и удаляет дубль
+func mergeOne(ctx context.Context, tx *sql.Tx, targetID, sourceID int, mergedBy string) (MergeRecord, error) {
+	source, err := lockCounterparty(ctx, tx, sourceID)
+	if err != nil {
+		return MergeRecord{}, err
+	}
+
+	record := MergeRecord{
+		TargetID:      targetID,
+		SourceID:      sourceID,
+		SourceName:    source.Name,
+		SourceInn:     source.Inn,
+		SourceKpp:     source.Kpp,
+		SourceAddress: source.Address,
+		MergedBy:      mergedBy,
+	}
+
+	res, err := tx.ExecContext(ctx, "UPDATE contracts SET counterparty_id = $1 WHERE counterparty_id = $2", targetID, sourceID)
+	if err != nil {
+		return record, fmt.Errorf("ошибка переноса контрактов контрагента %d: %w", sourceID, err)
+	}
+	record.ContractsMoved = rowsAffected(res)
+
+	// Документ на оплату уникален для контрагента: одинаковые заявки у дубля и сохраняемого контрагента
+	// объединять автоматически нельзя, их нужно сначала разобрать вручную
+	res, err = tx.ExecContext(ctx, "UPDATE payment_requests SET counterparty_id = $1 WHERE counterparty_id = $2", targetID, sourceID)
+	if database.IsUniqueViolation(err) {
+		return record, ErrMergePaymentRequestConflict
+	}
+	if err != nil {
+		return record, fmt.Errorf("ошибка переноса заявок на оплату контрагента %d: %w", sourceID, err)
+	}
+	record.PaymentRequestsMoved = rowsAffected(res)
+
+	res, err = tx.ExecContext(ctx,
+		`UPDATE transactions
+		SET counterparty_id = CASE WHEN counterparty_id = $2 THEN $1 ELSE counterparty_id END,
+		    counterparty_c_id = CASE WHEN counterparty_c_id = $2 THEN $1 ELSE counterparty_c_id END
+		WHERE counterparty_id = $2 OR counterparty_c_id = $2`, targetID, sourceID)
+	if err != nil {
+		return record, fmt.Errorf("ошибка переноса транзакций контрагента %d: %w", sourceID, err)
+	}
+	record.TransactionsMoved = rowsAffected(res)
+
+	// Переносим историю наименований, складывая счётчики совпадающих вариантов
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO counterparty_names (counterparty_id, name, first_seen_at, last_seen_at, occurrences)
+		SELECT $1, name, first_seen_at, last_seen_at, occurrences FROM counterparty_names WHERE counterparty_id = $2
+		ON CONFLICT (counterparty_id, name) DO UPDATE
+		SET first_seen_at = LEAST(counterparty_names.first_seen_at, EXCLUDED.first_seen_at),
+		    last_seen_at = GREATEST(counterparty_names.last_seen_at, EXCLUDED.last_seen_at),
+		    occurrences = counterparty_names.occurrences + EXCLUDED.occurrences`, targetID, sourceID)
+	if err != nil {
+		return record, fmt.Errorf("ошибка переноса наименований контрагента %d: %w", sourceID, err)
+	}
+
+	if err := moveAccounts(ctx, tx, targetID, sourceID); err != nil {
+		return record, err
+	}
+
+	// Наименование самого дубля тоже сохраняем как вариант
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO counterparty_names (counterparty_id, name) VALUES ($1, $2)
+		ON CONFLICT (counterparty_id, name) DO NOTHING`, targetID, source.Name)
+	if err != nil {
+		return record, fmt.Errorf("ошибка сохранения наименования контрагента %d: %w", sourceID, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM counterparties WHERE id = $1", sourceID); err != nil {
+		return record, fmt.Errorf("ошибка удаления контрагента %d: %w", sourceID, err)
+	}
+
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO counterparty_merges
+		    (target_id, source_id, source_name, source_inn, source_kpp, source_address,
+		     contracts_moved, transactions_moved, payment_requests_moved, merged_by)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), $7, $8, $9, NULLIF($10, ''))
+		RETURNING id, merged_at::text`,
+		record.TargetID, record.SourceID, record.SourceName, record.SourceInn, record.SourceKpp, record.SourceAddress,
+		record.ContractsMoved, record.TransactionsMoved, record.PaymentRequestsMoved, record.MergedBy).Scan(&record.ID, &record.MergedAt)
+	if err != nil {
+		return record, fmt.Errorf("ошибка записи журнала объединения: %w", err)
+	}
+
+	return record, nil
+}
+
+// moveAccounts переносит счета и оповещения дубля: совпадающие счета объединяются, остальные переходят целиком
+func moveAccounts(ctx context.Context, tx *sql.Tx, targetID, sourceID int) error {
+	queries := []string{
+		// Складываем статистику по счетам, известным у обоих контрагентов
+		`UPDATE counterparty_accounts t
+		SET occurrences = t.occurrences + s.occurrences,
+		    bik = COALESCE(t.bik, s.bik),
+		    first_seen_date = LEAST(t.first_seen_date, s.first_seen_date),
+		    last_seen_date = GREATEST(t.last_seen_date, s.last_seen_date)
+		FROM counterparty_accounts s
+		WHERE s.counterparty_id = $2 AND t.counterparty_id = $1 AND t.account = s.account`,
+		// Оповещения по совпадающим счетам переводим на счёт сохраняемого контрагента
+		`UPDATE counterparty_account_alerts al
+		SET account_id = t.id
+		FROM counterparty_accounts s, counterparty_accounts t
+		WHERE al.account_id = s.id AND s.counterparty_id = $2 AND t.counterparty_id = $1 AND t.account = s.account`,
+		// Остальные счета переходят целиком; совпавшие будут удалены вместе с дублем
+		`UPDATE counterparty_accounts s
+		SET counterparty_id = $1
+		WHERE s.counterparty_id = $2
+		  AND NOT EXISTS (SELECT 1 FROM counterparty_accounts t WHERE t.counterparty_id = $1 AND t.account = s.account)`,
+		`UPDATE counterparty_account_alerts SET counterparty_id = $1 WHERE counterparty_id = $2`,
+	}
+
+	for _, query := range queries {
+		if _, err := tx.ExecContext(ctx, query, targetID, sourceID); err != nil {
+			return fmt.Errorf("ошибка переноса счетов контрагента %d: %w", sourceID, err)
+		}
+	}
+	return nil
+}
+
+// lockCounterparty блокирует строку контрагента до конца транзакции и возвращает его данные
+func lockCounterparty(ctx context.Context, tx *sql.Tx, id int) (models.Counterparty, error) {
+	cp, err := scanCounterparty(tx.QueryRowContext(ctx,
+		"SELECT "+counterpartyColumns+" FROM counterparties WHERE id = $1 FOR UPDATE", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return cp, ErrNotFound
+	}
+	if err != nil {
+		return cp, fmt.Errorf("ошибка блокировки контрагента %d: %w", id, err)
+	}
+	return cp, nil
+}
+
+// rowsAffected возвращает количество затронутых строк, игнорируя неподдерживаемый драйвером подсчёт
+func rowsAffected(res sql.Result) int {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0
+	}
+	return int(n)
+}
+
+// sameInn проверяет, что у всех контрагентов группы один ИНН
+func sameInn(items []models.Counterparty) bool {
+	for _, cp := range items[1:] {
+		if cp.Inn != items[0].Inn {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,152 @@
+package counterparties
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// legalForm описывает организационно-правовую форму и её сокращение
+type legalForm struct {
+	name  string
+	full  *regexp.Regexp
+	short string
+}
+
+// legalForms перечисляет полные наименования ОПФ. Список упорядочивается по убыванию длины,
+// чтобы длинные формы («непубличное акционерное общество») заменялись раньше вложенных в них коротких
+var legalForms = func() []legalForm {
+	forms := []legalForm{
+		newLegalForm("федеральное государственное унитарное предприятие", "ФГУП"),
+		newLegalForm("федеральное государственное бюджетное учреждение", "ФГБУ"),
+		newLegalForm("федеральное государственное казенное учреждение", "ФГКУ"),
+		newLegalForm("федеральное государственное автономное учреждение", "ФГАУ"),
+		newLegalForm("государственное унитарное предприятие", "ГУП"),
+		newLegalForm("муниципальное унитарное предприятие", "МУП"),
+		newLegalForm("государственное бюджетное учреждение", "ГБУ"),
+		newLegalForm("государственное казенное учреждение", "ГКУ"),
+		newLegalForm("государственное автономное учреждение", "ГАУ"),
+		newLegalForm("муниципальное бюджетное учреждение", "МБУ"),
+		newLegalForm("муниципальное казенное учреждение", "МКУ"),
+		newLegalForm("муниципальное автономное учреждение", "МАУ"),
+		newLegalForm("общество с ограниченной ответственностью", "ООО"),
+		newLegalForm("непубличное акционерное общество", "НАО"),
+		newLegalForm("публичное акционерное общество", "ПАО"),
+		newLegalForm("закрытое акционерное общество", "ЗАО"),
+		newLegalForm("открытое акционерное общество", "ОАО"),
+		newLegalForm("акционерное общество", "АО"),
+		newLegalForm("автономная некоммерческая организация", "АНО"),
+		newLegalForm("индивидуальный предприниматель", "ИП"),
+		newLegalForm("казенное предприятие", "КП"),
+	}
+	sort.SliceStable(forms, func(i, j int) bool {
+		return utf8.RuneCountInString(forms[i].name) > utf8.RuneCountInString(forms[j].name)
+	})
+	return forms
+}()
+
+// shortForms — множество сокращений ОПФ для поиска формы в начале или конце наименования
+var shortForms = func() map[string]bool {
+	m := make(map[string]bool, len(legalForms))
+	for _, f := range legalForms {
+		m[f.short] = true
+	}
+	return m
+}()
+
+var (
+	quoteReplacer = strings.NewReplacer(`«`, `"`, `»`, `"`, `“`, `"`, `”`, `"`, `„`, `"`, `‘`, `"`, `’`, `"`)
+	spaceRe       = regexp.MustCompile(`\s+`)
+	keyJunkRe     = regexp.MustCompile(`[^\p{L}\p{N} ]+`)
+)
+
+// newLegalForm строит регулярное выражение для полного наименования ОПФ без учёта регистра и буквы «ё».
+// Граница слова задаётся явно: \b в Go учитывает только латиницу, и «публичное» находилось бы внутри «непубличное»
+func newLegalForm(full, short string) legalForm {
+	pattern := strings.ReplaceAll(regexp.QuoteMeta(full), "е", "[её]")
+	pattern = strings.ReplaceAll(pattern, " ", `\s+`)
+	return legalForm{
+		name:  full,
+		full:  regexp.MustCompile(`(?i)(^|[^\p{L}])` + pattern + `([^\p{L}]|$)`),
+		short: short,
+	}
+}
+
+// NormalizeName приводит наименование контрагента к виду `ООО "Ромашка"`:
+// полная ОПФ заменяется сокращением и ставится в начало, кавычки всех видов — прямыми, лишние пробелы удаляются.
+// Наименование, у которого в кавычках только часть (`ГБУ города Москвы "Жилищник"`), сохраняет свои кавычки
+func NormalizeName(name string) string {
+	name = spaceRe.ReplaceAllString(strings.TrimSpace(name), " ")
+	if name == "" {
+		return ""
+	}
+
+	for _, f := range legalForms {
+		name = f.full.ReplaceAllString(name, "${1}"+f.short+"${2}")
+	}
+	name = quoteReplacer.Replace(name)
+
+	form, rest := splitLegalForm(name)
+	rest, quoted := unquoteName(rest)
+	switch {
+	case form == "":
+		return rest
+	case rest == "":
+		return form
+	case !quoted:
+		return form + " " + rest
+	// У индивидуальных предпринимателей ФИО в кавычки не берётся
+	case form == "ИП":
+		return form + " " + rest
+	}
+	return form + ` "` + rest + `"`
+}
+
+// unquoteName снимает кавычки с наименования без ОПФ и сообщает, можно ли взять его в кавычки целиком.
+// Если в кавычках только часть наименования, кавычки сохраняются, а quoted = false
+func unquoteName(rest string) (name string, quoted bool) {
+	inner := strings.TrimSpace(strings.Trim(rest, `" `))
+	switch {
+	case !strings.Contains(inner, `"`):
+		return inner, true
+	case strings.HasPrefix(rest, `"`) && strings.HasSuffix(rest, `"`):
+		// Вложенные кавычки: `"Фирма "Ромашка""`
+		return spaceRe.ReplaceAllString(strings.TrimSpace(strings.ReplaceAll(rest, `"`, " ")), " "), true
+	}
+	return rest, false
+}
+
+// NameKey возвращает ключ для сравнения наименований: нормализованное имя в верхнем регистре без знаков препинания
+func NameKey(name string) string {
+	key := strings.ToUpper(NormalizeName(name))
+	key = strings.ReplaceAll(key, "Ё", "Е")
+	key = keyJunkRe.ReplaceAllString(key, " ")
+	return spaceRe.ReplaceAllString(strings.TrimSpace(key), " ")
+}
+
+// splitLegalForm отделяет сокращение ОПФ, стоящее в начале или в конце наименования,
+// вместе с окружающими его запятыми и кавычками
+func splitLegalForm(name string) (form, rest string) {
+	fields := strings.Fields(name)
+	if len(fields) == 0 {
+		return "", ""
+	}
+
+	first := strings.ToUpper(strings.Trim(fields[0], `,.;"`))
+	if shortForms[first] {
+		return first, trimSeparators(strings.Join(fields[1:], " "))
+	}
+
+	last := strings.ToUpper(strings.Trim(fields[len(fields)-1], `,.;"`))
+	if len(fields) > 1 && shortForms[last] {
+		return last, trimSeparators(strings.Join(fields[:len(fields)-1], " "))
+	}
+
+	return "", strings.Join(fields, " ")
+}
+
+// trimSeparators удаляет запятые и тире, оставшиеся на месте перенесённой ОПФ
+func trimSeparators(s string) string {
+	return strings.Trim(s, " ,;-–—")
+}
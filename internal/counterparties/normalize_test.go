@@ -0,0 +1,55 @@
+package counterparties
+
+import "testing"
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+		key  string
+	}{
+		{`Общество с ограниченной ответственностью "Ромашка"`, `ООО "Ромашка"`, "ООО РОМАШКА"},
+		{`ООО «Ромашка»`, `ООО "Ромашка"`, "ООО РОМАШКА"},
+		{`  ООО   Ромашка `, `ООО "Ромашка"`, "ООО РОМАШКА"},
+		{`Ромашка, ООО`, `ООО "Ромашка"`, "ООО РОМАШКА"},
+		{`"Ромашка", ООО`, `ООО "Ромашка"`, "ООО РОМАШКА"},
+		{`ООО, Ромашка`, `ООО "Ромашка"`, "ООО РОМАШКА"},
+		{`"Общество с ограниченной ответственностью Ромашка"`, `ООО "Ромашка"`, "ООО РОМАШКА"},
+		{`ООО "Фирма "Ромашка""`, `ООО "Фирма Ромашка"`, "ООО ФИРМА РОМАШКА"},
+		{`Непубличное акционерное общество "Ромашка"`, `НАО "Ромашка"`, "НАО РОМАШКА"},
+		{`Публичное акционерное общество "Ромашка"`, `ПАО "Ромашка"`, "ПАО РОМАШКА"},
+		{`НЕПУБЛИЧНОЕ АКЦИОНЕРНОЕ ОБЩЕСТВО "РОМАШКА"`, `НАО "РОМАШКА"`, "НАО РОМАШКА"},
+		{`Акционерное общество "Мосводоканал"`, `АО "Мосводоканал"`, "АО МОСВОДОКАНАЛ"},
+		{`Федеральное государственное бюджетное учреждение "Х"`, `ФГБУ "Х"`, "ФГБУ Х"},
+		{`Государственное бюджетное учреждение города Москвы "Жилищник района Марьино"`,
+			`ГБУ города Москвы "Жилищник района Марьино"`, "ГБУ ГОРОДА МОСКВЫ ЖИЛИЩНИК РАЙОНА МАРЬИНО"},
+		{`ГБУ ГОРОДА МОСКВЫ "ЖИЛИЩНИК"`, `ГБУ ГОРОДА МОСКВЫ "ЖИЛИЩНИК"`, "ГБУ ГОРОДА МОСКВЫ ЖИЛИЩНИК"},
+		{`ГБУ "Жилищник" района Марьино`, `ГБУ "Жилищник" района Марьино`, "ГБУ ЖИЛИЩНИК РАЙОНА МАРЬИНО"},
+		{`Государственное казённое учреждение "Дирекция"`, `ГКУ "Дирекция"`, "ГКУ ДИРЕКЦИЯ"},
+		{`Индивидуальный предприниматель Иванов Иван Иванович`, `ИП Иванов Иван Иванович`, "ИП ИВАНОВ ИВАН ИВАНОВИЧ"},
+		{`Иванов Иван Иванович ИП`, `ИП Иванов Иван Иванович`, "ИП ИВАНОВ ИВАН ИВАНОВИЧ"},
+		{`ИП Семёнов С.С.`, `ИП Семёнов С.С.`, "ИП СЕМЕНОВ С С"},
+		{`Ромашка`, `Ромашка`, "РОМАШКА"},
+		{`"Ромашка"`, `Ромашка`, "РОМАШКА"},
+		{`ООО`, `ООО`, "ООО"},
+		{``, ``, ``},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeName(tt.name); got != tt.want {
+				t.Errorf("NormalizeName(%q) = %q, ожидалось %q", tt.name, got, tt.want)
+			}
+			if got := NameKey(tt.name); got != tt.key {
+				t.Errorf("NameKey(%q) = %q, ожидалось %q", tt.name, got, tt.key)
+			}
+		})
+	}
+}
+
+func TestLegalFormsOrder(t *testing.T) {
+	for i := 1; i < len(legalForms); i++ {
+		if len([]rune(legalForms[i].name)) > len([]rune(legalForms[i-1].name)) {
+			t.Errorf("форма %q стоит после более короткой %q", legalForms[i].name, legalForms[i-1].name)
+		}
+	}
+}
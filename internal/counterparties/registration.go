@@ -0,0 +1,89 @@
+package counterparties
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"statements/internal/database"
+	"statements/internal/models"
+	"statements/internal/utils"
+)
+
+// ApplyRegistration создаёт или обновляет контрагента по сведениям ЕГРЮЛ/ЕГРИП.
+// Контрагент ищется по ИНН и КПП, а если такого нет — по ИНН с пустым КПП (так сохраняются контрагенты из выписок банка).
+// Сведения, которых нет в выписке, не затирают сохранённые ранее; дата прекращения деятельности только устанавливается
+func ApplyRegistration(ctx context.Context, db *sql.DB, reg models.CounterpartyRegistration) (id int, created bool, err error) {
+	cp := models.Counterparty{Name: NormalizeName(utils.FirstNonEmpty(reg.ShortName, reg.FullName)), Inn: reg.Inn, Kpp: reg.Kpp}
+	if err := Validate(cp); err != nil {
+		return 0, false, fmt.Errorf("некорректные сведения ЕГРЮЛ/ЕГРИП для ИНН %s: %w", reg.Inn, err)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, false, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	id, created, err = Resolve(ctx, tx, cp)
+	if err != nil {
+		return 0, false, err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE counterparties
+		SET kpp = COALESCE(NULLIF($2, ''), kpp),
+		    address = COALESCE(NULLIF($3, ''), address),
+		    full_name = COALESCE(NULLIF($4, ''), full_name),
+		    short_name = COALESCE(NULLIF($5, ''), short_name),
+		    ogrn = COALESCE(NULLIF($6, ''), ogrn),
+		    director = COALESCE(NULLIF($7, ''), director),
+		    registration_date = COALESCE(NULLIF($8, '')::date, registration_date),
+		    liquidation_date = COALESCE(NULLIF($9, '')::date, liquidation_date),
+		    egrul_updated_at = now()
+		WHERE id = $1`,
+		id, reg.Kpp, reg.Address, reg.FullName, reg.ShortName, reg.Ogrn, reg.Director, reg.RegistrationDate, reg.LiquidationDate)
+	if err != nil {
+		return 0, false, fmt.Errorf("ошибка обновления сведений контрагента %d: %w", id, err)
+	}
+
+	// Наименования из выписки тоже пополняют историю вариантов
+	for _, name := range []string{reg.FullName, reg.ShortName} {
+		if name == "" {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO counterparty_names (counterparty_id, name) VALUES ($1, $2)
+			ON CONFLICT (counterparty_id, name) DO NOTHING`, id, name); err != nil {
+			return 0, false, fmt.Errorf("ошибка сохранения наименования контрагента %d: %w", id, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, false, fmt.Errorf("ошибка фиксации сведений контрагента %d: %w", id, err)
+	}
+	return id, created, nil
+}
+
+// Resolve находит контрагента по ИНН и КПП, а если такого нет — по ИНН с пустым КПП, и блокирует его.
+// Если КПП не указан, подходит любой контрагент с этим ИНН, в первую очередь с заполненным КПП.
+// Если контрагент не найден, он создаётся из cp. Вызывать нужно в транзакции
+func Resolve(ctx context.Context, db database.DBTX, cp models.Counterparty) (id int, created bool, err error) {
+	err = db.QueryRowContext(ctx,
+		`SELECT id FROM counterparties
+		WHERE inn = $1 AND ($2 = '' OR kpp = $2 OR kpp IS NULL)
+		ORDER BY kpp NULLS LAST, id
+		LIMIT 1
+		FOR UPDATE`, cp.Inn, cp.Kpp).Scan(&id)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		cp, err = Create(ctx, db, cp)
+		if err != nil {
+			return 0, false, err
+		}
+		return cp.ID, true, nil
+	case err != nil:
+		return 0, false, fmt.Errorf("ошибка поиска контрагента с ИНН %s: %w", cp.Inn, err)
+	}
+	return id, false, nil
+}
@@ -0,0 +1,276 @@
+package counterparties
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"statements/internal/database"
+	"statements/internal/models"
+	"strings"
+)
+
+// counterpartyColumns — список колонок контрагента в порядке, ожидаемом scanCounterparty
+const counterpartyColumns = `id, name, inn, COALESCE(kpp, ''), COALESCE(address, ''),
+	COALESCE(full_name, ''), COALESCE(short_name, ''), COALESCE(ogrn, ''), COALESCE(director, ''),
+	COALESCE(registration_date::text, ''), COALESCE(liquidation_date::text, '')`
+
+var (
+	// ErrNotFound возвращается, если контрагент не найден
+	ErrNotFound = errors.New("контрагент не найден")
+	// ErrDuplicate возвращается при попытке создать второго контрагента с теми же ИНН и КПП
+	ErrDuplicate = errors.New("контрагент с таким ИНН и КПП уже существует")
+)
+
+// InUseError возвращается при попытке удалить контрагента, на которого ссылаются контракты или заявки на оплату
+type InUseError struct {
+	Contracts       int
+	PaymentRequests int
+}
+
+func (e *InUseError) Error() string {
+	var uses []string
+	if e.Contracts > 0 {
+		uses = append(uses, fmt.Sprintf("контрактах (%d)", e.Contracts))
+	}
+	if e.PaymentRequests > 0 {
+		uses = append(uses, fmt.Sprintf("заявках на оплату (%d)", e.PaymentRequests))
+	}
+	if len(uses) == 0 {
+		return "контрагент используется в других записях и не может быть удалён"
+	}
+	return "контрагент используется в " + strings.Join(uses, " и ") + " и не может быть удалён"
+}
+
+// ListFilter описывает параметры поиска и постраничного вывода контрагентов
+type ListFilter struct {
+	Query  string // Начало ИНН или часть наименования
+	Limit  int
+	Offset int
+}
+
+// Validate проверяет обязательные поля и формат реквизитов контрагента
+func Validate(cp models.Counterparty) error {
+	if strings.TrimSpace(cp.Name) == "" {
+		return errors.New("наименование контрагента обязательно")
+	}
+	if !isDigits(cp.Inn) || (len(cp.Inn) != 10 && len(cp.Inn) != 12) {
+		return errors.New("ИНН должен состоять из 10 или 12 цифр")
+	}
+	if cp.Kpp != "" && (!isDigits(cp.Kpp) || len(cp.Kpp) != 9) {
+		return errors.New("КПП должен состоять из 9 цифр")
+	}
+	return nil
+}
+
+// List возвращает страницу контрагентов и общее количество найденных записей
+func List(ctx context.Context, db database.DBTX, filter ListFilter) ([]models.Counterparty, int, error) {
+	where := ""
+	args := []interface{}{}
+	if q := strings.TrimSpace(filter.Query); q != "" {
+		args = append(args, database.EscapeLike(q))
+		where = `WHERE inn LIKE $1 || '%' OR name ILIKE '%' || $1 || '%'`
+	}
+
+	var total int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM counterparties "+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("ошибка подсчёта контрагентов: %w", err)
+	}
+
+	query := fmt.Sprintf(
+		`SELECT %s
+		FROM counterparties %s
+		ORDER BY name, id
+		LIMIT $%d OFFSET $%d`, counterpartyColumns, where, len(args)+1, len(args)+2)
+	rows, err := db.QueryContext(ctx, query, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ошибка получения списка контрагентов: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]models.Counterparty, 0)
+	for rows.Next() {
+		cp, err := scanCounterparty(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("ошибка чтения контрагента: %w", err)
+		}
+		items = append(items, cp)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("ошибка чтения списка контрагентов: %w", err)
+	}
+
+	return items, total, nil
+}
+
+// Get возвращает контрагента по идентификатору
+func Get(ctx context.Context, db database.DBTX, id int) (models.Counterparty, error) {
+	cp, err := scanCounterparty(db.QueryRowContext(ctx,
+		"SELECT "+counterpartyColumns+" FROM counterparties WHERE id = $1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return cp, ErrNotFound
+	}
+	if err != nil {
+		return cp, fmt.Errorf("ошибка получения контрагента %d: %w", id, err)
+	}
+	return cp, nil
+}
+
+// Create создаёт контрагента и возвращает его с присвоенным идентификатором
+func Create(ctx context.Context, db database.DBTX, cp models.Counterparty) (models.Counterparty, error) {
+	err := db.QueryRowContext(ctx,
+		`INSERT INTO counterparties (name, inn, kpp, address)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''))
+		RETURNING id`,
+		cp.Name, cp.Inn, cp.Kpp, cp.Address).Scan(&cp.ID)
+	if database.IsUniqueViolation(err) {
+		return cp, ErrDuplicate
+	}
+	if err != nil {
+		return cp, fmt.Errorf("ошибка создания контрагента: %w", err)
+	}
+	return cp, nil
+}
+
+// Update обновляет реквизиты контрагента
+func Update(ctx context.Context, db database.DBTX, cp models.Counterparty) error {
+	res, err := db.ExecContext(ctx,
+		`UPDATE counterparties
+		SET name = $2, inn = $3, kpp = NULLIF($4, ''), address = NULLIF($5, '')
+		WHERE id = $1`,
+		cp.ID, cp.Name, cp.Inn, cp.Kpp, cp.Address)
+	if database.IsUniqueViolation(err) {
+		return ErrDuplicate
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка обновления контрагента %d: %w", cp.ID, err)
+	}
+	return checkAffected(res)
+}
+
+// Delete удаляет контрагента, если на него не ссылается ни один контракт и ни одна заявка на оплату
+func Delete(ctx context.Context, db database.DBTX, id int) error {
+	inUse, err := usage(ctx, db, id)
+	if err != nil {
+		return err
+	}
+	if inUse.Contracts > 0 || inUse.PaymentRequests > 0 {
+		return inUse
+	}
+
+	res, err := db.ExecContext(ctx, "DELETE FROM counterparties WHERE id = $1", id)
+	if database.IsForeignKeyViolation(err) {
+		// Контракт или заявка могли появиться между проверкой и удалением
+		if recount, err := usage(ctx, db, id); err == nil {
+			inUse = recount
+		}
+		return inUse
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка удаления контрагента %d: %w", id, err)
+	}
+	return checkAffected(res)
+}
+
+// usage подсчитывает контракты и заявки на оплату, ссылающиеся на контрагента
+func usage(ctx context.Context, db database.DBTX, id int) (*InUseError, error) {
+	inUse := &InUseError{}
+	err := db.QueryRowContext(ctx,
+		`SELECT (SELECT COUNT(*) FROM contracts WHERE counterparty_id = $1),
+		        (SELECT COUNT(*) FROM payment_requests WHERE counterparty_id = $1)`, id).
+		Scan(&inUse.Contracts, &inUse.PaymentRequests)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка проверки использования контрагента %d: %w", id, err)
+	}
+	return inUse, nil
+}
+
+// Overview возвращает карточку контрагента с контрактами, итогами платежей и известными счетами
+func Overview(ctx context.Context, db database.DBTX, id int) (models.CounterpartyOverview, error) {
+	var overview models.CounterpartyOverview
+
+	cp, err := Get(ctx, db, id)
+	if err != nil {
+		return overview, err
+	}
+	overview.Counterparty = cp
+
+	rows, err := db.QueryContext(ctx,
+		`SELECT c.uid::text, c.contract_number, c.contract_date::text,
+		        COALESCE(tm.execution_period, c.execution_period)::text, COALESCE(tm.amount, c.amount)::float8,
+		        c.contract_type, COALESCE(tm.subject, c.subject), COALESCE(c.eaist_status::text, '')
+		FROM contracts c
+		CROSS JOIN LATERAL contract_amended_terms(c.uid, CURRENT_DATE) tm
+		WHERE c.counterparty_id = $1 AND c.deleted_at IS NULL
+		ORDER BY c.contract_date DESC, c.contract_number`, id)
+	if err != nil {
+		return overview, fmt.Errorf("ошибка получения контрактов контрагента %d: %w", id, err)
+	}
+	defer rows.Close()
+
+	overview.Contracts = make([]models.CounterpartyContract, 0)
+	for rows.Next() {
+		var contract models.CounterpartyContract
+		if err := rows.Scan(&contract.ID, &contract.ContractNumber, &contract.ContractDate, &contract.ExecutionPeriod, &contract.Amount,
+			&contract.ContractType, &contract.Subject, &contract.EaistStatus); err != nil {
+			return overview, fmt.Errorf("ошибка чтения контракта: %w", err)
+		}
+		overview.Contracts = append(overview.Contracts, contract)
+	}
+	if err := rows.Err(); err != nil {
+		return overview, fmt.Errorf("ошибка чтения контрактов контрагента %d: %w", id, err)
+	}
+
+	// Платежи контрагенту — списания, где он получатель; поступления — зачисления, где он плательщик
+	err = db.QueryRowContext(ctx,
+		`SELECT COALESCE(SUM(CASE WHEN counterparty_c_id = $1 THEN debit ELSE 0 END), 0)::float8,
+		        COALESCE(SUM(CASE WHEN counterparty_id = $1 THEN credit ELSE 0 END), 0)::float8,
+		        COUNT(*),
+		        COALESCE(MAX(date)::text, '')
+		FROM transactions
+		WHERE counterparty_id = $1 OR counterparty_c_id = $1`, id).
+		Scan(&overview.Payments.PaidTo, &overview.Payments.ReceivedFrom, &overview.Payments.TransactionCount, &overview.Payments.LastPaymentDate)
+	if err != nil {
+		return overview, fmt.Errorf("ошибка расчёта платежей контрагента %d: %w", id, err)
+	}
+
+	overview.Accounts, err = Accounts(ctx, db, id)
+	if err != nil {
+		return overview, err
+	}
+
+	return overview, nil
+}
+
+// scanCounterparty читает контрагента из строки, выбранной по counterpartyColumns
+func scanCounterparty(row database.RowScanner) (models.Counterparty, error) {
+	var cp models.Counterparty
+	err := row.Scan(&cp.ID, &cp.Name, &cp.Inn, &cp.Kpp, &cp.Address,
+		&cp.FullName, &cp.ShortName, &cp.Ogrn, &cp.Director, &cp.RegistrationDate, &cp.LiquidationDate)
+	return cp, err
+}
+
+// checkAffected возвращает ErrNotFound, если запрос не затронул ни одной строки
+func checkAffected(res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// isDigits проверяет, что строка непустая и состоит только из цифр
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,13 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+)
+
+// DBTX описывает общие методы *sql.DB и *sql.Tx, чтобы функции работы с данными выполнялись и внутри транзакций
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
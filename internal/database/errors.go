@@ -0,0 +1,29 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/jackc/pgconn"
+)
+
+// Коды ошибок PostgreSQL, которые обрабатываются приложением
+const (
+	pgForeignKeyViolation = "23503"
+	pgUniqueViolation     = "23505"
+)
+
+// IsUniqueViolation проверяет, вызвана ли ошибка нарушением ограничения уникальности
+func IsUniqueViolation(err error) bool {
+	return hasPgCode(err, pgUniqueViolation)
+}
+
+// IsForeignKeyViolation проверяет, вызвана ли ошибка нарушением внешнего ключа
+func IsForeignKeyViolation(err error) bool {
+	return hasPgCode(err, pgForeignKeyViolation)
+}
+
+// hasPgCode проверяет код ошибки PostgreSQL
+func hasPgCode(err error, code string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == code
+}
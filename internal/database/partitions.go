@@ -0,0 +1,95 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// ContractPartition описывает партицию таблицы contracts
+type ContractPartition struct {
+	Name  string `json:"name"`
+	Bound string `json:"bound"`
+	Rows  int64  `json:"rows"`
+}
+
+// EnsureContractPartition создаёт годовую партицию contracts, если её ещё нет, и сообщает, была ли она создана
+func EnsureContractPartition(ctx context.Context, year int) (bool, error) {
+	return ensureContractPartition(ctx, DB, year)
+}
+
+func ensureContractPartition(ctx context.Context, db DBTX, year int) (bool, error) {
+	var created bool
+	if err := db.QueryRowContext(ctx, "SELECT public.ensure_contracts_partition($1)", year).Scan(&created); err != nil {
+		return false, fmt.Errorf("ошибка создания партиции contracts за %d год: %w", year, err)
+	}
+	if created {
+		log.Printf("Создана партиция contracts_%d", year)
+	}
+	return created, nil
+}
+
+// EnsureContractPartitionFor создаёт партицию contracts для года указанной даты через db.
+// Внутри транзакции партиция создаётся в ней же: отдельное соединение ждало бы блокировок,
+// которые транзакция держит на contracts, а при откате транзакции созданная партиция тоже откатывается
+func EnsureContractPartitionFor(ctx context.Context, db DBTX, date time.Time) error {
+	_, err := ensureContractPartition(ctx, db, date.Year())
+	return err
+}
+
+// EnsureContractPartitions создаёт годовые партиции contracts за годы с fromYear по toYear включительно
+// и возвращает годы, для которых партиции были созданы
+func EnsureContractPartitions(ctx context.Context, fromYear, toYear int) ([]int, error) {
+	var created []int
+	for year := fromYear; year <= toYear; year++ {
+		ok, err := EnsureContractPartition(ctx, year)
+		if err != nil {
+			return created, err
+		}
+		if ok {
+			created = append(created, year)
+		}
+	}
+	return created, nil
+}
+
+// ListContractPartitions возвращает партиции таблицы contracts с границами и количеством строк
+func ListContractPartitions(ctx context.Context) ([]ContractPartition, error) {
+	rows, err := DB.QueryContext(ctx,
+		`SELECT c.relname, pg_get_expr(c.relpartbound, c.oid)
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		JOIN pg_class p ON p.oid = i.inhparent
+		JOIN pg_namespace n ON n.oid = p.relnamespace
+		WHERE n.nspname = 'public' AND p.relname = 'contracts'
+		ORDER BY c.relname`)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения партиций contracts: %w", err)
+	}
+
+	var partitions []ContractPartition
+	for rows.Next() {
+		var p ContractPartition
+		if err := rows.Scan(&p.Name, &p.Bound); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("ошибка чтения партиции: %w", err)
+		}
+		partitions = append(partitions, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения партиций contracts: %w", err)
+	}
+
+	for i := range partitions {
+		query := fmt.Sprintf("SELECT COUNT(*) FROM public.%s", pgx.Identifier{partitions[i].Name}.Sanitize())
+		if err := DB.QueryRowContext(ctx, query).Scan(&partitions[i].Rows); err != nil {
+			return nil, fmt.Errorf("ошибка подсчёта строк партиции %s: %w", partitions[i].Name, err)
+		}
+	}
+
+	return partitions, nil
+}
@@ -0,0 +1,15 @@
+package database
+
+import "strings"
+
+// RowScanner описывает общий метод Scan у *sql.Row и *sql.Rows
+type RowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+var likeReplacer = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// EscapeLike экранирует спецсимволы шаблона LIKE, чтобы строка поиска совпадала буквально
+func EscapeLike(s string) string {
+	return likeReplacer.Replace(s)
+}
@@ -0,0 +1,42 @@
+package database
+
+import (
+	"sort"
+	"strings"
+)
+
+// FieldErrors содержит ошибки по отдельным полям: ключ — имя поля формы, значение — текст ошибки.
+// Встраивается в ошибки проверки пакетов, которые добавляют к тексту, какие данные проверялись
+type FieldErrors struct {
+	Fields map[string]string
+}
+
+// Add запоминает ошибку поля. Для каждого поля сохраняется первая найденная ошибка
+func (e *FieldErrors) Add(field, message string) {
+	if e.Fields == nil {
+		e.Fields = make(map[string]string)
+	}
+	if _, ok := e.Fields[field]; !ok {
+		e.Fields[field] = message
+	}
+}
+
+// Empty сообщает, что ошибок нет
+func (e *FieldErrors) Empty() bool {
+	return len(e.Fields) == 0
+}
+
+// Describe перечисляет ошибки одной строкой в порядке имён полей: "поле: ошибка; поле: ошибка"
+func (e *FieldErrors) Describe() string {
+	fields := make([]string, 0, len(e.Fields))
+	for field := range e.Fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	parts := make([]string, 0, len(fields))
+	for _, field := range fields {
+		parts = append(parts, field+": "+e.Fields[field])
+	}
+	return strings.Join(parts, "; ")
+}
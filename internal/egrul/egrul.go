@@ -0,0 +1,265 @@
+package egrul
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"statements/internal/models"
+	"statements/internal/utils"
+	"strings"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// ErrNoRecords возвращается, если в файле не найдено ни одного сведения о ЮЛ или ИП
+var ErrNoRecords = errors.New("в файле нет сведений ЕГРЮЛ/ЕГРИП")
+
+// personName описывает ФИО физического лица
+type personName struct {
+	Last   string `xml:"Фамилия,attr"`
+	First  string `xml:"Имя,attr"`
+	Middle string `xml:"Отчество,attr"`
+}
+
+// String возвращает ФИО одной строкой
+func (p personName) String() string {
+	return utils.JoinNonEmpty(" ", p.Last, p.First, p.Middle)
+}
+
+// addressElement описывает элемент адреса в формате КЛАДР (тип и наименование)
+type addressElement struct {
+	Type string `xml:"Тип,attr"`
+	Name string `xml:"Наим,attr"`
+}
+
+// addressRF описывает адрес юридического лица в формате КЛАДР
+type addressRF struct {
+	Index    string `xml:"Индекс,attr"`
+	House    string `xml:"Дом,attr"`
+	Building string `xml:"Корпус,attr"`
+	Flat     string `xml:"Кварт,attr"`
+	Region   struct {
+		Type string `xml:"ТипРегион,attr"`
+		Name string `xml:"НаимРегион,attr"`
+	} `xml:"Регион"`
+	District struct {
+		Type string `xml:"ТипРайон,attr"`
+		Name string `xml:"НаимРайон,attr"`
+	} `xml:"Район"`
+	City struct {
+		Type string `xml:"ТипГород,attr"`
+		Name string `xml:"НаимГород,attr"`
+	} `xml:"Город"`
+	Locality struct {
+		Type string `xml:"ТипНаселПункт,attr"`
+		Name string `xml:"НаимНаселПункт,attr"`
+	} `xml:"НаселПункт"`
+	Street struct {
+		Type string `xml:"ТипУлица,attr"`
+		Name string `xml:"НаимУлица,attr"`
+	} `xml:"Улица"`
+}
+
+// String собирает адрес КЛАДР в строку
+func (a addressRF) String() string {
+	return utils.JoinNonEmpty(", ",
+		a.Index,
+		utils.JoinNonEmpty(" ", a.Region.Name, a.Region.Type),
+		utils.JoinNonEmpty(" ", a.District.Name, a.District.Type),
+		utils.JoinNonEmpty(" ", a.City.Type, a.City.Name),
+		utils.JoinNonEmpty(" ", a.Locality.Type, a.Locality.Name),
+		utils.JoinNonEmpty(" ", a.Street.Type, a.Street.Name),
+		a.House, a.Building, a.Flat)
+}
+
+// addressFIAS описывает адрес юридического лица в формате ФИАС
+type addressFIAS struct {
+	Index        string         `xml:"Индекс,attr"`
+	Region       string         `xml:"НаимРегион"`
+	Municipality addressElement `xml:"МуниципРайон"`
+	Settlement   addressElement `xml:"ГородСелПоселен"`
+	Locality     struct {
+		Kind string `xml:"Вид,attr"`
+		Name string `xml:"Наим,attr"`
+	} `xml:"НаселенПункт"`
+	Street    addressElement `xml:"ЭлУлДорСети"`
+	Buildings []struct {
+		Type   string `xml:"Тип,attr"`
+		Number string `xml:"Номер,attr"`
+	} `xml:"Здание"`
+	Room struct {
+		Type   string `xml:"Тип,attr"`
+		Number string `xml:"Номер,attr"`
+	} `xml:"ПомещЗдания"`
+}
+
+// String собирает адрес ФИАС в строку
+func (a addressFIAS) String() string {
+	parts := []string{
+		a.Index,
+		a.Region,
+		a.Municipality.Name,
+		a.Settlement.Name,
+		utils.JoinNonEmpty(" ", a.Locality.Kind, a.Locality.Name),
+		utils.JoinNonEmpty(" ", a.Street.Type, a.Street.Name),
+	}
+	for _, b := range a.Buildings {
+		parts = append(parts, utils.JoinNonEmpty(" ", b.Type, b.Number))
+	}
+	parts = append(parts, utils.JoinNonEmpty(" ", a.Room.Type, a.Room.Number))
+	return utils.JoinNonEmpty(", ", parts...)
+}
+
+// legalEntity описывает элемент СвЮЛ выписки ЕГРЮЛ
+type legalEntity struct {
+	Ogrn     string `xml:"ОГРН,attr"`
+	OgrnDate string `xml:"ДатаОГРН,attr"`
+	Inn      string `xml:"ИНН,attr"`
+	Kpp      string `xml:"КПП,attr"`
+	Name     struct {
+		Full  string `xml:"НаимЮЛПолн,attr"`
+		Short struct {
+			Name string `xml:"НаимСокр,attr"`
+		} `xml:"СвНаимЮЛСокр"`
+	} `xml:"СвНаимЮЛ"`
+	Address struct {
+		RF   *addressRF   `xml:"АдресРФ"`
+		FIAS *addressFIAS `xml:"СвАдрЮЛФИАС"`
+	} `xml:"СвАдресЮЛ"`
+	Registration struct {
+		Date     string `xml:"ДатаРег,attr"`
+		OgrnDate string `xml:"ДатаОГРН,attr"`
+	} `xml:"СвОбрЮЛ"`
+	Termination *struct {
+		Date string `xml:"ДатаПрекрЮЛ,attr"`
+	} `xml:"СвПрекрЮЛ"`
+	Officials []struct {
+		Person   personName `xml:"СвФЛ"`
+		Position struct {
+			Name string `xml:"НаимДолжн,attr"`
+		} `xml:"СвДолжн"`
+	} `xml:"СведДолжнФЛ"`
+}
+
+// entrepreneur описывает элемент СвИП выписки ЕГРИП
+type entrepreneur struct {
+	Ogrn     string `xml:"ОГРНИП,attr"`
+	OgrnDate string `xml:"ДатаОГРНИП,attr"`
+	Inn      string `xml:"ИННФЛ,attr"`
+	Person   struct {
+		Name personName `xml:"ФИОРус"`
+	} `xml:"СвФЛ"`
+	Registration struct {
+		Date string `xml:"ДатаРег,attr"`
+	} `xml:"СвРегИП"`
+	Termination *struct {
+		Status struct {
+			Date string `xml:"ДатаПрекращ,attr"`
+		} `xml:"СвСтатус"`
+	} `xml:"СвПрекращ"`
+}
+
+// Parse читает выписку ЕГРЮЛ/ЕГРИП в формате ФНС и возвращает сведения обо всех найденных лицах.
+// Поддерживаются кодировки UTF-8 и windows-1251.
+func Parse(r io.Reader) ([]models.CounterpartyRegistration, error) {
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = charsetReader
+
+	var records []models.CounterpartyRegistration
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения XML: %w", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "СвЮЛ":
+			var ul legalEntity
+			if err := decoder.DecodeElement(&ul, &start); err != nil {
+				return nil, fmt.Errorf("ошибка разбора сведений ЮЛ: %w", err)
+			}
+			records = append(records, ul.registration())
+		case "СвИП":
+			var ip entrepreneur
+			if err := decoder.DecodeElement(&ip, &start); err != nil {
+				return nil, fmt.Errorf("ошибка разбора сведений ИП: %w", err)
+			}
+			records = append(records, ip.registration())
+		}
+	}
+
+	if len(records) == 0 {
+		return nil, ErrNoRecords
+	}
+	return records, nil
+}
+
+// registration преобразует сведения ЮЛ в модель
+func (ul legalEntity) registration() models.CounterpartyRegistration {
+	reg := models.CounterpartyRegistration{
+		Inn:              strings.TrimSpace(ul.Inn),
+		Kpp:              strings.TrimSpace(ul.Kpp),
+		Ogrn:             strings.TrimSpace(ul.Ogrn),
+		FullName:         strings.TrimSpace(ul.Name.Full),
+		ShortName:        strings.TrimSpace(ul.Name.Short.Name),
+		RegistrationDate: utils.FirstNonEmpty(ul.Registration.Date, ul.Registration.OgrnDate, ul.OgrnDate),
+	}
+
+	switch {
+	case ul.Address.FIAS != nil:
+		reg.Address = ul.Address.FIAS.String()
+	case ul.Address.RF != nil:
+		reg.Address = ul.Address.RF.String()
+	}
+
+	if len(ul.Officials) > 0 {
+		official := ul.Officials[0]
+		reg.Director = utils.JoinNonEmpty(" ", strings.TrimSpace(official.Position.Name), official.Person.String())
+	}
+
+	if ul.Termination != nil {
+		reg.LiquidationDate = ul.Termination.Date
+	}
+
+	return reg
+}
+
+// registration преобразует сведения ИП в модель
+func (ip entrepreneur) registration() models.CounterpartyRegistration {
+	fio := ip.Person.Name.String()
+	reg := models.CounterpartyRegistration{
+		Inn:              strings.TrimSpace(ip.Inn),
+		Ogrn:             strings.TrimSpace(ip.Ogrn),
+		FullName:         utils.JoinNonEmpty(" ", "Индивидуальный предприниматель", fio),
+		ShortName:        utils.JoinNonEmpty(" ", "ИП", fio),
+		Director:         fio,
+		RegistrationDate: utils.FirstNonEmpty(ip.Registration.Date, ip.OgrnDate),
+		Entrepreneur:     true,
+	}
+
+	if ip.Termination != nil {
+		reg.LiquidationDate = ip.Termination.Status.Date
+	}
+
+	return reg
+}
+
+// charsetReader поддерживает кодировку windows-1251, в которой ФНС обычно выдаёт выписки
+func charsetReader(label string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(label) {
+	case "windows-1251", "cp1251":
+		return charmap.Windows1251.NewDecoder().Reader(input), nil
+	case "utf-8", "utf8":
+		return input, nil
+	}
+	return nil, fmt.Errorf("неподдерживаемая кодировка XML: %s", label)
+}
@@ -0,0 +1,84 @@
+package egrul
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"statements/internal/models"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		file string
+		want []models.CounterpartyRegistration
+	}{
+		{"ul.xml", []models.CounterpartyRegistration{
+			{
+				Inn:              "7701234567",
+				Kpp:              "770101001",
+				Ogrn:             "1027700132195",
+				FullName:         `ОБЩЕСТВО С ОГРАНИЧЕННОЙ ОТВЕТСТВЕННОСТЬЮ "РОМАШКА"`,
+				ShortName:        `ООО "РОМАШКА"`,
+				Address:          "101000, ГОРОД МОСКВА, МУНИЦИПАЛЬНЫЙ ОКРУГ БАСМАННЫЙ, УЛИЦА МЯСНИЦКАЯ, ДОМ 10, СТРОЕНИЕ 1, ПОМЕЩЕНИЕ 5",
+				Director:         "ГЕНЕРАЛЬНЫЙ ДИРЕКТОР ИВАНОВ ИВАН ИВАНОВИЧ",
+				RegistrationDate: "1998-03-12",
+			},
+			{
+				Inn:              "7702000001",
+				Kpp:              "770201001",
+				Ogrn:             "1037700000010",
+				FullName:         `АКЦИОНЕРНОЕ ОБЩЕСТВО "ВАСИЛЁК"`,
+				Address:          "129090, МОСКВА ГОРОД, ПРОСПЕКТ МИРА, ДОМ 3, КОРПУС 2",
+				RegistrationDate: "2003-01-20",
+				LiquidationDate:  "2023-11-01",
+			},
+		}},
+		{"ip_cp1251.xml", []models.CounterpartyRegistration{{
+			Inn:              "771234567890",
+			Ogrn:             "304770000123456",
+			FullName:         "Индивидуальный предприниматель СЕМЁНОВ СЕМЁН СЕМЁНОВИЧ",
+			ShortName:        "ИП СЕМЁНОВ СЕМЁН СЕМЁНОВИЧ",
+			Director:         "СЕМЁНОВ СЕМЁН СЕМЁНОВИЧ",
+			RegistrationDate: "2004-05-17",
+			LiquidationDate:  "2022-06-30",
+			Entrepreneur:     true,
+		}}},
+		// В неполной выписке нет КПП, ОГРН, адреса и руководителя: эти поля остаются пустыми,
+		// и ApplyRegistration сохраняет ранее загруженные значения
+		{"partial.xml", []models.CounterpartyRegistration{{
+			Inn:      "7703000002",
+			FullName: `ГОСУДАРСТВЕННОЕ БЮДЖЕТНОЕ УЧРЕЖДЕНИЕ ГОРОДА МОСКВЫ "ЖИЛИЩНИК РАЙОНА МАРЬИНО"`,
+		}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			f, err := os.Open("testdata/" + tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			got, err := Parse(f)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() =\n%+v\nожидалось\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	if _, err := Parse(strings.NewReader(`<?xml version="1.0"?><Файл><Документ/></Файл>`)); !errors.Is(err, ErrNoRecords) {
+		t.Errorf("файл без сведений: %v, ожидалась ErrNoRecords", err)
+	}
+	if _, err := Parse(strings.NewReader(`<Файл><СвЮЛ ИНН="7701234567">`)); err == nil || errors.Is(err, ErrNoRecords) {
+		t.Errorf("обрезанный файл: %v, ожидалась ошибка разбора", err)
+	}
+	if _, err := Parse(strings.NewReader(`<?xml version="1.0" encoding="koi8-r"?><Файл/>`)); err == nil {
+		t.Error("неподдерживаемая кодировка не отклонена")
+	}
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// currentUser возвращает идентификатор пользователя из claims JWT-токена, установленных AuthMiddleware
+func currentUser(c *gin.Context) string {
+	value, ok := c.Get("claims")
+	if !ok {
+		return ""
+	}
+	claims, ok := value.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	for _, key := range []string{"sub", "username", "email"} {
+		if v, ok := claims[key]; ok && v != nil {
+			return fmt.Sprintf("%v", v)
+		}
+	}
+	return ""
+}
+
+// currentRoles возвращает роли пользователя из claim roles (массив или строка через запятую) и role
+func currentRoles(c *gin.Context) []string {
+	value, ok := c.Get("claims")
+	if !ok {
+		return nil
+	}
+	claims, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	var roles []string
+	add := func(v interface{}) {
+		if s := strings.TrimSpace(fmt.Sprintf("%v", v)); s != "" {
+			roles = append(roles, s)
+		}
+	}
+	switch v := claims["roles"].(type) {
+	case []interface{}:
+		for _, role := range v {
+			add(role)
+		}
+	case string:
+		for _, role := range strings.Split(v, ",") {
+			add(role)
+		}
+	}
+	if v, ok := claims["role"].(string); ok {
+		add(v)
+	}
+	return roles
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"statements/internal/contracts"
+	"statements/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// amendmentRequest описывает тело запроса на добавление дополнительного соглашения.
+// Указываются только изменяемые условия контракта
+type amendmentRequest struct {
+	Number          string   `json:"number"`
+	Date            string   `json:"date"`
+	EffectiveDate   string   `json:"effective_date"` // По умолчанию — дата соглашения
+	Amount          *float64 `json:"amount"`
+	ExecutionPeriod *string  `json:"execution_period"`
+	ValidityPeriod  *string  `json:"validity_period"`
+	Subject         *string  `json:"subject"`
+	PaymentDays     *int     `json:"payment_days"`
+	Comment         string   `json:"comment"`
+	DocumentID      *int     `json:"document_id"` // Документ контракта вида amendment с текстом соглашения
+}
+
+// HandleContractAmendments возвращает дополнительные соглашения контракта с изменёнными условиями
+func HandleContractAmendments(c *gin.Context, db *sql.DB) {
+	amendments, err := contracts.Amendments(c.Request.Context(), db, c.Param("id"))
+	if err != nil {
+		respondContractError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": amendments})
+}
+
+// HandleContractAmendmentAdd добавляет к контракту дополнительное соглашение
+func HandleContractAmendmentAdd(c *gin.Context, db *sql.DB) {
+	var req amendmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверные данные запроса"})
+		return
+	}
+
+	user := currentUser(c)
+	amendment, err := contracts.AddAmendment(c.Request.Context(), db, models.ContractAmendment{
+		ContractID:      c.Param("id"),
+		Number:          req.Number,
+		Date:            req.Date,
+		EffectiveDate:   req.EffectiveDate,
+		Amount:          req.Amount,
+		ExecutionPeriod: req.ExecutionPeriod,
+		ValidityPeriod:  req.ValidityPeriod,
+		Subject:         req.Subject,
+		PaymentDays:     req.PaymentDays,
+		Comment:         req.Comment,
+		DocumentID:      req.DocumentID,
+		CreatedBy:       user,
+	})
+	if err != nil {
+		respondContractError(c, err)
+		return
+	}
+
+	log.Printf("К контракту %s добавлено соглашение %s (%s)", amendment.ContractID, amendment.Number, user)
+	c.JSON(http.StatusCreated, amendment)
+}
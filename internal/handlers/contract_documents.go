@@ -0,0 +1,323 @@
+package handlers
+
+import (
+	"archive/zip"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"statements/internal/config"
+	"statements/internal/contracts"
+	"statements/internal/models"
+	"statements/internal/storage"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HandleContractDocuments возвращает текущие версии документов контракта
+func HandleContractDocuments(c *gin.Context, db *sql.DB) {
+	docs, err := contracts.Documents(c.Request.Context(), db, c.Param("id"))
+	if err != nil {
+		respondContractError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": docs})
+}
+
+// HandleContractDocumentVersions возвращает историю версий документа контракта
+func HandleContractDocumentVersions(c *gin.Context, db *sql.DB) {
+	documentID, ok := parseIDParam(c, "doc")
+	if !ok {
+		respondContractError(c, contracts.ErrDocumentNotFound)
+		return
+	}
+	docs, err := contracts.DocumentVersions(c.Request.Context(), db, c.Param("id"), documentID)
+	if err != nil {
+		respondContractError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": docs})
+}
+
+// HandleContractDocumentAdd добавляет к контракту новый документ. Форма: type — вид документа, file — файл
+func HandleContractDocumentAdd(c *gin.Context, cfg *config.Config, store storage.BlobStore, db *sql.DB) {
+	// Поля формы доступны только после разбора с ограничением размера внутри saveContractDocument
+	documentType := func() (string, error) {
+		return contracts.NormalizeDocumentType(c.PostForm("type"))
+	}
+	saveContractDocument(c, cfg, store, db, documentType, func(tx *sql.Tx, f models.StoredFile) (models.ContractDocument, error) {
+		return contracts.AddDocument(c.Request.Context(), tx, c.Param("id"), c.PostForm("type"), f)
+	})
+}
+
+// HandleContractDocumentReplace загружает новую версию документа контракта. Предыдущие версии остаются доступны
+func HandleContractDocumentReplace(c *gin.Context, cfg *config.Config, store storage.BlobStore, db *sql.DB) {
+	documentID, ok := parseIDParam(c, "doc")
+	if !ok {
+		respondContractError(c, contracts.ErrDocumentNotFound)
+		return
+	}
+	documentType := func() (string, error) {
+		doc, err := contracts.Document(c.Request.Context(), db, c.Param("id"), documentID)
+		return doc.Type, err
+	}
+	saveContractDocument(c, cfg, store, db, documentType, func(tx *sql.Tx, f models.StoredFile) (models.ContractDocument, error) {
+		return contracts.ReplaceDocument(c.Request.Context(), tx, c.Param("id"), documentID, f)
+	})
+}
+
+// saveContractDocument проверяет файл из поля file, сохраняет его в хранилище и вызывает save в транзакции.
+// documentType возвращает вид документа после разбора формы: файл документа «ecp» проверяется как подпись.
+// Если save вернул ошибку, файл удаляется из хранилища
+func saveContractDocument(c *gin.Context, cfg *config.Config, store storage.BlobStore, db *sql.DB,
+	documentType func() (string, error), save func(tx *sql.Tx, f models.StoredFile) (models.ContractDocument, error)) {
+	ctx := c.Request.Context()
+
+	limits := cfg.FileUpload.LimitsFor("contracts")
+	form, ok := parseUploadForm(c, limits)
+	if !ok {
+		return
+	}
+	if len(form.File["file"]) != 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Прикрепите один файл в поле file"})
+		return
+	}
+	docType, err := documentType()
+	if err != nil {
+		respondContractError(c, err)
+		return
+	}
+	if errs := checkFiles(form, limits, map[string]bool{"file": docType == "ecp"}, "file"); len(errs) > 0 {
+		respondUploadErrors(c, errs)
+		return
+	}
+
+	ct, err := contracts.Get(ctx, db, c.Param("id"))
+	if err != nil {
+		respondContractError(c, err)
+		return
+	}
+
+	upload := storage.NewUpload(store)
+	defer func() {
+		if err := upload.Rollback(context.Background()); err != nil {
+			log.Printf("Ошибка удаления несохранённого документа контракта %s: %v", ct.ID, err)
+		}
+	}()
+
+	f, err := upload.PutFile(ctx, "contracts/"+ct.ID, form.File["file"][0], currentUser(c))
+	if err != nil {
+		log.Printf("Ошибка сохранения документа контракта %s: %v", ct.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка сохранения файла"})
+		return
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		respondContractError(c, fmt.Errorf("ошибка начала транзакции: %w", err))
+		return
+	}
+	defer tx.Rollback()
+
+	if err := upload.Record(ctx, tx); err != nil {
+		respondContractError(c, err)
+		return
+	}
+	doc, err := save(tx, f)
+	if err != nil {
+		respondContractError(c, err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondContractError(c, fmt.Errorf("ошибка фиксации документа контракта %s: %w", ct.ID, err))
+		return
+	}
+	upload.Commit()
+
+	// Новая версия файла контракта или подписи требует повторной проверки ЭЦП
+	if doc.Type == "contract" || doc.Type == "ecp" {
+		if _, err := checkContractSignature(ctx, store, db, ct.ID, currentUser(c)); err != nil && !errors.Is(err, contracts.ErrNoSignature) {
+			log.Printf("Ошибка проверки ЭЦП контракта %s: %v", ct.ID, err)
+		}
+	}
+
+	c.JSON(http.StatusCreated, doc)
+}
+
+// HandleContractDocumentDownload отдаёт файл документа контракта с исходным именем.
+// По умолчанию отдаётся текущая версия, параметр version выбирает одну из предыдущих, inline=true — предпросмотр
+func HandleContractDocumentDownload(c *gin.Context, store storage.BlobStore, db *sql.DB) {
+	documentID, ok := parseIDParam(c, "doc")
+	if !ok {
+		respondContractError(c, contracts.ErrDocumentNotFound)
+		return
+	}
+	version := 0
+	if value := c.Query("version"); value != "" {
+		v, err := strconv.Atoi(value)
+		if err != nil || v <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный номер версии"})
+			return
+		}
+		version = v
+	}
+
+	doc, err := contracts.DocumentVersion(c.Request.Context(), db, c.Param("id"), documentID, version)
+	if err != nil {
+		respondContractError(c, err)
+		return
+	}
+
+	sendStoredFile(c, store, doc.Key, doc.FileName, doc.ContentType, doc.Size)
+}
+
+// sendStoredFile отдаёт файл из хранилища с исходным именем.
+// С параметром inline=true файл открывается в браузере для предпросмотра
+func sendStoredFile(c *gin.Context, store storage.BlobStore, key, fileName, contentType string, size int64) {
+	file, err := store.Get(c.Request.Context(), key)
+	if err != nil {
+		respondStorageError(c, key, err)
+		return
+	}
+	defer file.Close()
+
+	disposition := "attachment"
+	if inline, _ := strconv.ParseBool(c.Query("inline")); inline {
+		disposition = "inline"
+	}
+
+	c.Header("Content-Type", fileContentType(contentType, fileName))
+	c.Header("Content-Disposition", contentDisposition(disposition, fileName))
+	c.Header("X-Content-Type-Options", "nosniff")
+	if size > 0 {
+		c.Header("Content-Length", strconv.FormatInt(size, 10))
+	}
+	c.Status(http.StatusOK)
+
+	if _, err := io.Copy(c.Writer, file); err != nil {
+		log.Printf("Ошибка отправки файла %s: %v", key, err)
+	}
+}
+
+// HandleContractDocumentsArchive отдаёт ZIP-архив с текущими версиями документов контракта
+func HandleContractDocumentsArchive(c *gin.Context, store storage.BlobStore, db *sql.DB) {
+	ctx := c.Request.Context()
+	ct, err := contracts.Get(ctx, db, c.Param("id"))
+	if err != nil {
+		respondContractError(c, err)
+		return
+	}
+	docs, err := contracts.Documents(ctx, db, ct.ID)
+	if err != nil {
+		respondContractError(c, err)
+		return
+	}
+	if len(docs) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "У контракта нет документов"})
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", contentDisposition("attachment", "Контракт "+strings.ReplaceAll(ct.ContractNumber, "/", "-")+" от "+ct.ContractDate+".zip"))
+	c.Status(http.StatusOK)
+
+	// Заголовки уже отправлены, поэтому ошибки отдельных файлов только логируются
+	archive := zip.NewWriter(c.Writer)
+	names := make(map[string]int)
+	for _, doc := range docs {
+		if err := addToArchive(c, archive, store, doc, uniqueName(names, doc.FileName)); err != nil {
+			log.Printf("Ошибка добавления документа %d контракта %s в архив: %v", doc.ID, ct.ID, err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		log.Printf("Ошибка формирования архива документов контракта %s: %v", ct.ID, err)
+	}
+}
+
+// addToArchive записывает документ в архив под именем name
+func addToArchive(c *gin.Context, archive *zip.Writer, store storage.BlobStore, doc models.ContractDocument, name string) error {
+	file, err := store.Get(c.Request.Context(), doc.Key)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	header := &zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()}
+	if uploadedAt, err := time.Parse("2006-01-02 15:04:05.999999-07", doc.UploadedAt); err == nil {
+		header.Modified = uploadedAt
+	}
+	w, err := archive.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, file)
+	return err
+}
+
+// uniqueName возвращает имя файла, не совпадающее с уже добавленными в архив: "акт.pdf", "акт (2).pdf"
+func uniqueName(used map[string]int, name string) string {
+	name = storage.OriginalName(name)
+	used[name]++
+	if used[name] == 1 {
+		return name
+	}
+	ext := path.Ext(name)
+	return uniqueName(used, fmt.Sprintf("%s (%d)%s", strings.TrimSuffix(name, ext), used[name], ext))
+}
+
+// fileContentType возвращает тип файла, сохранённый при загрузке, или определяет его по расширению
+func fileContentType(contentType, fileName string) string {
+	if contentType != "" {
+		return contentType
+	}
+	if t := mime.TypeByExtension(path.Ext(fileName)); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// contentDisposition формирует заголовок Content-Disposition с именем файла по RFC 5987/6266:
+// упрощённое ASCII-имя для старых клиентов и полное имя в UTF-8 в параметре filename*
+func contentDisposition(disposition, filename string) string {
+	fallback := strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e || r == '"' || r == '\\' {
+			return '_'
+		}
+		return r
+	}, filename)
+	return fmt.Sprintf(`%s; filename="%s"; filename*=UTF-8''%s`, disposition, fallback, encodeRFC5987(filename))
+}
+
+// encodeRFC5987 кодирует значение параметра по RFC 5987: без кодирования остаются только attr-char
+func encodeRFC5987(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || '0' <= ch && ch <= '9' || strings.IndexByte("!#$&+-.^_`|~", ch) >= 0 {
+			b.WriteByte(ch)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", ch)
+	}
+	return b.String()
+}
+
+// respondStorageError отправляет ответ, соответствующий ошибке чтения из хранилища документов
+func respondStorageError(c *gin.Context, key string, err error) {
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Файл не найден в хранилище"})
+		return
+	}
+	log.Printf("Ошибка чтения %s из хранилища: %v", key, err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка чтения файла"})
+}
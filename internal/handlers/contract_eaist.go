@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"statements/internal/contracts"
+
+	"github.com/gin-gonic/gin"
+)
+
+// eaistStatusRequest описывает тело запроса на смену статуса ЕАИСТ контракта
+type eaistStatusRequest struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"` // Обязательна для аннулирования и возобновления завершённого контракта
+}
+
+// HandleContractEaistStatus возвращает текущий статус ЕАИСТ контракта, допустимые переходы и историю статусов
+func HandleContractEaistStatus(c *gin.Context, db *sql.DB) {
+	ctx := c.Request.Context()
+	ct, err := contracts.Get(ctx, db, c.Param("id"))
+	if err != nil {
+		respondContractError(c, err)
+		return
+	}
+	history, err := contracts.EaistHistory(ctx, db, ct.ID)
+	if err != nil {
+		respondContractError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":                ct.EaistStatus,
+		"eaist_registry_number": ct.EaistRegistryNumber,
+		"eaist_link":            ct.EaistLink,
+		"allowed":               contracts.AllowedEaistTransitions(ct.EaistStatus),
+		"history":               history,
+	})
+}
+
+// HandleContractEaistStatusChange переводит контракт в новый статус ЕАИСТ
+func HandleContractEaistStatusChange(c *gin.Context, db *sql.DB) {
+	ctx := c.Request.Context()
+	var req eaistStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверные данные запроса"})
+		return
+	}
+	if !contracts.IsEaistStatus(req.Status) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Недопустимый статус ЕАИСТ", "allowed": contracts.EaistStatuses})
+		return
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		respondContractError(c, fmt.Errorf("ошибка начала транзакции: %w", err))
+		return
+	}
+	defer tx.Rollback()
+
+	user := currentUser(c)
+	ct, err := contracts.ChangeEaistStatus(ctx, tx, c.Param("id"), req.Status, req.Reason, user)
+	if err != nil {
+		respondContractError(c, err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondContractError(c, fmt.Errorf("ошибка фиксации статуса ЕАИСТ: %w", err))
+		return
+	}
+
+	log.Printf("Статус ЕАИСТ контракта %s изменён на %q (%s)", ct.ID, ct.EaistStatus, user)
+	c.JSON(http.StatusOK, ct)
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"statements/internal/contracts"
+	"statements/internal/matching"
+	"statements/internal/models"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// matchReviewRequest описывает тело запроса на разбор записи очереди сопоставления
+type matchReviewRequest struct {
+	ContractID string `json:"contract_id"` // Контракт, с которым связывается транзакция
+	Comment    string `json:"comment"`
+}
+
+// HandleContractPayments возвращает банковские транзакции, связанные с контрактом
+func HandleContractPayments(c *gin.Context, db *sql.DB) {
+	ctx := c.Request.Context()
+	id := c.Param("id")
+	if _, err := contracts.Get(ctx, db, id); err != nil {
+		respondContractError(c, err)
+		return
+	}
+
+	payments, err := matching.ContractPayments(ctx, db, id)
+	if err != nil {
+		respondContractError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, payments)
+}
+
+// HandleContractMatchRun запускает сопоставление с контрактами транзакций, которые ещё не сопоставлялись
+func HandleContractMatchRun(c *gin.Context, db *sql.DB) {
+	stats, err := matching.MatchPending(c.Request.Context(), db)
+	if err != nil {
+		log.Printf("Ошибка сопоставления транзакций с контрактами: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка сопоставления транзакций с контрактами"})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// rematchContracts возвращает на сопоставление транзакции, для которых раньше не нашлось контракта,
+// если они ссылаются на созданный или изменённый контракт. Ошибка не мешает сохранению контракта и только логируется
+func rematchContracts(ctx context.Context, db *sql.DB, changed ...models.Contract) {
+	stats, err := matching.RematchNotFound(ctx, db, changed)
+	if err != nil {
+		log.Printf("Ошибка повторного сопоставления транзакций с контрактами: %v", err)
+		return
+	}
+	if stats.Linked > 0 {
+		log.Printf("После сохранения контрактов связано с ними транзакций: %d", stats.Linked)
+	}
+}
+
+// HandleMatchReviewsList возвращает очередь ручного сопоставления; status=all включает разобранные записи
+func HandleMatchReviewsList(c *gin.Context, db *sql.DB) {
+	p := parsePagination(c)
+	onlyPending := c.Query("status") != "all"
+
+	reviews, total, err := matching.Reviews(c.Request.Context(), db, onlyPending, p.PageSize, p.Offset())
+	if err != nil {
+		log.Printf("Ошибка получения очереди сопоставления: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения очереди сопоставления"})
+		return
+	}
+
+	c.JSON(http.StatusOK, paginatedResponse(reviews, total, p))
+}
+
+// HandleMatchReviewLink связывает транзакцию из очереди с выбранным контрактом
+func HandleMatchReviewLink(c *gin.Context, db *sql.DB) {
+	ctx := c.Request.Context()
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный идентификатор записи"})
+		return
+	}
+
+	var req matchReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.ContractID) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Укажите контракт"})
+		return
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		respondMatchReviewError(c, fmt.Errorf("ошибка начала транзакции: %w", err))
+		return
+	}
+	defer tx.Rollback()
+
+	user := currentUser(c)
+	if err := matching.ResolveReview(ctx, tx, id, strings.TrimSpace(req.ContractID), user, strings.TrimSpace(req.Comment)); err != nil {
+		respondMatchReviewError(c, err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondMatchReviewError(c, fmt.Errorf("ошибка фиксации сопоставления: %w", err))
+		return
+	}
+
+	log.Printf("Запись очереди сопоставления %d связана с контрактом %s (%s)", id, req.ContractID, user)
+	c.Status(http.StatusNoContent)
+}
+
+// HandleMatchReviewDismiss закрывает запись очереди без связи с контрактом
+func HandleMatchReviewDismiss(c *gin.Context, db *sql.DB) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный идентификатор записи"})
+		return
+	}
+
+	var req matchReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверные данные запроса"})
+		return
+	}
+
+	if err := matching.DismissReview(c.Request.Context(), db, id, currentUser(c), strings.TrimSpace(req.Comment)); err != nil {
+		respondMatchReviewError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// respondMatchReviewError отправляет ответ, соответствующий ошибке разбора очереди сопоставления
+func respondMatchReviewError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, matching.ErrReviewNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Запись не найдена или уже разобрана"})
+	case errors.Is(err, contracts.ErrNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Контракт не найден"})
+	default:
+		log.Printf("Ошибка разбора очереди сопоставления: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка разбора очереди сопоставления"})
+	}
+}
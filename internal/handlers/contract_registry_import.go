@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"statements/internal/config"
+	"statements/internal/registry"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// xlsxContentType — MIME-тип книги Excel, определённый по содержимому файла
+const xlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// registryImportError описывает файл реестра, который не удалось прочитать
+type registryImportError struct {
+	File  string `json:"file"`
+	Error string `json:"error"`
+}
+
+// HandleContractRegistryImport загружает реестры контрактов ЕАИСТ в форматах xlsx и CSV.
+// Поля формы: files — файлы реестров, dry_run — только проверить строки без сохранения,
+// contract_type — тип контракта для строк, где он не указан. В ответе — отчёт по каждой строке
+func HandleContractRegistryImport(c *gin.Context, cfg *config.Config, db *sql.DB) {
+	limits := cfg.FileUpload.LimitsFor("registry")
+	form, ok := parseUploadForm(c, limits)
+	if !ok {
+		return
+	}
+
+	files := form.File["files"]
+	if len(files) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Не выбрано ни одного файла"})
+		return
+	}
+	if errs := checkUploadedFiles(form, limits, "files"); len(errs) > 0 {
+		respondUploadErrors(c, errs)
+		return
+	}
+
+	opts, ok := parseImportOptions(c)
+	if !ok {
+		return
+	}
+
+	reports := make([]registry.Report, 0, len(files))
+	failed := make([]registryImportError, 0)
+	for _, fileHeader := range files {
+		records, err := parseRegistryFile(fileHeader)
+		if err != nil {
+			log.Printf("Ошибка разбора реестра %s: %v", fileHeader.Filename, err)
+			failed = append(failed, registryImportError{File: fileHeader.Filename, Error: err.Error()})
+			continue
+		}
+
+		report, err := registry.Import(c.Request.Context(), db, fileHeader.Filename, records, opts)
+		if err != nil {
+			log.Printf("Ошибка загрузки реестра %s: %v", fileHeader.Filename, err)
+			failed = append(failed, registryImportError{File: fileHeader.Filename, Error: "Ошибка сохранения реестра"})
+			continue
+		}
+		log.Printf("Загружен реестр %s: строк %d, создано %d, обновлено %d, без изменений %d, с ошибками %d (проверка: %t)",
+			fileHeader.Filename, report.Total, report.Created, report.Updated, report.Unchanged, report.Failed, report.DryRun)
+		reports = append(reports, report)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reports": reports, "errors": failed})
+}
+
+// HandleContractEISImport загружает карточки контрактов 44-ФЗ/223-ФЗ в формате XML, выгруженные из ЕИС.
+// Поля формы те же, что у HandleContractRegistryImport. Этапы исполнения попадают в график платежей,
+// а расхождения с сохранёнными контрактами — в отчёт
+func HandleContractEISImport(c *gin.Context, cfg *config.Config, db *sql.DB) {
+	limits := cfg.FileUpload.LimitsFor("eis")
+	form, ok := parseUploadForm(c, limits)
+	if !ok {
+		return
+	}
+
+	files := form.File["files"]
+	if len(files) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Не выбрано ни одного файла"})
+		return
+	}
+	if errs := checkUploadedFiles(form, limits, "files"); len(errs) > 0 {
+		respondUploadErrors(c, errs)
+		return
+	}
+
+	opts, ok := parseImportOptions(c)
+	if !ok {
+		return
+	}
+
+	reports := make([]registry.Report, 0, len(files))
+	failed := make([]registryImportError, 0)
+	for _, fileHeader := range files {
+		items, err := parseEISFile(fileHeader)
+		if err != nil {
+			log.Printf("Ошибка разбора файла ЕИС %s: %v", fileHeader.Filename, err)
+			failed = append(failed, registryImportError{File: fileHeader.Filename, Error: err.Error()})
+			continue
+		}
+
+		report, err := registry.ImportEIS(c.Request.Context(), db, fileHeader.Filename, items, opts)
+		if err != nil {
+			log.Printf("Ошибка загрузки контрактов ЕИС из %s: %v", fileHeader.Filename, err)
+			failed = append(failed, registryImportError{File: fileHeader.Filename, Error: "Ошибка сохранения контрактов"})
+			continue
+		}
+		log.Printf("Загружены контракты ЕИС из %s: всего %d, создано %d, обновлено %d, без изменений %d, с ошибками %d (проверка: %t)",
+			fileHeader.Filename, report.Total, report.Created, report.Updated, report.Unchanged, report.Failed, report.DryRun)
+		reports = append(reports, report)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reports": reports, "errors": failed})
+}
+
+// parseImportOptions читает из формы параметры загрузки контрактов: dry_run и contract_type
+func parseImportOptions(c *gin.Context) (registry.Options, bool) {
+	opts := registry.Options{
+		ContractType: strings.TrimSpace(c.PostForm("contract_type")),
+		User:         currentUser(c),
+	}
+	if value := c.PostForm("dry_run"); value != "" {
+		dryRun, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректное значение dry_run"})
+			return opts, false
+		}
+		opts.DryRun = dryRun
+	}
+	return opts, true
+}
+
+// parseEISFile читает карточки контрактов из загруженного файла XML
+func parseEISFile(fileHeader *multipart.FileHeader) ([]registry.EISContract, error) {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return registry.ParseEIS(file)
+}
+
+// parseRegistryFile читает реестр из книги Excel или CSV. Тип файла уже определён checkUploadedFiles
+func parseRegistryFile(fileHeader *multipart.FileHeader) ([]registry.Record, error) {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if fileHeader.Header.Get("Content-Type") == xlsxContentType {
+		return registry.ParseXLSX(file)
+	}
+	return registry.ParseCSV(file)
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"statements/internal/contracts"
+	"statements/internal/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// scheduleRequest описывает тело запроса на замену графика платежей контракта
+type scheduleRequest struct {
+	Stages []struct {
+		Title       string  `json:"title"`
+		PlannedDate string  `json:"planned_date"`
+		Amount      float64 `json:"amount"`
+	} `json:"stages"`
+}
+
+// HandleContractSchedule возвращает график платежей контракта в сравнении с фактической оплатой
+func HandleContractSchedule(c *gin.Context, db *sql.DB) {
+	schedule, err := contracts.Schedule(c.Request.Context(), db, c.Param("id"))
+	if err != nil {
+		respondContractError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+// HandleContractScheduleSave заменяет график платежей контракта. Пустой список этапов удаляет график
+func HandleContractScheduleSave(c *gin.Context, db *sql.DB) {
+	ctx := c.Request.Context()
+	var req scheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверные данные запроса"})
+		return
+	}
+	stages := make([]models.PaymentStage, 0, len(req.Stages))
+	for _, s := range req.Stages {
+		stages = append(stages, models.PaymentStage{Title: s.Title, PlannedDate: s.PlannedDate, Amount: s.Amount})
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		respondContractError(c, fmt.Errorf("ошибка начала транзакции: %w", err))
+		return
+	}
+	defer tx.Rollback()
+
+	user := currentUser(c)
+	schedule, err := contracts.SaveSchedule(ctx, tx, c.Param("id"), stages, user)
+	if err != nil {
+		respondContractError(c, err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondContractError(c, fmt.Errorf("ошибка фиксации графика платежей: %w", err))
+		return
+	}
+
+	log.Printf("График платежей контракта %s сохранён: %d этапов (%s)", schedule.ContractID, len(schedule.Stages), user)
+	c.JSON(http.StatusOK, schedule)
+}
+
+// HandlePaymentForecast возвращает прогноз выплат по графикам платежей действующих контрактов.
+// Параметры: period — week или month (по умолчанию), from и to — период прогноза (по умолчанию три месяца с сегодняшнего дня)
+func HandlePaymentForecast(c *gin.Context, db *sql.DB) {
+	filter, ok := parseForecastFilter(c)
+	if !ok {
+		return
+	}
+
+	forecast, err := contracts.Forecast(c.Request.Context(), db, filter)
+	if err != nil {
+		respondContractError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, forecast)
+}
+
+// parseForecastFilter читает параметры прогноза выплат из запроса.
+// При ошибке отправляет ответ 400 и возвращает false
+func parseForecastFilter(c *gin.Context) (contracts.ForecastFilter, bool) {
+	filter := contracts.ForecastFilter{Period: c.DefaultQuery("period", contracts.ForecastMonth)}
+
+	today := time.Now()
+	filter.From = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	if v := c.Query("from"); v != "" {
+		from, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Даты периода должны быть в формате ГГГГ-ММ-ДД"})
+			return filter, false
+		}
+		filter.From = from
+	}
+	filter.To = filter.From.AddDate(0, 3, -1)
+	if v := c.Query("to"); v != "" {
+		to, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Даты периода должны быть в формате ГГГГ-ММ-ДД"})
+			return filter, false
+		}
+		filter.To = to
+	}
+	if err := filter.Validate(); err != nil {
+		respondContractError(c, err)
+		return filter, false
+	}
+	return filter, true
+}
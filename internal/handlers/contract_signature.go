@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"statements/internal/contracts"
+	"statements/internal/models"
+	"statements/internal/signature"
+	"statements/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HandleContractSignature возвращает результат последней проверки ЭЦП контракта
+func HandleContractSignature(c *gin.Context, db *sql.DB) {
+	check, err := contracts.LatestSignatureCheck(c.Request.Context(), db, c.Param("id"))
+	if err != nil {
+		respondContractError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, check)
+}
+
+// HandleContractSignatureVerify заново проверяет ЭЦП контракта по текущим версиям файлов и сохраняет результат
+func HandleContractSignatureVerify(c *gin.Context, store storage.BlobStore, db *sql.DB) {
+	check, err := checkContractSignature(c.Request.Context(), store, db, c.Param("id"), currentUser(c))
+	if err != nil {
+		respondContractError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, check)
+}
+
+// checkContractSignature проверяет подпись контракта и сохраняет результат
+func checkContractSignature(ctx context.Context, store storage.BlobStore, db *sql.DB, id, checkedBy string) (models.SignatureCheck, error) {
+	check, err := contracts.VerifySignature(ctx, db, store, id)
+	if err != nil {
+		return check, err
+	}
+	check.CheckedBy = checkedBy
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return check, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := contracts.SaveSignatureCheck(ctx, tx, &check); err != nil {
+		return check, err
+	}
+	if err := tx.Commit(); err != nil {
+		return check, fmt.Errorf("ошибка фиксации проверки подписи контракта %s: %w", id, err)
+	}
+	return check, nil
+}
+
+// signatureWarning возвращает предупреждение для пользователя, если подпись не прошла проверку
+func signatureWarning(check models.SignatureCheck) string {
+	switch signature.Status(check.Status) {
+	case signature.StatusInvalid, signature.StatusExpired, signature.StatusMalformed:
+		return "Внимание: ЭЦП не прошла проверку. " + check.Message + "."
+	}
+	return ""
+}
@@ -1,148 +1,210 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"log"
-	"mime/multipart"
 	"net/http"
-	"os"
-	"path/filepath"
 	"statements/internal/config"
+	"statements/internal/contracts"
+	"statements/internal/counterparties"
+	"statements/internal/models"
+	"statements/internal/storage"
+	"strconv"
+	"strings"
 )
 
-// HandleContractSubmission обрабатывает форму добавления контракта и загрузку файлов
-func HandleContractSubmission(c *gin.Context, cfg *config.Config, db *sql.DB) {
-	// Парсинг формы
-	counterpartyID := c.PostForm("counterparty_id")
-	contractNumber := c.PostForm("contract_number")
-	contractDate := c.PostForm("contract_date")
-	executionPeriod := c.PostForm("execution_period")
-	amount := c.PostForm("amount")
-	contractType := c.PostForm("contract_type")
-	subject := c.PostForm("subject")
-
-	fmt.Printf("Контракт: %s, Дата: %s, Срок исполнения: %s, Сумма: %s, Тип: %s, Предмет: %s, Контрагент: %s\n",
-		contractNumber, contractDate, executionPeriod, amount, contractType, subject, counterpartyID)
-
-	// Функция для сохранения файлов
-	saveFile := func(fileHeader *multipart.FileHeader, directory string) (string, error) {
-		file, err := fileHeader.Open()
-		if err != nil {
-			return "", err
-		}
-		defer file.Close()
+// contractFileFields — поля формы контракта с файлами
+var contractFileFields = []string{"contract_file", "memo_file", "ecp_file", "technical_task_file", "additional_files[]"}
 
-		// Путь для сохранения файла
-		filePath := filepath.Join(directory, fileHeader.Filename)
-		outFile, err := os.Create(filePath)
-		if err != nil {
-			return "", err
-		}
-		defer outFile.Close()
+// contractFileTypes сопоставляет поля формы контракта видам документов
+var contractFileTypes = map[string]string{
+	"contract_file":       "contract",
+	"memo_file":           "memo",
+	"ecp_file":            "ecp",
+	"technical_task_file": "technical_task",
+	"additional_files[]":  "additional",
+}
 
-		// Запись файла
-		_, err = outFile.ReadFrom(file)
-		return filePath, err
-	}
+// HandleContractSubmission обрабатывает форму добавления контракта и загрузку файлов.
+// Поля формы проверяются до записи файлов; ошибки возвращаются в JSON по каждому полю
+func HandleContractSubmission(c *gin.Context, cfg *config.Config, store storage.BlobStore, db *sql.DB) {
+	ctx := c.Request.Context()
 
-	// Создание директории для файлов контракта
-	baseDir := filepath.Join(cfg.FileUpload.UploadDir, contractNumber)
-	err := os.MkdirAll(baseDir, os.ModePerm)
-	if err != nil {
-		log.Printf("Ошибка создания директории: %v", err)
-		c.String(http.StatusInternalServerError, "Ошибка создания директории для файлов")
+	limits := cfg.FileUpload.LimitsFor("contracts")
+	form, ok := parseUploadForm(c, limits)
+	if !ok {
 		return
 	}
 
-	// Сохранение путей к файлам
-	var contractFilePath, memoFilePath, ecpFilePath, technicalTaskFilePath string
-	var additionalFilePaths []string
+	// Разбор и проверка полей формы
+	verr := &contracts.ValidationError{}
+	ct := parseContractForm(c, verr)
 
-	// Загрузка обязательных файлов
-	fileHeader, err := c.FormFile("contract_file")
-	if err == nil {
-		contractFilePath, err = saveFile(fileHeader, baseDir)
-		if err != nil {
-			log.Printf("Ошибка сохранения файла контракта: %v", err)
-			c.String(http.StatusInternalServerError, "Ошибка сохранения файла контракта")
+	if _, err := c.FormFile("contract_file"); err != nil {
+		verr.Add("contract_file", "Прикрепите файл контракта")
+	}
+
+	// Размер, количество и тип файлов проверяются по содержимому до сохранения
+	fileErrors := checkUploadedFiles(form, limits, contractFileFields...)
+	for _, fe := range fileErrors {
+		field := fe.Field
+		if field == "" {
+			field = "additional_files[]"
+		}
+		verr.Add(field, fe.Error)
+	}
+
+	if err := contracts.ValidateReferences(ctx, db, ct); err != nil {
+		var fieldErrors *contracts.ValidationError
+		if !errors.As(err, &fieldErrors) {
+			respondContractError(c, err)
 			return
 		}
+		for field, message := range fieldErrors.Fields {
+			verr.Add(field, message)
+		}
+	}
+	if len(fileErrors) > 0 {
+		c.JSON(uploadErrorsStatus(fileErrors), gin.H{"error": "Файлы не прошли проверку", "fields": verr.Fields, "files": fileErrors})
+		return
+	}
+	if err := verr.Err(); err != nil {
+		respondContractError(c, err)
+		return
 	}
 
-	// Загрузка необязательных файлов
-	files := map[string]*string{
-		"memo_file":           &memoFilePath,
-		"ecp_file":            &ecpFilePath,
-		"technical_task_file": &technicalTaskFilePath,
+	log.Printf("Контракт: %s, Дата: %s, Срок исполнения: %s, Сумма: %.2f, Тип: %s, Контрагент: %d",
+		ct.ContractNumber, ct.ContractDate, ct.ExecutionPeriod, ct.Amount, ct.ContractType, ct.CounterpartyID)
+
+	// Каждый контракт получает собственный префикс ключей в хранилище по публичному идентификатору
+	var err error
+	ct.ID, err = contracts.NewID()
+	if err != nil {
+		respondContractError(c, err)
+		return
+	}
+	prefix := "contracts/" + ct.ID
+
+	// Файлы, сохранённые до ошибки, удаляются вместе с несостоявшейся записью в базе
+	upload := storage.NewUpload(store)
+	defer func() {
+		if err := upload.Rollback(context.Background()); err != nil {
+			log.Printf("Ошибка удаления файлов несохранённого контракта %s: %v", ct.ID, err)
+		}
+	}()
+
+	// Файлы хранятся под именами, сгенерированными сервером; исходные имена сохраняются в stored_files.
+	// Поля формы сопоставляются видам документов в порядке contractFileFields
+	uploadedBy := currentUser(c)
+	type contractDocument struct {
+		documentType string
+		file         models.StoredFile
 	}
-	for key, path := range files {
-		fileHeader, err := c.FormFile(key)
-		if err == nil {
-			*path, err = saveFile(fileHeader, baseDir)
+	var documents []contractDocument
+	for _, field := range contractFileFields {
+		for _, fileHeader := range form.File[field] {
+			f, err := upload.PutFile(ctx, prefix, fileHeader, uploadedBy)
 			if err != nil {
-				log.Printf("Ошибка сохранения файла %s: %v", key, err)
-				c.String(http.StatusInternalServerError, fmt.Sprintf("Ошибка сохранения файла %s", key))
+				log.Printf("Ошибка сохранения файла %s: %v", field, err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Ошибка сохранения файла %s", fileHeader.Filename)})
 				return
 			}
+			documents = append(documents, contractDocument{documentType: contractFileTypes[field], file: f})
 		}
 	}
 
-	// Обработка дополнительных файлов
-	form, _ := c.MultipartForm()
-	additionalFiles := form.File["additional_files[]"]
-	for _, fileHeader := range additionalFiles {
-		filePath, err := saveFile(fileHeader, baseDir)
-		if err != nil {
-			log.Printf("Ошибка при сохранении дополнительного файла: %v", err)
-			c.String(http.StatusInternalServerError, "Ошибка сохранения дополнительных файлов")
+	// Файлы остаются в хранилище, только если запись о контракте и сведения о документах сохранились
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		respondContractError(c, fmt.Errorf("ошибка начала транзакции: %w", err))
+		return
+	}
+	defer tx.Rollback()
+
+	id, err := contracts.Create(ctx, tx, ct, uploadedBy)
+	if err != nil {
+		respondContractError(c, err)
+		return
+	}
+	if err := upload.Record(ctx, tx); err != nil {
+		respondContractError(c, err)
+		return
+	}
+	for _, doc := range documents {
+		if _, err := contracts.AddDocument(ctx, tx, id, doc.documentType, doc.file); err != nil {
+			respondContractError(c, err)
 			return
 		}
-		additionalFilePaths = append(additionalFilePaths, filePath)
 	}
+	if err := tx.Commit(); err != nil {
+		respondContractError(c, fmt.Errorf("ошибка фиксации контракта %s: %w", ct.ID, err))
+		return
+	}
+	upload.Commit()
+	rematchContracts(ctx, db, ct)
 
-	// Сохранение данных в базу данных
-	query := `INSERT INTO contracts 
-                (counterparty_id, contract_number, contract_date, execution_period, amount, contract_type, subject, 
-                contract_file_path, memo_file_path, ecp_file_path, technical_task_file_path, additional_files_paths) 
-              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
-	_, err = db.Exec(query, counterpartyID, contractNumber, contractDate, executionPeriod, amount, contractType, subject,
-		contractFilePath, memoFilePath, ecpFilePath, technicalTaskFilePath, additionalFilePaths)
+	// Предупреждаем о контрагенте, прекратившем деятельность по данным ЕГРЮЛ/ЕГРИП
+	response := gin.H{"id": id, "message": "Контракт успешно добавлен!"}
+	var warnings []string
+	cp, err := counterparties.Get(ctx, db, ct.CounterpartyID)
 	if err != nil {
-		log.Printf("Ошибка сохранения данных контракта в базу: %v", err)
-		c.String(http.StatusInternalServerError, "Ошибка сохранения данных контракта")
-		return
+		log.Printf("Ошибка проверки статуса контрагента %d: %v", ct.CounterpartyID, err)
+	} else if cp.Liquidated() {
+		log.Printf("Контракт %s заключён с ликвидированным контрагентом %d", ct.ContractNumber, ct.CounterpartyID)
+		warnings = append(warnings, fmt.Sprintf("Внимание: контрагент %s прекратил деятельность %s.", cp.Name, cp.LiquidationDate))
 	}
 
-	// Возвращаем результат пользователю
-	c.String(http.StatusOK, "Контракт успешно добавлен!")
+	// Приложенная ЭЦП проверяется сразу; контракт сохраняется и при неудачной проверке
+	if len(form.File["ecp_file"]) > 0 {
+		check, err := checkContractSignature(ctx, store, db, id, uploadedBy)
+		if err != nil {
+			log.Printf("Ошибка проверки ЭЦП контракта %s: %v", id, err)
+		} else {
+			response["signature"] = check
+			if warning := signatureWarning(check); warning != "" {
+				log.Printf("ЭЦП контракта %s не прошла проверку: %s", id, check.Message)
+				warnings = append(warnings, warning)
+			}
+		}
+	}
+	if len(warnings) > 0 {
+		response["warning"] = strings.Join(warnings, " ")
+	}
+
+	c.JSON(http.StatusCreated, response)
 }
 
-// HandleCounterpartiesList возвращает список всех контрагентов
-func HandleCounterpartiesList(c *gin.Context, db *sql.DB) {
-	query := "SELECT id, name FROM counterparties"
-	rows, err := db.Query(query)
-	if err != nil {
-		log.Printf("Ошибка получения списка контрагентов: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения данных контрагентов"})
-		return
+// parseContractForm читает поля контракта из формы. Ошибки преобразования чисел записываются в verr
+func parseContractForm(c *gin.Context, verr *contracts.ValidationError) models.Contract {
+	ct := models.Contract{
+		ContractNumber:  strings.TrimSpace(c.PostForm("contract_number")),
+		ContractDate:    strings.TrimSpace(c.PostForm("contract_date")),
+		ExecutionPeriod: strings.TrimSpace(c.PostForm("execution_period")),
+		ContractType:    strings.TrimSpace(c.PostForm("contract_type")),
+		Subject:         strings.TrimSpace(c.PostForm("subject")),
 	}
-	defer rows.Close()
 
-	var counterparties []map[string]interface{}
-	for rows.Next() {
-		var id int
-		var name string
-		if err := rows.Scan(&id, &name); err != nil {
-			log.Printf("Ошибка чтения строки: %v", err)
-			continue
+	if value := strings.TrimSpace(c.PostForm("counterparty_id")); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil || id <= 0 {
+			verr.Add("counterparty_id", "Некорректный контрагент")
 		}
-		counterparties = append(counterparties, map[string]interface{}{
-			"id":   id,
-			"name": name,
-		})
+		ct.CounterpartyID = id
+	}
+
+	amount, ok := parseFormAmount(c.PostForm("amount"))
+	switch {
+	case !ok:
+		verr.Add("amount", "Сумма должна быть числом")
+	case amount == nil:
+		verr.Add("amount", "Укажите сумму договора")
+	default:
+		ct.Amount = *amount
 	}
 
-	c.JSON(http.StatusOK, counterparties)
+	return ct
 }
@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"statements/internal/contracts"
+	"statements/internal/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// contractRequest описывает тело запроса на создание или изменение контракта
+type contractRequest struct {
+	CounterpartyID      int     `json:"counterparty_id"`
+	ContractNumber      string  `json:"contract_number"`
+	ContractDate        string  `json:"contract_date"`
+	ExecutionPeriod     string  `json:"execution_period"`
+	Amount              float64 `json:"amount"`
+	EaistRegistryNumber string  `json:"eaist_registry_number"`
+	PaymentDays         *int    `json:"payment_days"`
+	ValidityPeriod      string  `json:"validity_period"`
+	Subject             string  `json:"subject"`
+	ContractType        string  `json:"contract_type"`
+	WorkType            string  `json:"work_type"`
+	ConclusionBasis     string  `json:"conclusion_basis"`
+	ProcurementType     string  `json:"procurement_type"`
+	Initiator           string  `json:"initiator"`
+	EaistStatus         string  `json:"eaist_status"`
+	EaistLink           string  `json:"eaist_link"`
+}
+
+// toModel преобразует запрос в модель контракта, обрезая пробелы
+func (r contractRequest) toModel() models.Contract {
+	return models.Contract{
+		CounterpartyID:      r.CounterpartyID,
+		ContractNumber:      strings.TrimSpace(r.ContractNumber),
+		ContractDate:        strings.TrimSpace(r.ContractDate),
+		ExecutionPeriod:     strings.TrimSpace(r.ExecutionPeriod),
+		Amount:              r.Amount,
+		EaistRegistryNumber: strings.TrimSpace(r.EaistRegistryNumber),
+		PaymentDays:         r.PaymentDays,
+		ValidityPeriod:      strings.TrimSpace(r.ValidityPeriod),
+		Subject:             strings.TrimSpace(r.Subject),
+		ContractType:        strings.TrimSpace(r.ContractType),
+		WorkType:            strings.TrimSpace(r.WorkType),
+		ConclusionBasis:     strings.TrimSpace(r.ConclusionBasis),
+		ProcurementType:     strings.TrimSpace(r.ProcurementType),
+		Initiator:           strings.TrimSpace(r.Initiator),
+		EaistStatus:         strings.TrimSpace(r.EaistStatus),
+		EaistLink:           strings.TrimSpace(r.EaistLink),
+	}
+}
+
+// HandleContractsList возвращает страницу контрактов с фильтрами по контрагенту, периоду заключения, типу,
+// статусу ЕАИСТ (eaist_status=none — без статуса) и статусу исполнения (execution). Сортировка задаётся параметром sort (например, sort=-amount — по убыванию суммы).
+// Параметр as_of показывает условия и исполнение контрактов на указанную дату
+func HandleContractsList(c *gin.Context, db *sql.DB) {
+	p := parsePagination(c)
+	filter, ok := parseContractFilter(c)
+	if !ok {
+		return
+	}
+	filter.Limit = p.PageSize
+	filter.Offset = p.Offset()
+
+	items, total, err := contracts.List(c.Request.Context(), db, filter)
+	if err != nil {
+		respondContractError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, paginatedResponse(items, total, p))
+}
+
+// parseContractFilter читает параметры отбора и сортировки контрактов из запроса.
+// При ошибке отправляет ответ 400 и возвращает false
+func parseContractFilter(c *gin.Context) (contracts.ListFilter, bool) {
+	filter := contracts.ListFilter{
+		DateFrom:     c.Query("date_from"),
+		DateTo:       c.Query("date_to"),
+		ContractType: c.Query("contract_type"),
+		EaistStatus:  c.Query("eaist_status"),
+		Query:        c.Query("q"),
+		Execution:    c.Query("execution"),
+	}
+
+	if v := c.Query("counterparty_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil || id <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный идентификатор контрагента"})
+			return filter, false
+		}
+		filter.CounterpartyID = id
+	}
+	for _, date := range []string{filter.DateFrom, filter.DateTo} {
+		if _, err := time.Parse(time.DateOnly, date); date != "" && err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Даты периода должны быть в формате ГГГГ-ММ-ДД"})
+			return filter, false
+		}
+	}
+	if filter.EaistStatus != "" && filter.EaistStatus != contracts.EaistStatusNone && !contracts.IsEaistStatus(filter.EaistStatus) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Недопустимый статус ЕАИСТ", "allowed": append(append([]string{}, contracts.EaistStatuses...), contracts.EaistStatusNone)})
+		return filter, false
+	}
+	if filter.Execution != "" && !contracts.IsExecutionStatus(filter.Execution) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Недопустимый статус исполнения", "allowed": contracts.ExecutionStatuses})
+		return filter, false
+	}
+	asOf, ok := parseAsOf(c)
+	if !ok {
+		return filter, false
+	}
+	filter.AsOf = asOf
+	// По умолчанию сначала новые контракты
+	sort := c.DefaultQuery("sort", "-contract_date")
+	filter.Sort = strings.TrimPrefix(sort, "-")
+	filter.Desc = strings.HasPrefix(sort, "-")
+	return filter, true
+}
+
+// parseAsOf читает дату состояния контракта из параметра as_of; без параметра возвращает нулевую дату (текущее состояние).
+// При ошибке отправляет ответ 400 и возвращает false
+func parseAsOf(c *gin.Context) (time.Time, bool) {
+	value := c.Query("as_of")
+	if value == "" {
+		return time.Time{}, true
+	}
+	asOf, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Дата состояния должна быть в формате ГГГГ-ММ-ДД"})
+		return asOf, false
+	}
+	return asOf, true
+}
+
+// HandleContractGet возвращает контракт по публичному идентификатору.
+// Параметр as_of возвращает состояние контракта на указанную дату с учётом дополнительных соглашений
+func HandleContractGet(c *gin.Context, db *sql.DB) {
+	asOf, ok := parseAsOf(c)
+	if !ok {
+		return
+	}
+	ct, err := contracts.GetAsOf(c.Request.Context(), db, c.Param("id"), asOf)
+	if err != nil {
+		respondContractError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ct)
+}
+
+// HandleContractCreate создаёт контракт без файлов
+func HandleContractCreate(c *gin.Context, db *sql.DB) {
+	var req contractRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверные данные запроса"})
+		return
+	}
+
+	ct := req.toModel()
+	if err := contracts.ValidateReferences(c.Request.Context(), db, ct); err != nil {
+		respondContractError(c, err)
+		return
+	}
+
+	id, err := contracts.Create(c.Request.Context(), db, ct, currentUser(c))
+	if err != nil {
+		respondContractError(c, err)
+		return
+	}
+
+	ct, err = contracts.Get(c.Request.Context(), db, id)
+	if err != nil {
+		respondContractError(c, err)
+		return
+	}
+	rematchContracts(c.Request.Context(), db, ct)
+
+	c.JSON(http.StatusCreated, ct)
+}
+
+// HandleContractUpdate изменяет реквизиты и первоначальные условия контракта.
+// Изменения условий после заключения оформляются дополнительными соглашениями
+func HandleContractUpdate(c *gin.Context, db *sql.DB) {
+	var req contractRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверные данные запроса"})
+		return
+	}
+
+	ct := req.toModel()
+	ct.ID = c.Param("id")
+	if !contracts.IsID(ct.ID) {
+		respondContractError(c, contracts.ErrNotFound)
+		return
+	}
+	if err := contracts.ValidateReferences(c.Request.Context(), db, ct); err != nil {
+		respondContractError(c, err)
+		return
+	}
+
+	if err := contracts.Update(c.Request.Context(), db, ct); err != nil {
+		respondContractError(c, err)
+		return
+	}
+
+	ct, err := contracts.Get(c.Request.Context(), db, ct.ID)
+	if err != nil {
+		respondContractError(c, err)
+		return
+	}
+	rematchContracts(c.Request.Context(), db, ct)
+
+	c.JSON(http.StatusOK, ct)
+}
+
+// HandleContractDelete помечает контракт удалённым
+func HandleContractDelete(c *gin.Context, db *sql.DB) {
+	if err := contracts.Delete(c.Request.Context(), db, c.Param("id"), currentUser(c)); err != nil {
+		respondContractError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// respondContractError отправляет ответ, соответствующий ошибке работы с контрактом
+func respondContractError(c *gin.Context, err error) {
+	var verr *contracts.ValidationError
+	var terr *contracts.EaistTransitionError
+	switch {
+	case errors.As(err, &verr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Проверьте правильность заполнения полей", "fields": verr.Fields})
+	case errors.Is(err, contracts.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Контракт не найден"})
+	case errors.Is(err, contracts.ErrDocumentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Документ контракта не найден"})
+	case errors.Is(err, contracts.ErrNoSignature):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Для проверки ЭЦП у контракта должны быть файл контракта и файл подписи"})
+	case errors.Is(err, contracts.ErrSignatureNotChecked):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Подпись контракта не проверялась"})
+	case errors.Is(err, contracts.ErrInvalidDocumentType):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Укажите вид документа (не более 100 символов)"})
+	case errors.Is(err, contracts.ErrDuplicate):
+		c.JSON(http.StatusConflict, gin.H{"error": "Контракт с таким номером и датой уже существует"})
+	case errors.Is(err, contracts.ErrCounterpartyNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Контрагент не найден"})
+	case errors.Is(err, contracts.ErrInvalidSort):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Недопустимое поле сортировки"})
+	case errors.Is(err, contracts.ErrInvalidExecutionStatus):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Недопустимый статус исполнения", "allowed": contracts.ExecutionStatuses})
+	case errors.As(err, &terr):
+		c.JSON(http.StatusConflict, gin.H{"error": "Недопустимая смена статуса ЕАИСТ", "status": terr.From, "allowed": terr.Allowed})
+	case errors.Is(err, contracts.ErrEaistReasonRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Для аннулирования и возобновления контракта укажите причину"})
+	case errors.Is(err, contracts.ErrEaistReasonTooLong):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Причина смены статуса не может быть длиннее 2000 символов"})
+	case errors.Is(err, contracts.ErrInvalidForecastPeriod):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Недопустимый период группировки", "allowed": []string{contracts.ForecastWeek, contracts.ForecastMonth}})
+	case errors.Is(err, contracts.ErrInvalidForecastRange):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Конец периода прогноза должен быть не раньше начала, а период — не длиннее двух лет"})
+	default:
+		log.Printf("Ошибка обработки контракта: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка обработки данных контракта"})
+	}
+}
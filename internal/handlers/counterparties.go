@@ -0,0 +1,362 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"statements/internal/counterparties"
+	"statements/internal/models"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// counterpartyRequest описывает тело запроса на создание или изменение контрагента
+type counterpartyRequest struct {
+	Name    string `json:"name"`
+	Inn     string `json:"inn"`
+	Kpp     string `json:"kpp"`
+	Address string `json:"address"`
+}
+
+// toModel преобразует запрос в модель контрагента, обрезая пробелы
+func (r counterpartyRequest) toModel() models.Counterparty {
+	return models.Counterparty{
+		Name:    strings.TrimSpace(r.Name),
+		Inn:     strings.TrimSpace(r.Inn),
+		Kpp:     strings.TrimSpace(r.Kpp),
+		Address: strings.TrimSpace(r.Address),
+	}
+}
+
+// HandleCounterpartiesList возвращает страницу контрагентов с поиском по началу ИНН или части наименования
+func HandleCounterpartiesList(c *gin.Context, db *sql.DB) {
+	p := parsePagination(c)
+	items, total, err := counterparties.List(c.Request.Context(), db, counterparties.ListFilter{
+		Query:  c.Query("q"),
+		Limit:  p.PageSize,
+		Offset: p.Offset(),
+	})
+	if err != nil {
+		log.Printf("Ошибка получения списка контрагентов: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения данных контрагентов"})
+		return
+	}
+
+	c.JSON(http.StatusOK, paginatedResponse(items, total, p))
+}
+
+// HandleCounterpartyGet возвращает контрагента по идентификатору
+func HandleCounterpartyGet(c *gin.Context, db *sql.DB) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный идентификатор контрагента"})
+		return
+	}
+
+	cp, err := counterparties.Get(c.Request.Context(), db, id)
+	if err != nil {
+		respondCounterpartyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, cp)
+}
+
+// HandleCounterpartyOverview возвращает контракты и итоги платежей контрагента
+func HandleCounterpartyOverview(c *gin.Context, db *sql.DB) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный идентификатор контрагента"})
+		return
+	}
+
+	overview, err := counterparties.Overview(c.Request.Context(), db, id)
+	if err != nil {
+		respondCounterpartyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, overview)
+}
+
+// HandleCounterpartyCreate создаёт контрагента
+func HandleCounterpartyCreate(c *gin.Context, db *sql.DB) {
+	var req counterpartyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверные данные запроса"})
+		return
+	}
+
+	cp := req.toModel()
+	if err := counterparties.Validate(cp); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cp, err := counterparties.Create(c.Request.Context(), db, cp)
+	if err != nil {
+		respondCounterpartyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, cp)
+}
+
+// HandleCounterpartyUpdate изменяет реквизиты контрагента
+func HandleCounterpartyUpdate(c *gin.Context, db *sql.DB) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный идентификатор контрагента"})
+		return
+	}
+
+	var req counterpartyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверные данные запроса"})
+		return
+	}
+
+	cp := req.toModel()
+	cp.ID = id
+	if err := counterparties.Validate(cp); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := counterparties.Update(c.Request.Context(), db, cp); err != nil {
+		respondCounterpartyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, cp)
+}
+
+// HandleCounterpartyDelete удаляет контрагента, если на него не ссылаются контракты
+func HandleCounterpartyDelete(c *gin.Context, db *sql.DB) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный идентификатор контрагента"})
+		return
+	}
+
+	if err := counterparties.Delete(c.Request.Context(), db, id); err != nil {
+		respondCounterpartyError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// HandleCounterpartiesPage отображает страницу со списком контрагентов
+func HandleCounterpartiesPage(c *gin.Context, db *sql.DB) {
+	p := parsePagination(c)
+	query := c.Query("q")
+	items, total, err := counterparties.List(c.Request.Context(), db, counterparties.ListFilter{
+		Query:  query,
+		Limit:  p.PageSize,
+		Offset: p.Offset(),
+	})
+	if err != nil {
+		log.Printf("Ошибка получения списка контрагентов: %v", err)
+		c.String(http.StatusInternalServerError, "Ошибка получения данных контрагентов")
+		return
+	}
+
+	data := gin.H{
+		"Title":          "Контрагенты",
+		"Header":         "Контрагенты",
+		"Query":          query,
+		"Counterparties": items,
+		"Total":          total,
+		"Page":           p.Page,
+		"PageSize":       p.PageSize,
+	}
+	if p.Page > 1 {
+		data["PrevPage"] = p.Page - 1
+	}
+	if p.Offset()+len(items) < total {
+		data["NextPage"] = p.Page + 1
+	}
+
+	renderTemplate(c, "counterparties.html", data)
+}
+
+// HandleCounterpartyPage отображает карточку контрагента с контрактами и итогами платежей
+func HandleCounterpartyPage(c *gin.Context, db *sql.DB) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		c.String(http.StatusBadRequest, "Некорректный идентификатор контрагента")
+		return
+	}
+
+	overview, err := counterparties.Overview(c.Request.Context(), db, id)
+	if errors.Is(err, counterparties.ErrNotFound) {
+		c.String(http.StatusNotFound, "Контрагент не найден")
+		return
+	}
+	if err != nil {
+		log.Printf("Ошибка получения карточки контрагента %d: %v", id, err)
+		c.String(http.StatusInternalServerError, "Ошибка получения данных контрагента")
+		return
+	}
+
+	renderTemplate(c, "counterparty.html", gin.H{
+		"Title":    overview.Counterparty.Name,
+		"Header":   "Карточка контрагента",
+		"Overview": overview,
+	})
+}
+
+// respondCounterpartyError преобразует ошибку пакета counterparties в HTTP-ответ
+func respondCounterpartyError(c *gin.Context, err error) {
+	var inUse *counterparties.InUseError
+	switch {
+	case errors.Is(err, counterparties.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Контрагент не найден"})
+	case errors.Is(err, counterparties.ErrDuplicate):
+		c.JSON(http.StatusConflict, gin.H{"error": "Контрагент с таким ИНН и КПП уже существует"})
+	case errors.As(err, &inUse):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":            "Контрагент используется в контрактах или заявках на оплату и не может быть удалён",
+			"contracts":        inUse.Contracts,
+			"payment_requests": inUse.PaymentRequests,
+		})
+	default:
+		log.Printf("Ошибка обработки контрагента: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка обработки данных контрагента"})
+	}
+}
+
+// mergeRequest описывает тело запроса на объединение дублей
+type mergeRequest struct {
+	SourceIDs []int `json:"source_ids"`
+}
+
+// HandleCounterpartyDuplicates возвращает группы возможных дублей контрагентов
+func HandleCounterpartyDuplicates(c *gin.Context, db *sql.DB) {
+	groups, err := counterparties.FindDuplicates(c.Request.Context(), db)
+	if err != nil {
+		log.Printf("Ошибка поиска дублей контрагентов: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка поиска дублей контрагентов"})
+		return
+	}
+
+	c.JSON(http.StatusOK, groups)
+}
+
+// HandleCounterpartyMerge объединяет дубли с контрагентом из маршрута
+func HandleCounterpartyMerge(c *gin.Context, db *sql.DB) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный идентификатор контрагента"})
+		return
+	}
+
+	var req mergeRequest
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.SourceIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Не указаны контрагенты для объединения"})
+		return
+	}
+
+	records, err := counterparties.Merge(c.Request.Context(), db, id, req.SourceIDs, currentUser(c))
+	if errors.Is(err, counterparties.ErrMergeIntoSelf) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, counterparties.ErrMergePaymentRequestConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": "У объединяемых контрагентов есть заявки на оплату по одному и тому же документу"})
+		return
+	}
+	if err != nil {
+		respondCounterpartyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, records)
+}
+
+// HandleCounterpartyMerges возвращает журнал объединений контрагента
+func HandleCounterpartyMerges(c *gin.Context, db *sql.DB) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный идентификатор контрагента"})
+		return
+	}
+
+	records, err := counterparties.MergeHistory(c.Request.Context(), db, id)
+	if err != nil {
+		log.Printf("Ошибка получения журнала объединений контрагента %d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения журнала объединений"})
+		return
+	}
+
+	c.JSON(http.StatusOK, records)
+}
+
+// resolveAlertRequest описывает тело запроса на закрытие оповещения
+type resolveAlertRequest struct {
+	Comment string `json:"comment"`
+}
+
+// HandleCounterpartyAccounts возвращает известные банковские счета контрагента
+func HandleCounterpartyAccounts(c *gin.Context, db *sql.DB) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный идентификатор контрагента"})
+		return
+	}
+
+	accounts, err := counterparties.Accounts(c.Request.Context(), db, id)
+	if err != nil {
+		log.Printf("Ошибка получения счетов контрагента %d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения счетов контрагента"})
+		return
+	}
+
+	c.JSON(http.StatusOK, accounts)
+}
+
+// HandleAccountAlertsList возвращает оповещения о платежах на новые счета; по умолчанию только непроверенные
+func HandleAccountAlertsList(c *gin.Context, db *sql.DB) {
+	p := parsePagination(c)
+	onlyOpen := c.Query("status") != "all"
+
+	alerts, total, err := counterparties.Alerts(c.Request.Context(), db, onlyOpen, p.PageSize, p.Offset())
+	if err != nil {
+		log.Printf("Ошибка получения оповещений о новых счетах: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения оповещений"})
+		return
+	}
+
+	c.JSON(http.StatusOK, paginatedResponse(alerts, total, p))
+}
+
+// HandleAccountAlertResolve отмечает оповещение о новом счёте как проверенное
+func HandleAccountAlertResolve(c *gin.Context, db *sql.DB) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный идентификатор оповещения"})
+		return
+	}
+
+	var req resolveAlertRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверные данные запроса"})
+		return
+	}
+
+	err := counterparties.ResolveAlert(c.Request.Context(), db, id, currentUser(c), strings.TrimSpace(req.Comment))
+	if errors.Is(err, counterparties.ErrAlertNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Ошибка закрытия оповещения %d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка закрытия оповещения"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -1,11 +1,13 @@
 package handlers
 
 import (
+	"context"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 	"github.com/xuri/excelize/v2"
 	"net/http"
+	"statements/internal/contracts"
 	"statements/internal/database"
 )
 
@@ -116,5 +118,132 @@ func HandleDownloadTransactionsExcel(c *gin.Context) {
 	ExcelFileExporter(c, exporter, "transactions")
 }
 
+// ContractsExporter экспорт реестра контрактов с показателями исполнения
+type ContractsExporter struct {
+	ctx    context.Context
+	filter contracts.ListFilter
+}
+
+// contractRegisterHeaders — колонки реестра контрактов
+var contractRegisterHeaders = []string{
+	"Номер контракта", "Дата контракта", "Контрагент", "ИНН", "Предмет", "Тип контракта", "Статус ЕАИСТ",
+	"Сумма контракта", "Оплачено", "Остаток", "Переплата", "Исполнение, %", "Платежей", "Последний платёж",
+	"Срок исполнения", "Статус исполнения", "Доп. соглашений",
+}
+
+// GetHeaders возвращает заголовки реестра контрактов
+func (e *ContractsExporter) GetHeaders() []string {
+	return contractRegisterHeaders
+}
+
+// GetRows возвращает строки реестра по всем контрактам, подходящим под фильтр
+func (e *ContractsExporter) GetRows() ([]map[string]interface{}, error) {
+	items, _, err := contracts.List(e.ctx, database.DB, e.filter)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]map[string]interface{}, 0, len(items))
+	for _, ct := range items {
+		ex := ct.Execution
+		results = append(results, map[string]interface{}{
+			"Номер контракта":   ct.ContractNumber,
+			"Дата контракта":    ct.ContractDate,
+			"Контрагент":        ct.CounterpartyName,
+			"ИНН":               ct.CounterpartyInn,
+			"Предмет":           ct.Subject,
+			"Тип контракта":     ct.ContractType,
+			"Статус ЕАИСТ":      ct.EaistStatus,
+			"Сумма контракта":   ct.Amount,
+			"Оплачено":          ex.Paid,
+			"Остаток":           ex.Remaining,
+			"Переплата":         ex.Overpaid,
+			"Исполнение, %":     ex.PercentExecuted,
+			"Платежей":          ex.PaymentCount,
+			"Последний платёж":  ex.LastPaymentDate,
+			"Срок исполнения":   ct.ExecutionPeriod,
+			"Статус исполнения": contracts.ExecutionTitles[ex.Status],
+			"Доп. соглашений":   ct.AmendmentCount,
+		})
+	}
+	return results, nil
+}
+
+// HandleDownloadContractsExcel обработчик для скачивания реестра контрактов с показателями исполнения.
+// Принимает те же фильтры и сортировку, что и список контрактов; as_of — дата отчёта
+func HandleDownloadContractsExcel(c *gin.Context) {
+	filter, ok := parseContractFilter(c)
+	if !ok {
+		return
+	}
+	exporter := &ContractsExporter{ctx: c.Request.Context(), filter: filter}
+	ExcelFileExporter(c, exporter, "contracts_register")
+}
+
+// PaymentForecastExporter экспорт прогноза выплат по графикам платежей
+type PaymentForecastExporter struct {
+	ctx    context.Context
+	filter contracts.ForecastFilter
+}
+
+// paymentForecastHeaders — колонки прогноза выплат
+var paymentForecastHeaders = []string{
+	"Начало периода", "Конец периода", "Номер контракта", "Дата контракта", "Контрагент", "Этап", "Описание этапа",
+	"Плановая дата", "Ожидаемая сумма", "Просрочен",
+}
+
+// GetHeaders возвращает заголовки прогноза выплат
+func (e *PaymentForecastExporter) GetHeaders() []string {
+	return paymentForecastHeaders
+}
+
+// GetRows возвращает ожидаемые выплаты по периодам; после каждого периода идёт строка с итогом
+func (e *PaymentForecastExporter) GetRows() ([]map[string]interface{}, error) {
+	forecast, err := contracts.Forecast(e.ctx, database.DB, e.filter)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]map[string]interface{}, 0)
+	for _, b := range forecast.Buckets {
+		for _, item := range b.Items {
+			overdue := ""
+			if item.Overdue {
+				overdue = "Да"
+			}
+			results = append(results, map[string]interface{}{
+				"Начало периода":  b.Start,
+				"Конец периода":   b.End,
+				"Номер контракта": item.ContractNumber,
+				"Дата контракта":  item.ContractDate,
+				"Контрагент":      item.CounterpartyName,
+				"Этап":            item.StageNumber,
+				"Описание этапа":  item.StageTitle,
+				"Плановая дата":   item.PlannedDate,
+				"Ожидаемая сумма": item.Amount,
+				"Просрочен":       overdue,
+			})
+		}
+		results = append(results, map[string]interface{}{
+			"Начало периода":  b.Start,
+			"Конец периода":   b.End,
+			"Номер контракта": "Итого за период",
+			"Ожидаемая сумма": b.Amount,
+		})
+	}
+	return results, nil
+}
+
+// HandleDownloadPaymentForecastExcel обработчик для скачивания прогноза выплат.
+// Принимает те же параметры, что и прогноз выплат
+func HandleDownloadPaymentForecastExcel(c *gin.Context) {
+	filter, ok := parseForecastFilter(c)
+	if !ok {
+		return
+	}
+	exporter := &PaymentForecastExporter{ctx: c.Request.Context(), filter: filter}
+	ExcelFileExporter(c, exporter, "payment_forecast")
+}
+
 // Здесь можно добавить новые экспортеры для других таблиц
 // Например, для выгрузки другой таблицы можно реализовать аналогичный экспорт
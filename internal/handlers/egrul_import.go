@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"statements/internal/config"
+	"statements/internal/counterparties"
+	"statements/internal/egrul"
+	"statements/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// egrulImportResult описывает результат загрузки одного лица из выписки ЕГРЮЛ/ЕГРИП
+type egrulImportResult struct {
+	File           string `json:"file"`
+	Inn            string `json:"inn,omitempty"`
+	Name           string `json:"name,omitempty"`
+	CounterpartyID int    `json:"counterparty_id,omitempty"`
+	Created        bool   `json:"created"`
+	Liquidated     bool   `json:"liquidated"`
+	Error          string `json:"error,omitempty"`
+}
+
+// HandleEgrulImport загружает выписки ЕГРЮЛ/ЕГРИП в формате XML и создаёт или обновляет контрагентов
+func HandleEgrulImport(c *gin.Context, cfg *config.Config, db *sql.DB) {
+	limits := cfg.FileUpload.LimitsFor("egrul")
+	form, ok := parseUploadForm(c, limits)
+	if !ok {
+		return
+	}
+
+	files := form.File["files"]
+	if len(files) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Не выбрано ни одного файла"})
+		return
+	}
+	if errs := checkUploadedFiles(form, limits, "files"); len(errs) > 0 {
+		respondUploadErrors(c, errs)
+		return
+	}
+
+	results := make([]egrulImportResult, 0, len(files))
+	for _, fileHeader := range files {
+		file, err := fileHeader.Open()
+		if err != nil {
+			results = append(results, egrulImportResult{File: fileHeader.Filename, Error: "Ошибка открытия файла"})
+			continue
+		}
+		records, err := egrul.Parse(file)
+		file.Close()
+		if err != nil {
+			log.Printf("Ошибка разбора выписки %s: %v", fileHeader.Filename, err)
+			results = append(results, egrulImportResult{File: fileHeader.Filename, Error: err.Error()})
+			continue
+		}
+
+		for _, reg := range records {
+			result := egrulImportResult{
+				File:       fileHeader.Filename,
+				Inn:        reg.Inn,
+				Name:       utils.FirstNonEmpty(reg.ShortName, reg.FullName),
+				Liquidated: reg.LiquidationDate != "",
+			}
+			result.CounterpartyID, result.Created, err = counterparties.ApplyRegistration(c.Request.Context(), db, reg)
+			if err != nil {
+				log.Printf("Ошибка загрузки сведений ЕГРЮЛ/ЕГРИП для ИНН %s: %v", reg.Inn, err)
+				result.Error = "Ошибка сохранения сведений контрагента"
+			}
+			results = append(results, result)
+		}
+	}
+
+	c.JSON(http.StatusOK, results)
+}
@@ -1,24 +1,31 @@
 package handlers
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"mime/multipart"
 	"net/http"
+	"os"
 	"statements/internal/config"
+	"statements/internal/database"
+	"statements/internal/matching"
 	"statements/internal/python"
+	"statements/internal/storage"
 	"statements/internal/transactions"
 	"statements/internal/utils"
 	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 // HandleFileUploadGin обрабатывает загрузку файлов через Gin
-func HandleFileUploadGin(c *gin.Context, cfg *config.Config) {
-	form, err := c.MultipartForm()
-	if err != nil {
-		c.String(http.StatusInternalServerError, "Ошибка при обработке формы")
+// Оригиналы выписок сохраняются в хранилище документов, а для разбора Python-скриптом используется временная локальная копия
+func HandleFileUploadGin(c *gin.Context, cfg *config.Config, store storage.BlobStore) {
+	limits := cfg.FileUpload.LimitsFor("statements")
+	form, ok := parseUploadForm(c, limits)
+	if !ok {
 		return
 	}
 
@@ -28,6 +35,12 @@ func HandleFileUploadGin(c *gin.Context, cfg *config.Config) {
 		return
 	}
 
+	// Размер, количество и тип файлов проверяются до сохранения и разбора
+	if errs := checkUploadedFiles(form, limits, "files"); len(errs) > 0 {
+		respondUploadErrors(c, errs)
+		return
+	}
+
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 	successfulFiles := 0
@@ -42,8 +55,23 @@ func HandleFileUploadGin(c *gin.Context, cfg *config.Config) {
 		go func(fileHeader *multipart.FileHeader) {
 			defer wg.Done()
 
-			// Открытие файла и его сохранение
-			filePath, err := utils.SaveFile(fileHeader, cfg.FileUpload.UploadDir)
+			// Сохранение оригинала выписки в хранилище
+			if err := archiveStatement(c.Request.Context(), store, fileHeader, currentUser(c)); err != nil {
+				log.Printf("Ошибка сохранения файла в хранилище: %v", err)
+				resultChan <- fmt.Errorf("Ошибка сохранения файла: %v", err)
+				return
+			}
+
+			// Временная локальная копия для Python-скрипта
+			tmpDir, err := os.MkdirTemp("", "statement-*")
+			if err != nil {
+				log.Printf("Ошибка создания временного каталога: %v", err)
+				resultChan <- fmt.Errorf("Ошибка сохранения файла: %v", err)
+				return
+			}
+			defer os.RemoveAll(tmpDir)
+
+			filePath, err := utils.SaveFile(fileHeader, tmpDir)
 			if err != nil {
 				log.Printf("Ошибка сохранения файла: %v", err)
 				resultChan <- fmt.Errorf("Ошибка сохранения файла: %v", err)
@@ -105,10 +133,35 @@ func HandleFileUploadGin(c *gin.Context, cfg *config.Config) {
 		}
 	}
 
+	// Новые транзакции сопоставляются с контрактами по назначению платежа
+	var matched matching.Stats
+	if successfulFiles > 0 {
+		var err error
+		if matched, err = matching.MatchPending(c.Request.Context(), database.DB); err != nil {
+			log.Printf("Ошибка сопоставления транзакций с контрактами: %v", err)
+		}
+	}
+
 	// Возвращаем результат пользователю
 	if errorsOccurred {
 		c.String(http.StatusInternalServerError, "Произошли ошибки при обработке файлов")
 	} else {
-		c.String(http.StatusOK, fmt.Sprintf("Файлы успешно загружены и обработаны: %d", successfulFiles))
+		c.String(http.StatusOK, fmt.Sprintf("Файлы успешно загружены и обработаны: %d. Связано с контрактами: %d, на ручную проверку: %d",
+			successfulFiles, matched.Linked, matched.Review))
+	}
+}
+
+// archiveStatement сохраняет оригинал выписки в хранилище под ключом statements/<дата загрузки>/<сгенерированное имя>
+func archiveStatement(ctx context.Context, store storage.BlobStore, fileHeader *multipart.FileHeader, uploadedBy string) error {
+	upload := storage.NewUpload(store)
+	defer upload.Rollback(context.Background())
+
+	if _, err := upload.PutFile(ctx, "statements/"+time.Now().Format(time.DateOnly), fileHeader, uploadedBy); err != nil {
+		return err
+	}
+	if err := upload.Record(ctx, database.DB); err != nil {
+		return err
 	}
+	upload.Commit()
+	return nil
 }
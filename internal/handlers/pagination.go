@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 1000
+)
+
+// Pagination описывает параметры постраничного вывода из query-параметров page и page_size
+type Pagination struct {
+	Page     int
+	PageSize int
+}
+
+// Offset возвращает смещение первой записи страницы
+func (p Pagination) Offset() int {
+	return (p.Page - 1) * p.PageSize
+}
+
+// parsePagination читает параметры page и page_size, подставляя значения по умолчанию
+func parsePagination(c *gin.Context) Pagination {
+	p := Pagination{Page: 1, PageSize: defaultPageSize}
+	if page, err := strconv.Atoi(c.Query("page")); err == nil && page > 0 {
+		p.Page = page
+	}
+	if size, err := strconv.Atoi(c.Query("page_size")); err == nil && size > 0 {
+		p.PageSize = min(size, maxPageSize)
+	}
+	return p
+}
+
+// paginatedResponse формирует ответ со страницей данных и общим количеством записей
+func paginatedResponse(items interface{}, total int, p Pagination) gin.H {
+	return gin.H{
+		"items":     items,
+		"total":     total,
+		"page":      p.Page,
+		"page_size": p.PageSize,
+	}
+}
+
+// parseIDParam читает числовой идентификатор из параметра маршрута
+func parseIDParam(c *gin.Context, name string) (int, bool) {
+	id, err := strconv.Atoi(c.Param(name))
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,369 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"statements/internal/config"
+	"statements/internal/contracts"
+	"statements/internal/models"
+	"statements/internal/paymentrequests"
+	"statements/internal/storage"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// paymentRequestFileTypes сопоставляет поля формы заявки видам файлов
+var paymentRequestFileTypes = map[string]string{
+	"request_file":           "request",
+	"supporting_documents[]": "supporting",
+}
+
+// HandlePaymentRequestSubmission обрабатывает форму заявки на оплату по договору.
+// Договор ищется по номеру и дате; ошибки возвращаются в JSON по каждому полю
+func HandlePaymentRequestSubmission(c *gin.Context, cfg *config.Config, store storage.BlobStore, db *sql.DB) {
+	ctx := c.Request.Context()
+
+	limits := cfg.FileUpload.LimitsFor("payment_requests")
+	form, ok := parseUploadForm(c, limits)
+	if !ok {
+		return
+	}
+
+	// Разбор и проверка полей формы
+	verr := &paymentrequests.ValidationError{}
+	pr, contractAmount := parsePaymentRequestForm(c, verr)
+	pr.CreatedBy = currentUser(c)
+
+	if len(form.File["request_file"]) != 1 {
+		verr.Add("request_file", "Прикрепите документ на оплату")
+	}
+	fileErrors := checkUploadedFiles(form, limits, "request_file", "supporting_documents[]")
+	for _, fe := range fileErrors {
+		field := fe.Field
+		if field == "" {
+			field = "supporting_documents[]"
+		}
+		verr.Add(field, fe.Error)
+	}
+
+	if err := paymentrequests.ValidateReferences(ctx, db, &pr, contractAmount); err != nil {
+		var fieldErrors *paymentrequests.ValidationError
+		if !errors.As(err, &fieldErrors) {
+			respondPaymentRequestError(c, err)
+			return
+		}
+		for field, message := range fieldErrors.Fields {
+			verr.Add(field, message)
+		}
+	}
+	if len(fileErrors) > 0 {
+		c.JSON(uploadErrorsStatus(fileErrors), gin.H{"error": "Файлы не прошли проверку", "fields": verr.Fields, "files": fileErrors})
+		return
+	}
+	if err := verr.Err(); err != nil {
+		respondPaymentRequestError(c, err)
+		return
+	}
+
+	log.Printf("Заявка на оплату: договор %s от %s, документ %s № %s от %s, сумма %.2f",
+		pr.ContractNumber, pr.ContractDate, pr.DocumentType, pr.DocumentNumber, pr.DocumentDate, pr.Amount)
+
+	// Файлы, сохранённые до ошибки, удаляются вместе с несостоявшейся записью в базе
+	upload := storage.NewUpload(store)
+	defer func() {
+		if err := upload.Rollback(context.Background()); err != nil {
+			log.Printf("Ошибка удаления файлов несохранённой заявки: %v", err)
+		}
+	}()
+
+	type requestDocument struct {
+		documentType string
+		file         models.StoredFile
+	}
+	var documents []requestDocument
+	for _, field := range []string{"request_file", "supporting_documents[]"} {
+		for _, fileHeader := range form.File[field] {
+			f, err := upload.PutFile(ctx, "payment-requests/"+pr.ContractID, fileHeader, pr.CreatedBy)
+			if err != nil {
+				log.Printf("Ошибка сохранения файла заявки %s: %v", field, err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Ошибка сохранения файла %s", fileHeader.Filename)})
+				return
+			}
+			documents = append(documents, requestDocument{documentType: paymentRequestFileTypes[field], file: f})
+		}
+	}
+
+	// Файлы остаются в хранилище, только если заявка и сведения о файлах сохранились
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		respondPaymentRequestError(c, fmt.Errorf("ошибка начала транзакции: %w", err))
+		return
+	}
+	defer tx.Rollback()
+
+	id, err := paymentrequests.Create(ctx, tx, pr)
+	if err != nil {
+		respondPaymentRequestError(c, err)
+		return
+	}
+	if err := paymentrequests.Route(ctx, tx, id, cfg.Approval.StepsFor(pr.Amount)); err != nil {
+		respondPaymentRequestError(c, err)
+		return
+	}
+	if err := upload.Record(ctx, tx); err != nil {
+		respondPaymentRequestError(c, err)
+		return
+	}
+	for _, doc := range documents {
+		if err := paymentrequests.AddDocument(ctx, tx, id, doc.documentType, doc.file); err != nil {
+			respondPaymentRequestError(c, err)
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		respondPaymentRequestError(c, fmt.Errorf("ошибка фиксации заявки на оплату: %w", err))
+		return
+	}
+	upload.Commit()
+
+	c.JSON(http.StatusCreated, gin.H{"id": id, "message": "Заявка успешно создана!"})
+}
+
+// HandlePaymentRequestsList возвращает страницу заявок на оплату с фильтрами
+// contract_id, counterparty_id, date_from, date_to (по дате документа), q (номер документа),
+// status (статус согласования) и awaiting (код этапа, ожидающего решения)
+func HandlePaymentRequestsList(c *gin.Context, db *sql.DB) {
+	p := parsePagination(c)
+	filter := paymentrequests.ListFilter{
+		ContractID:   c.Query("contract_id"),
+		DateFrom:     c.Query("date_from"),
+		DateTo:       c.Query("date_to"),
+		Query:        c.Query("q"),
+		Status:       c.Query("status"),
+		AwaitingStep: c.Query("awaiting"),
+		Limit:        p.PageSize,
+		Offset:       p.Offset(),
+	}
+	switch filter.Status {
+	case "", paymentrequests.StatusPending, paymentrequests.StatusApproved, paymentrequests.StatusRejected:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Статус должен быть pending, approved или rejected"})
+		return
+	}
+	if filter.ContractID != "" && !contracts.IsID(filter.ContractID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный идентификатор контракта"})
+		return
+	}
+	if value := c.Query("counterparty_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil || id <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный идентификатор контрагента"})
+			return
+		}
+		filter.CounterpartyID = id
+	}
+	for _, date := range []string{filter.DateFrom, filter.DateTo} {
+		if _, err := time.Parse(time.DateOnly, date); date != "" && err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Даты периода должны быть в формате ГГГГ-ММ-ДД"})
+			return
+		}
+	}
+
+	items, total, err := paymentrequests.List(c.Request.Context(), db, filter)
+	if err != nil {
+		respondPaymentRequestError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, paginatedResponse(items, total, p))
+}
+
+// HandlePaymentRequestGet возвращает заявку на оплату с приложенными файлами
+func HandlePaymentRequestGet(c *gin.Context, db *sql.DB) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		respondPaymentRequestError(c, paymentrequests.ErrNotFound)
+		return
+	}
+
+	pr, err := paymentrequests.Get(c.Request.Context(), db, id)
+	if err != nil {
+		respondPaymentRequestError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pr)
+}
+
+// HandlePaymentRequestDocumentDownload отдаёт файл заявки на оплату с исходным именем
+func HandlePaymentRequestDocumentDownload(c *gin.Context, store storage.BlobStore, db *sql.DB) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		respondPaymentRequestError(c, paymentrequests.ErrNotFound)
+		return
+	}
+	documentID, ok := parseIDParam(c, "doc")
+	if !ok {
+		respondPaymentRequestError(c, paymentrequests.ErrDocumentNotFound)
+		return
+	}
+
+	doc, err := paymentrequests.Document(c.Request.Context(), db, id, documentID)
+	if err != nil {
+		respondPaymentRequestError(c, err)
+		return
+	}
+
+	sendStoredFile(c, store, doc.Key, doc.FileName, doc.ContentType, doc.Size)
+}
+
+// approvalDecisionRequest описывает тело запроса с решением по этапу согласования
+type approvalDecisionRequest struct {
+	Decision string `json:"decision"` // approve или reject
+	Comment  string `json:"comment"`
+}
+
+// HandlePaymentRequestApproval возвращает ход согласования заявки: статус, этапы с решениями и текущий этап
+func HandlePaymentRequestApproval(c *gin.Context, db *sql.DB) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		respondPaymentRequestError(c, paymentrequests.ErrNotFound)
+		return
+	}
+
+	approval, err := paymentrequests.Approval(c.Request.Context(), db, id)
+	if err != nil {
+		respondPaymentRequestError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, approval)
+}
+
+// HandlePaymentRequestDecision записывает решение текущего пользователя по этапу, ожидающему решения.
+// Решение принимает пользователь с ролью этапа; при отклонении комментарий обязателен
+func HandlePaymentRequestDecision(c *gin.Context, cfg *config.Config, db *sql.DB) {
+	ctx := c.Request.Context()
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		respondPaymentRequestError(c, paymentrequests.ErrNotFound)
+		return
+	}
+
+	var req approvalDecisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверные данные запроса"})
+		return
+	}
+	user := currentUser(c)
+	if user == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Не удалось определить пользователя"})
+		return
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		respondPaymentRequestError(c, fmt.Errorf("ошибка начала транзакции: %w", err))
+		return
+	}
+	defer tx.Rollback()
+
+	approval, err := paymentrequests.Decide(ctx, tx, id, paymentrequests.Decision{
+		Decision: strings.TrimSpace(req.Decision),
+		Comment:  req.Comment,
+		User:     user,
+		Roles:    currentRoles(c),
+	}, cfg.Approval)
+	if err != nil {
+		respondPaymentRequestError(c, err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondPaymentRequestError(c, fmt.Errorf("ошибка фиксации решения по заявке: %w", err))
+		return
+	}
+
+	log.Printf("Решение по заявке на оплату %d: %s (%s), статус %s", id, req.Decision, user, approval.Status)
+	c.JSON(http.StatusOK, approval)
+}
+
+// parsePaymentRequestForm читает поля заявки из формы. Ошибки преобразования чисел записываются в verr.
+// Вторым значением возвращается сумма договора из формы, если она указана
+func parsePaymentRequestForm(c *gin.Context, verr *paymentrequests.ValidationError) (models.PaymentRequest, *float64) {
+	pr := models.PaymentRequest{
+		DocumentType:   strings.TrimSpace(c.PostForm("document_type")),
+		DocumentNumber: strings.TrimSpace(c.PostForm("document_number")),
+		DocumentDate:   strings.TrimSpace(c.PostForm("document_date")),
+		PaymentPurpose: strings.TrimSpace(c.PostForm("payment_purpose")),
+		ContractNumber: strings.TrimSpace(c.PostForm("contract_number")),
+		ContractDate:   strings.TrimSpace(c.PostForm("contract_date")),
+		Address:        strings.TrimSpace(c.PostForm("address")),
+	}
+
+	if value := strings.TrimSpace(c.PostForm("counterparty_id")); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil || id <= 0 {
+			verr.Add("counterparty_id", "Некорректный контрагент")
+		}
+		pr.CounterpartyID = id
+	}
+
+	amount, ok := parseFormAmount(c.PostForm("amount"))
+	switch {
+	case !ok:
+		verr.Add("amount", "Сумма должна быть числом")
+	case amount == nil:
+		verr.Add("amount", "Укажите сумму")
+	default:
+		pr.Amount = *amount
+	}
+
+	contractAmount, ok := parseFormAmount(c.PostForm("contract_amount"))
+	if !ok {
+		verr.Add("contract_amount", "Сумма договора должна быть числом")
+	}
+	return pr, contractAmount
+}
+
+// parseFormAmount разбирает сумму, введённую с пробелами между разрядами и десятичной запятой.
+// Для пустого значения возвращает nil; false — если значение не число
+func parseFormAmount(value string) (*float64, bool) {
+	value = strings.NewReplacer(" ", "", " ", "", ",", ".").Replace(value)
+	if value == "" {
+		return nil, true
+	}
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, false
+	}
+	return &amount, true
+}
+
+// respondPaymentRequestError отправляет ответ, соответствующий ошибке обработки заявки на оплату
+func respondPaymentRequestError(c *gin.Context, err error) {
+	var verr *paymentrequests.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Проверьте правильность заполнения полей", "fields": verr.Fields})
+	case errors.Is(err, paymentrequests.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Заявка на оплату не найдена"})
+	case errors.Is(err, paymentrequests.ErrDocumentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Документ заявки не найден"})
+	case errors.Is(err, paymentrequests.ErrNotPending):
+		c.JSON(http.StatusConflict, gin.H{"error": "Согласование заявки уже завершено"})
+	case errors.Is(err, paymentrequests.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Решение на текущем этапе принимает пользователь с другой ролью"})
+	case errors.Is(err, paymentrequests.ErrDuplicate):
+		c.JSON(http.StatusConflict, gin.H{"error": "Заявка по этому документу уже существует"})
+	default:
+		log.Printf("Ошибка обработки заявки на оплату: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка обработки заявки на оплату"})
+	}
+}
@@ -55,3 +55,11 @@ func HandleAddContractPage(c *gin.Context) {
 		"Header": "Добавление контракта",
 	})
 }
+
+// HandleAddRequestPage обрабатывает запрос на страницу заявки на оплату по договору
+func HandleAddRequestPage(c *gin.Context) {
+	renderTemplate(c, "add_requestInContract.html", gin.H{
+		"Title":  "Заявка на оплату",
+		"Header": "Заявка на оплату по договору",
+	})
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"statements/internal/matching"
+
+	"github.com/gin-gonic/gin"
+)
+
+// allocationRequest описывает тело запроса на распределение суммы транзакции
+type allocationRequest struct {
+	Allocations []struct {
+		ContractID       string  `json:"contract_id"`
+		PaymentRequestID int     `json:"payment_request_id"`
+		Amount           float64 `json:"amount"`
+		Comment          string  `json:"comment"`
+	} `json:"allocations"`
+	Comment string `json:"comment"` // Причина изменения распределения
+}
+
+// HandleTransactionAllocations возвращает действующее распределение суммы транзакции по контрактам
+func HandleTransactionAllocations(c *gin.Context, db *sql.DB) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный идентификатор транзакции"})
+		return
+	}
+
+	set, err := matching.Allocations(c.Request.Context(), db, id)
+	if err != nil {
+		respondAllocationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, set)
+}
+
+// HandleTransactionAllocationHistory возвращает все версии распределения транзакции
+func HandleTransactionAllocationHistory(c *gin.Context, db *sql.DB) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный идентификатор транзакции"})
+		return
+	}
+
+	history, err := matching.AllocationHistory(c.Request.Context(), db, id)
+	if err != nil {
+		respondAllocationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// HandleTransactionAllocate заменяет распределение суммы транзакции по контрактам и заявкам на оплату.
+// Сумма строк должна совпадать с суммой транзакции; предыдущая версия сохраняется в истории
+func HandleTransactionAllocate(c *gin.Context, db *sql.DB) {
+	ctx := c.Request.Context()
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный идентификатор транзакции"})
+		return
+	}
+
+	var req allocationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверные данные запроса"})
+		return
+	}
+	lines := make([]matching.AllocationLine, 0, len(req.Allocations))
+	for _, a := range req.Allocations {
+		lines = append(lines, matching.AllocationLine{
+			ContractID:       a.ContractID,
+			PaymentRequestID: a.PaymentRequestID,
+			Amount:           a.Amount,
+			Comment:          a.Comment,
+		})
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		respondAllocationError(c, fmt.Errorf("ошибка начала транзакции: %w", err))
+		return
+	}
+	defer tx.Rollback()
+
+	user := currentUser(c)
+	set, err := matching.Allocate(ctx, tx, id, lines, req.Comment, user)
+	if err != nil {
+		respondAllocationError(c, err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondAllocationError(c, fmt.Errorf("ошибка фиксации распределения: %w", err))
+		return
+	}
+
+	log.Printf("Распределение транзакции %d изменено (версия %d, %s)", id, set.Version, user)
+	c.JSON(http.StatusOK, set)
+}
+
+// respondAllocationError отправляет ответ, соответствующий ошибке распределения транзакции
+func respondAllocationError(c *gin.Context, err error) {
+	var aerr *matching.AllocationError
+	switch {
+	case errors.As(err, &aerr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": aerr.Message, "line": aerr.Line})
+	case errors.Is(err, matching.ErrTransactionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Транзакция не найдена"})
+	default:
+		log.Printf("Ошибка распределения транзакции: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка распределения транзакции"})
+	}
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"statements/internal/config"
+	"statements/internal/signature"
+	"statements/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// uploadFileError описывает файл, не прошедший проверку ограничений загрузки
+type uploadFileError struct {
+	Field  string `json:"field,omitempty"`
+	File   string `json:"file,omitempty"`
+	Status int    `json:"status"`
+	Error  string `json:"error"`
+}
+
+// parseUploadForm ограничивает размер запроса и разбирает multipart-форму.
+// При превышении размера отвечает 413 и возвращает false
+func parseUploadForm(c *gin.Context, limits config.UploadLimits) (*multipart.Form, bool) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limits.MaxRequestSize())
+
+	form, err := c.MultipartForm()
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": fmt.Sprintf("Размер запроса превышает %d МБ", limits.MaxRequestSize()>>20),
+		})
+		return nil, false
+	case err != nil:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ошибка при обработке формы"})
+		return nil, false
+	}
+	return form, true
+}
+
+// signatureFields — поля форм с файлами отсоединённых подписей
+var signatureFields = map[string]bool{"ecp_file": true}
+
+// checkUploadedFiles проверяет количество, размер и тип файлов в указанных полях формы.
+// Тип определяется по содержимому и записывается в заголовок Content-Type файла для дальнейшего сохранения.
+// Файлы из signatureFields проверяются как подписи
+func checkUploadedFiles(form *multipart.Form, limits config.UploadLimits, fields ...string) []uploadFileError {
+	return checkFiles(form, limits, signatureFields, fields...)
+}
+
+// checkFiles проверяет файлы как checkUploadedFiles; файлы полей из signatures проверяются разбором подписи.
+// Подписи в Base64 и PEM с заголовком CMS по содержимому определяются как текст, поэтому список допустимых типов к ним не применяется
+func checkFiles(form *multipart.Form, limits config.UploadLimits, signatures map[string]bool, fields ...string) []uploadFileError {
+	var errs []uploadFileError
+
+	total := 0
+	for _, field := range fields {
+		total += len(form.File[field])
+	}
+	if total > limits.MaxFiles {
+		errs = append(errs, uploadFileError{
+			Status: http.StatusRequestEntityTooLarge,
+			Error:  fmt.Sprintf("Слишком много файлов: %d, допускается не более %d", total, limits.MaxFiles),
+		})
+	}
+
+	for _, field := range fields {
+		for _, fileHeader := range form.File[field] {
+			if fileHeader.Size > limits.MaxFileSize() {
+				errs = append(errs, uploadFileError{
+					Field:  field,
+					File:   fileHeader.Filename,
+					Status: http.StatusRequestEntityTooLarge,
+					Error:  fmt.Sprintf("Файл больше %d МБ", limits.MaxFileSizeMB),
+				})
+				continue
+			}
+
+			if signatures[field] {
+				if fe := checkSignatureFile(fileHeader); fe != nil {
+					fe.Field = field
+					errs = append(errs, *fe)
+					continue
+				}
+				fileHeader.Header.Set("Content-Type", signature.MIMEType)
+				continue
+			}
+
+			mtype, err := utils.DetectFileType(fileHeader)
+			if err != nil {
+				log.Printf("Ошибка определения типа файла: %v", err)
+				errs = append(errs, uploadFileError{Field: field, File: fileHeader.Filename, Status: http.StatusBadRequest, Error: "Не удалось прочитать файл"})
+				continue
+			}
+			if !utils.TypeAllowed(mtype, limits.AllowedTypes) {
+				errs = append(errs, uploadFileError{
+					Field:  field,
+					File:   fileHeader.Filename,
+					Status: http.StatusUnsupportedMediaType,
+					Error:  fmt.Sprintf("Недопустимый тип файла (%s), допускаются: %s", mtype.String(), utils.DescribeTypes(limits.AllowedTypes)),
+				})
+				continue
+			}
+			fileHeader.Header.Set("Content-Type", mtype.String())
+		}
+	}
+
+	return errs
+}
+
+// checkSignatureFile проверяет, что файл — подпись CMS/PKCS#7 в любом из форматов, которые разбирает проверка ЭЦП
+func checkSignatureFile(fileHeader *multipart.FileHeader) *uploadFileError {
+	data, err := readUploadedFile(fileHeader)
+	if err != nil {
+		log.Printf("Ошибка чтения файла подписи: %v", err)
+		return &uploadFileError{File: fileHeader.Filename, Status: http.StatusBadRequest, Error: "Не удалось прочитать файл"}
+	}
+	if err := signature.Check(data); err != nil {
+		return &uploadFileError{
+			File:   fileHeader.Filename,
+			Status: http.StatusUnsupportedMediaType,
+			Error:  "Файл не является подписью CMS/PKCS#7 (.sig в DER, PEM или Base64)",
+		}
+	}
+	return nil
+}
+
+// readUploadedFile читает загруженный файл целиком; размер файла к этому моменту уже проверен
+func readUploadedFile(fileHeader *multipart.FileHeader) ([]byte, error) {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка открытия файла %s: %w", fileHeader.Filename, err)
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла %s: %w", fileHeader.Filename, err)
+	}
+	return data, nil
+}
+
+// uploadErrorsStatus выбирает код ответа: 413, если есть превышение размера или количества, иначе 415 или 400
+func uploadErrorsStatus(errs []uploadFileError) int {
+	status := http.StatusBadRequest
+	for _, e := range errs {
+		switch e.Status {
+		case http.StatusRequestEntityTooLarge:
+			return e.Status
+		case http.StatusUnsupportedMediaType:
+			status = e.Status
+		}
+	}
+	return status
+}
+
+// respondUploadErrors отвечает списком файлов, не прошедших проверку
+func respondUploadErrors(c *gin.Context, errs []uploadFileError) {
+	c.JSON(uploadErrorsStatus(errs), gin.H{"error": "Файлы не прошли проверку", "files": errs})
+}
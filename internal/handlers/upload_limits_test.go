@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"statements/internal/config"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// uploadResponse — ответ на форму с непрошедшими проверку полями или файлами
+type uploadResponse struct {
+	Fields map[string]string `json:"fields"`
+	Files  []uploadFileError `json:"files"`
+}
+
+func testUploadConfig() *config.Config {
+	return &config.Config{FileUpload: config.FileUploadConfig{Limits: map[string]config.UploadLimits{
+		"contracts": {MaxFileSizeMB: 1, MaxFiles: 5, AllowedTypes: []string{"application/pdf", "application/pkcs7-signature"}},
+	}}}
+}
+
+// postForm выполняет handler с multipart-формой из полей fields и файлов files (поле → содержимое)
+func postForm(t *testing.T, handler gin.HandlerFunc, fields map[string]string, files map[string][]byte) (int, uploadResponse) {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for name, value := range fields {
+		w.WriteField(name, value)
+	}
+	for name, data := range files {
+		part, err := w.CreateFormFile(name, name+".bin")
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write(data)
+	}
+	w.Close()
+
+	gin.SetMode(gin.TestMode)
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", &body)
+	c.Request.Header.Set("Content-Type", w.FormDataContentType())
+	handler(c)
+
+	var resp uploadResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("ответ %d не JSON: %s", rec.Code, rec.Body)
+	}
+	return rec.Code, resp
+}
+
+func TestContractSubmissionSignatureFormats(t *testing.T) {
+	base64Sig, err := os.ReadFile("testdata/contract.pdf.sig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pemSig := "-----BEGIN CMS-----\n" + strings.ReplaceAll(string(base64Sig), "\r", "") + "-----END CMS-----\n"
+	pdf := []byte("%PDF-1.7\nДоговор поставки № 12/3 от 01.02.2024\n")
+
+	cfg := testUploadConfig()
+	// Поля контракта не заполнены: форма отклоняется проверкой полей до обращения к базе,
+	// а ошибки файлов попадают в ответ, только если файлы не прошли проверку
+	handler := func(c *gin.Context) { HandleContractSubmission(c, cfg, nil, nil) }
+
+	tests := []struct {
+		name      string
+		sig       []byte
+		wantError bool
+	}{
+		{"Base64", base64Sig, false},
+		{"PEM с заголовком CMS", []byte(pemSig), false},
+		{"не подпись", []byte("Подпись будет позже"), true},
+		{"PDF вместо подписи", pdf, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, resp := postForm(t, handler, nil, map[string][]byte{"contract_file": pdf, "ecp_file": tt.sig})
+			_, sigFailed := resp.Fields["ecp_file"]
+			if sigFailed != tt.wantError {
+				t.Fatalf("статус %d, ошибка ecp_file: %q, файлы: %+v", status, resp.Fields["ecp_file"], resp.Files)
+			}
+			if tt.wantError && status != http.StatusUnsupportedMediaType {
+				t.Errorf("статус %d, ожидался 415", status)
+			}
+			if !tt.wantError && (status == http.StatusUnsupportedMediaType || len(resp.Files) > 0) {
+				t.Errorf("статус %d, файлы не прошли проверку: %+v", status, resp.Files)
+			}
+			if _, ok := resp.Fields["contract_number"]; !ok {
+				t.Errorf("нет ошибки незаполненного номера контракта: %+v", resp.Fields)
+			}
+		})
+	}
+}
+
+func TestContractDocumentAddRejectsNonSignature(t *testing.T) {
+	cfg := testUploadConfig()
+	handler := func(c *gin.Context) { HandleContractDocumentAdd(c, cfg, nil, nil) }
+
+	status, resp := postForm(t, handler, map[string]string{"type": "ecp"}, map[string][]byte{"file": []byte("Подпись будет позже")})
+	if status != http.StatusUnsupportedMediaType || len(resp.Files) != 1 || resp.Files[0].Field != "file" {
+		t.Errorf("статус %d, файлы %+v", status, resp.Files)
+	}
+}
@@ -0,0 +1,263 @@
+package matching
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"statements/internal/contracts"
+	"statements/internal/database"
+	"statements/internal/models"
+	"statements/internal/paymentrequests"
+	"strings"
+)
+
+// allocationReference — ссылка, с которой создаётся связь транзакции с контрактом при распределении
+const allocationReference = "распределение суммы платежа"
+
+var (
+	// ErrTransactionNotFound возвращается, если транзакция не найдена
+	ErrTransactionNotFound = errors.New("транзакция не найдена")
+)
+
+// AllocationError описывает ошибку распределения. Line — номер строки распределения начиная с 1,
+// 0 — ошибка распределения в целом
+type AllocationError struct {
+	Line    int
+	Message string
+}
+
+func (e *AllocationError) Error() string {
+	if e.Line == 0 {
+		return "некорректное распределение: " + e.Message
+	}
+	return fmt.Sprintf("некорректное распределение, строка %d: %s", e.Line, e.Message)
+}
+
+// AllocationLine — часть суммы транзакции, относимая на контракт и, при необходимости, на заявку на оплату.
+// Если указана только заявка, контракт берётся из неё
+type AllocationLine struct {
+	ContractID       string
+	PaymentRequestID int
+	Amount           float64
+	Comment          string
+}
+
+// Allocations возвращает действующее распределение транзакции. Если транзакция не распределялась,
+// Version равен 0, а список строк пуст
+func Allocations(ctx context.Context, db database.DBTX, transactionID int) (models.AllocationSet, error) {
+	set := models.AllocationSet{TransactionID: transactionID}
+	if err := db.QueryRowContext(ctx,
+		`SELECT `+transactionAmountSQL+` FROM transactions t WHERE t.id = $1`, transactionID).Scan(&set.TransactionAmount); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return set, ErrTransactionNotFound
+		}
+		return set, fmt.Errorf("ошибка получения транзакции %d: %w", transactionID, err)
+	}
+
+	sets, err := allocationSets(ctx, db, transactionID, true)
+	if err != nil {
+		return set, err
+	}
+	if len(sets) == 0 {
+		set.Allocations = make([]models.TransactionAllocation, 0)
+		return set, nil
+	}
+	return sets[0], nil
+}
+
+// AllocationHistory возвращает все версии распределения транзакции, начиная с последней
+func AllocationHistory(ctx context.Context, db database.DBTX, transactionID int) ([]models.AllocationSet, error) {
+	var exists bool
+	if err := db.QueryRowContext(ctx,
+		`SELECT EXISTS(SELECT 1 FROM transactions WHERE id = $1)`, transactionID).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("ошибка получения транзакции %d: %w", transactionID, err)
+	}
+	if !exists {
+		return nil, ErrTransactionNotFound
+	}
+	return allocationSets(ctx, db, transactionID, false)
+}
+
+// Allocate сохраняет новую версию распределения транзакции. Сумма строк должна в точности совпадать
+// с суммой транзакции, заявка — относиться к контракту строки и не быть отклонённой.
+// Для каждого контракта из распределения создаётся связь с транзакцией. Вызывать нужно в транзакции;
+// ошибки строк возвращаются как *AllocationError
+func Allocate(ctx context.Context, db database.DBTX, transactionID int, lines []AllocationLine, comment, createdBy string) (models.AllocationSet, error) {
+	var amount float64
+	err := db.QueryRowContext(ctx,
+		`SELECT `+transactionAmountSQL+` FROM transactions t WHERE t.id = $1 FOR UPDATE`, transactionID).Scan(&amount)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.AllocationSet{}, ErrTransactionNotFound
+	}
+	if err != nil {
+		return models.AllocationSet{}, fmt.Errorf("ошибка блокировки транзакции %d: %w", transactionID, err)
+	}
+
+	if len(lines) == 0 {
+		return models.AllocationSet{}, &AllocationError{Message: "Добавьте хотя бы одну строку распределения"}
+	}
+	var totalCents float64
+	seen := make(map[string]bool)
+	for i := range lines {
+		line := &lines[i]
+		if err := resolveLine(ctx, db, i+1, line); err != nil {
+			return models.AllocationSet{}, err
+		}
+		key := fmt.Sprintf("%s|%d", line.ContractID, line.PaymentRequestID)
+		if seen[key] {
+			return models.AllocationSet{}, &AllocationError{Line: i + 1, Message: "Контракт и заявка уже указаны в другой строке"}
+		}
+		seen[key] = true
+		totalCents += math.Round(line.Amount * 100)
+	}
+	if amountCents := math.Round(amount * 100); totalCents != amountCents {
+		return models.AllocationSet{}, &AllocationError{Message: fmt.Sprintf(
+			"Сумма распределения %.2f не совпадает с суммой платежа %.2f", totalCents/100, amountCents/100)}
+	}
+
+	if _, err := db.ExecContext(ctx,
+		`UPDATE transaction_allocation_sets SET is_current = FALSE WHERE transaction_id = $1 AND is_current`, transactionID); err != nil {
+		return models.AllocationSet{}, fmt.Errorf("ошибка замены распределения транзакции %d: %w", transactionID, err)
+	}
+	var setID int
+	err = db.QueryRowContext(ctx,
+		`INSERT INTO transaction_allocation_sets (transaction_id, version, comment, created_by)
+		SELECT $1, COALESCE(MAX(version), 0) + 1, NULLIF($2, ''), NULLIF($3, '')
+		FROM transaction_allocation_sets WHERE transaction_id = $1
+		RETURNING id`,
+		transactionID, strings.TrimSpace(comment), createdBy).Scan(&setID)
+	if err != nil {
+		return models.AllocationSet{}, fmt.Errorf("ошибка сохранения распределения транзакции %d: %w", transactionID, err)
+	}
+
+	for _, line := range lines {
+		_, err := db.ExecContext(ctx,
+			`INSERT INTO transaction_allocations (set_id, contract_uid, payment_request_id, amount, comment)
+			VALUES ($1, $2, NULLIF($3, 0), $4, NULLIF($5, ''))`,
+			setID, line.ContractID, line.PaymentRequestID, math.Round(line.Amount*100)/100, strings.TrimSpace(line.Comment))
+		if err != nil {
+			return models.AllocationSet{}, fmt.Errorf("ошибка сохранения строки распределения транзакции %d: %w", transactionID, err)
+		}
+		if err := Link(ctx, db, transactionID, line.ContractID, 1, MethodManual, allocationReference, createdBy); err != nil {
+			return models.AllocationSet{}, err
+		}
+	}
+
+	return Allocations(ctx, db, transactionID)
+}
+
+// resolveLine проверяет строку распределения и дополняет контракт по заявке на оплату
+func resolveLine(ctx context.Context, db database.DBTX, n int, line *AllocationLine) error {
+	line.ContractID = strings.TrimSpace(line.ContractID)
+	switch {
+	case math.IsNaN(line.Amount) || line.Amount <= 0:
+		return &AllocationError{Line: n, Message: "Сумма должна быть больше нуля"}
+	case math.Abs(line.Amount*100-math.Round(line.Amount*100)) > 1e-6:
+		return &AllocationError{Line: n, Message: "Сумма указывается с точностью до копеек"}
+	case line.ContractID == "" && line.PaymentRequestID <= 0:
+		return &AllocationError{Line: n, Message: "Укажите контракт или заявку на оплату"}
+	}
+
+	if line.PaymentRequestID > 0 {
+		var contractID, status string
+		err := db.QueryRowContext(ctx,
+			`SELECT contract_uid::text, status FROM payment_requests WHERE id = $1`, line.PaymentRequestID).Scan(&contractID, &status)
+		if errors.Is(err, sql.ErrNoRows) {
+			return &AllocationError{Line: n, Message: "Заявка на оплату не найдена"}
+		}
+		if err != nil {
+			return fmt.Errorf("ошибка получения заявки %d: %w", line.PaymentRequestID, err)
+		}
+		if status == paymentrequests.StatusRejected {
+			return &AllocationError{Line: n, Message: "Заявка на оплату отклонена"}
+		}
+		if line.ContractID == "" {
+			line.ContractID = contractID
+		} else if !strings.EqualFold(line.ContractID, contractID) {
+			return &AllocationError{Line: n, Message: "Заявка относится к другому контракту"}
+		}
+	}
+
+	ct, err := contracts.Get(ctx, db, line.ContractID)
+	if errors.Is(err, contracts.ErrNotFound) {
+		return &AllocationError{Line: n, Message: "Контракт не найден"}
+	}
+	if err != nil {
+		return err
+	}
+	line.ContractID = ct.ID
+	return nil
+}
+
+// allocationSets возвращает версии распределения транзакции со строками; onlyCurrent ограничивает выборку действующей
+func allocationSets(ctx context.Context, db database.DBTX, transactionID int, onlyCurrent bool) ([]models.AllocationSet, error) {
+	where := ""
+	if onlyCurrent {
+		where = " AND s.is_current"
+	}
+	rows, err := db.QueryContext(ctx,
+		`SELECT s.id, s.transaction_id, `+transactionAmountSQL+`, s.version, s.is_current,
+		        COALESCE(s.comment, ''), COALESCE(s.created_by, ''), s.created_at::text
+		FROM transaction_allocation_sets s
+		JOIN transactions t ON t.id = s.transaction_id
+		WHERE s.transaction_id = $1`+where+`
+		ORDER BY s.version DESC`, transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения распределений транзакции %d: %w", transactionID, err)
+	}
+	defer rows.Close()
+
+	sets := make([]models.AllocationSet, 0)
+	var ids []int
+	for rows.Next() {
+		var id int
+		var set models.AllocationSet
+		if err := rows.Scan(&id, &set.TransactionID, &set.TransactionAmount, &set.Version, &set.Current,
+			&set.Comment, &set.CreatedBy, &set.CreatedAt); err != nil {
+			return nil, fmt.Errorf("ошибка чтения распределения: %w", err)
+		}
+		sets = append(sets, set)
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения распределений транзакции %d: %w", transactionID, err)
+	}
+	rows.Close()
+
+	for i, id := range ids {
+		sets[i].Allocations, err = allocationLines(ctx, db, id)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return sets, nil
+}
+
+// allocationLines возвращает строки версии распределения с реквизитами контрактов и заявок
+func allocationLines(ctx context.Context, db database.DBTX, setID int) ([]models.TransactionAllocation, error) {
+	rows, err := db.QueryContext(ctx,
+		`SELECT a.id, a.contract_uid::text, COALESCE(c.contract_number, ''), COALESCE(c.contract_date::text, ''),
+		        COALESCE(a.payment_request_id, 0), COALESCE(r.document_number, ''), a.amount::float8, COALESCE(a.comment, '')
+		FROM transaction_allocations a
+		LEFT JOIN contracts c ON c.uid = a.contract_uid
+		LEFT JOIN payment_requests r ON r.id = a.payment_request_id
+		WHERE a.set_id = $1
+		ORDER BY a.id`, setID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения строк распределения %d: %w", setID, err)
+	}
+	defer rows.Close()
+
+	lines := make([]models.TransactionAllocation, 0)
+	for rows.Next() {
+		var a models.TransactionAllocation
+		if err := rows.Scan(&a.ID, &a.ContractID, &a.ContractNumber, &a.ContractDate,
+			&a.PaymentRequestID, &a.PaymentRequestNumber, &a.Amount, &a.Comment); err != nil {
+			return nil, fmt.Errorf("ошибка чтения строки распределения: %w", err)
+		}
+		lines = append(lines, a)
+	}
+	return lines, rows.Err()
+}
@@ -0,0 +1,386 @@
+package matching
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"statements/internal/database"
+	"statements/internal/models"
+	"strings"
+)
+
+// Вклад признаков в уверенность сопоставления
+const (
+	scoreNumber   = 0.5 // Совпал номер контракта
+	scoreRegistry = 0.7 // Совпал реестровый номер ЕИС
+	scoreDate     = 0.3 // Совпала дата контракта
+	scoreInn      = 0.2 // Совпал ИНН контрагента
+	penaltyDate   = 0.2 // Дата указана, но не совпадает
+	penaltyInn    = 0.3 // Контрагент платежа известен, но это не контрагент по контракту
+)
+
+const (
+	// AutoLinkConfidence — уверенность, начиная с которой связь создаётся без проверки
+	AutoLinkConfidence = 0.7
+	// ambiguityMargin — насколько лучший кандидат должен опережать следующего, чтобы выбор считался однозначным
+	ambiguityMargin = 0.2
+	// batchSize — сколько транзакций выбирается за один запрос при сопоставлении
+	batchSize = 500
+)
+
+// Причины отправки на ручную проверку
+const (
+	ReasonAmbiguous     = "ambiguous"      // Подходят несколько контрактов
+	ReasonLowConfidence = "low_confidence" // Единственный кандидат недостаточно надёжен
+	ReasonNotFound      = "not_found"      // Контракт по ссылке не найден
+)
+
+// Stats — итоги сопоставления транзакций с контрактами
+type Stats struct {
+	Processed int `json:"processed"` // Транзакций проверено
+	Linked    int `json:"linked"`    // Связано с контрактами автоматически
+	Review    int `json:"review"`    // Отправлено на ручную проверку
+}
+
+// transaction — сведения о транзакции, нужные для сопоставления
+type transaction struct {
+	id          int
+	description string
+	inns        []string // ИНН контрагентов платежа, кроме собственной организации
+}
+
+// candidate — контракт, подходящий под ссылку из назначения платежа
+type candidate struct {
+	contractID string
+	confidence float64
+	reference  string
+}
+
+// MatchPending сопоставляет с контрактами транзакции, ещё не прошедшие сопоставление.
+// Вызывается после загрузки выписки и при запуске для ранее загруженных транзакций
+func MatchPending(ctx context.Context, db *sql.DB) (Stats, error) {
+	var stats Stats
+	lastID := 0
+	for {
+		rows, err := db.QueryContext(ctx,
+			`SELECT id FROM transactions WHERE contract_matched_at IS NULL AND id > $1 ORDER BY id LIMIT $2`,
+			lastID, batchSize)
+		if err != nil {
+			return stats, fmt.Errorf("ошибка выборки транзакций для сопоставления: %w", err)
+		}
+		var ids []int
+		for rows.Next() {
+			var id int
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return stats, fmt.Errorf("ошибка чтения транзакции: %w", err)
+			}
+			ids = append(ids, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return stats, fmt.Errorf("ошибка чтения транзакций для сопоставления: %w", err)
+		}
+		if len(ids) == 0 {
+			break
+		}
+
+		for _, id := range ids {
+			outcome, err := matchTransaction(ctx, db, id)
+			if err != nil {
+				return stats, err
+			}
+			switch outcome {
+			case outcomeLinked:
+				stats.Linked++
+			case outcomeReview:
+				stats.Review++
+			}
+			if outcome != outcomeSkipped {
+				stats.Processed++
+			}
+		}
+		lastID = ids[len(ids)-1]
+	}
+
+	if stats.Processed > 0 {
+		log.Printf("Сопоставление транзакций с контрактами: проверено %d, связано %d, на проверку %d",
+			stats.Processed, stats.Linked, stats.Review)
+	}
+	return stats, nil
+}
+
+// RematchNotFound повторно сопоставляет транзакции, отложенные на проверку из-за того, что контракт по ссылке
+// не был найден, если ссылка указывает на один из новых или изменённых контрактов: по номеру, реестровому номеру
+// или дате. Такие записи очереди удаляются, транзакции сопоставляются заново через MatchPending.
+// Вызывается после создания и изменения контрактов
+func RematchNotFound(ctx context.Context, db *sql.DB, changed []models.Contract) (Stats, error) {
+	numbers := make(map[string]bool)
+	registry := make(map[string]bool)
+	dates := make(map[string]bool)
+	for _, ct := range changed {
+		if ct.ContractNumber != "" {
+			numbers[NormalizeNumber(ct.ContractNumber)] = true
+		}
+		if ct.EaistRegistryNumber != "" {
+			registry[ct.EaistRegistryNumber] = true
+		}
+		if ct.ContractDate != "" {
+			dates[ct.ContractDate] = true
+		}
+	}
+	if len(numbers) == 0 && len(registry) == 0 {
+		return Stats{}, nil
+	}
+
+	rows, err := db.QueryContext(ctx,
+		`SELECT r.id, COALESCE(t.payment_description, '')
+		FROM transaction_match_reviews r
+		JOIN transactions t ON t.id = r.transaction_id
+		WHERE r.status = $1 AND r.reason = $2`, ReviewPending, ReasonNotFound)
+	if err != nil {
+		return Stats{}, fmt.Errorf("ошибка выборки отложенных транзакций: %w", err)
+	}
+	var reviewIDs []int
+	for rows.Next() {
+		var id int
+		var description string
+		if err := rows.Scan(&id, &description); err != nil {
+			rows.Close()
+			return Stats{}, fmt.Errorf("ошибка чтения отложенной транзакции: %w", err)
+		}
+		for _, ref := range ParseReferences(description) {
+			// Дата без номера учитывается, потому что findCandidates ищет контракт по дате и ИНН при опечатке в номере
+			if registry[ref.Number] || (!ref.Registry && (numbers[NormalizeNumber(ref.Number)] || dates[ref.Date])) {
+				reviewIDs = append(reviewIDs, id)
+				break
+			}
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return Stats{}, fmt.Errorf("ошибка чтения отложенных транзакций: %w", err)
+	}
+	if len(reviewIDs) == 0 {
+		return Stats{}, nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return Stats{}, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+	for _, id := range reviewIDs {
+		// Запись могли разобрать вручную, пока шёл поиск
+		_, err := tx.ExecContext(ctx,
+			`WITH review AS (
+			    DELETE FROM transaction_match_reviews WHERE id = $1 AND status = $2 AND reason = $3
+			    RETURNING transaction_id
+			)
+			UPDATE transactions SET contract_matched_at = NULL WHERE id IN (SELECT transaction_id FROM review)`,
+			id, ReviewPending, ReasonNotFound)
+		if err != nil {
+			return Stats{}, fmt.Errorf("ошибка возврата отложенной транзакции на сопоставление: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return Stats{}, fmt.Errorf("ошибка фиксации возврата транзакций на сопоставление: %w", err)
+	}
+
+	return MatchPending(ctx, db)
+}
+
+// Итоги сопоставления одной транзакции
+const (
+	outcomeSkipped = iota // Транзакция уже сопоставлена другим процессом
+	outcomeNoMatch        // Ссылок на контракт нет
+	outcomeLinked         // Создана связь с контрактом
+	outcomeReview         // Отправлена на ручную проверку
+)
+
+// matchTransaction сопоставляет транзакцию с контрактами, если она ещё не сопоставлялась.
+// Транзакция отмечается сопоставленной в той же транзакции БД, поэтому параллельные загрузки
+// не обрабатывают её дважды
+func matchTransaction(ctx context.Context, db *sql.DB, transactionID int) (int, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return outcomeSkipped, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	t := transaction{id: transactionID}
+	var inn, innC sql.NullString
+	err = tx.QueryRowContext(ctx,
+		`UPDATE transactions SET contract_matched_at = now()
+		WHERE id = $1 AND contract_matched_at IS NULL
+		RETURNING COALESCE(payment_description, ''),
+		          CASE WHEN counterparty_id IS NOT NULL THEN inn END,
+		          CASE WHEN counterparty_c_id IS NOT NULL THEN inn_c END`,
+		transactionID).Scan(&t.description, &inn, &innC)
+	if errors.Is(err, sql.ErrNoRows) {
+		return outcomeSkipped, nil
+	}
+	if err != nil {
+		return outcomeSkipped, fmt.Errorf("ошибка отметки транзакции %d: %w", transactionID, err)
+	}
+	for _, v := range []sql.NullString{inn, innC} {
+		if v.Valid && v.String != "" {
+			t.inns = append(t.inns, v.String)
+		}
+	}
+
+	outcome, err := match(ctx, tx, t)
+	if err != nil {
+		return outcomeSkipped, err
+	}
+	if err := tx.Commit(); err != nil {
+		return outcomeSkipped, fmt.Errorf("ошибка фиксации сопоставления транзакции %d: %w", transactionID, err)
+	}
+	return outcome, nil
+}
+
+// match находит контракты по ссылкам из назначения платежа и создаёт связь или запись для ручной проверки.
+// Связь создаётся, только если все ссылки указывают на один контракт с достаточной уверенностью
+func match(ctx context.Context, db database.DBTX, t transaction) (int, error) {
+	refs := ParseReferences(t.description)
+	if len(refs) == 0 {
+		return outcomeNoMatch, nil
+	}
+
+	// Для каждого контракта учитывается лучшая из ссылок
+	best := make(map[string]candidate)
+	for _, ref := range refs {
+		found, err := findCandidates(ctx, db, ref, t.inns)
+		if err != nil {
+			return outcomeSkipped, fmt.Errorf("ошибка поиска контракта для транзакции %d: %w", t.id, err)
+		}
+		for _, cand := range found {
+			if cand.confidence > best[cand.contractID].confidence {
+				best[cand.contractID] = cand
+			}
+		}
+	}
+
+	candidates := make([]candidate, 0, len(best))
+	for _, cand := range best {
+		candidates = append(candidates, cand)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].confidence != candidates[j].confidence {
+			return candidates[i].confidence > candidates[j].confidence
+		}
+		return candidates[i].contractID < candidates[j].contractID
+	})
+
+	raw := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		raw = append(raw, ref.Raw)
+	}
+	reference := strings.Join(raw, "; ")
+
+	var reason string
+	switch {
+	case len(candidates) == 0:
+		reason = ReasonNotFound
+	case len(candidates) > 1 && candidates[1].confidence > candidates[0].confidence-ambiguityMargin:
+		reason = ReasonAmbiguous
+	case candidates[0].confidence < AutoLinkConfidence:
+		reason = ReasonLowConfidence
+	default:
+		top := candidates[0]
+		if err := Link(ctx, db, t.id, top.contractID, top.confidence, MethodAuto, top.reference, ""); err != nil {
+			return outcomeSkipped, err
+		}
+		return outcomeLinked, nil
+	}
+
+	if err := addReview(ctx, db, t.id, reason, reference, candidates); err != nil {
+		return outcomeSkipped, err
+	}
+	return outcomeReview, nil
+}
+
+// findCandidates ищет действующие контракты по ссылке и оценивает уверенность совпадения.
+// Если по номеру ничего не найдено, кандидатами считаются контракты того же контрагента с той же датой
+func findCandidates(ctx context.Context, db database.DBTX, ref Reference, inns []string) ([]candidate, error) {
+	condition, base := normalizedNumberSQL+" = $1", scoreNumber
+	value := NormalizeNumber(ref.Number)
+	if ref.Registry {
+		condition, base, value = "c.eaist_registry_number = $1", scoreRegistry, ref.Number
+	}
+
+	found, err := queryCandidates(ctx, db, condition, value)
+	if err != nil {
+		return nil, err
+	}
+	if len(found) == 0 && ref.Date != "" && len(inns) > 0 && !ref.Registry {
+		// Номер мог быть указан с опечаткой: дата и ИНН без номера дают кандидата для проверки
+		found, err = queryCandidates(ctx, db, "c.contract_date = $1::date", ref.Date)
+		if err != nil {
+			return nil, err
+		}
+		base = 0
+	}
+
+	candidates := make([]candidate, 0, len(found))
+	for _, f := range found {
+		confidence := base
+		switch {
+		case ref.Date == "":
+		case ref.Date == f.date:
+			confidence += scoreDate
+		default:
+			confidence -= penaltyDate
+		}
+		switch {
+		case len(inns) == 0:
+		case slices.Contains(inns, f.inn):
+			confidence += scoreInn
+		default:
+			confidence -= penaltyInn
+		}
+		if base == 0 && !slices.Contains(inns, f.inn) {
+			continue
+		}
+		candidates = append(candidates, candidate{
+			contractID: f.contractID,
+			confidence: min(max(confidence, 0), 1),
+			reference:  ref.Raw,
+		})
+	}
+	return candidates, nil
+}
+
+// foundContract — контракт, выбранный по условию поиска
+type foundContract struct {
+	contractID string
+	date       string
+	inn        string
+}
+
+// queryCandidates выбирает действующие контракты по условию с единственным параметром $1
+func queryCandidates(ctx context.Context, db database.DBTX, condition, value string) ([]foundContract, error) {
+	rows, err := db.QueryContext(ctx,
+		`SELECT c.uid::text, c.contract_date::text, cp.inn
+		FROM contracts c
+		JOIN counterparties cp ON cp.id = c.counterparty_id
+		WHERE c.deleted_at IS NULL AND `+condition, value)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var found []foundContract
+	for rows.Next() {
+		var f foundContract
+		if err := rows.Scan(&f.contractID, &f.date, &f.inn); err != nil {
+			return nil, err
+		}
+		found = append(found, f)
+	}
+	return found, rows.Err()
+}
@@ -0,0 +1,158 @@
+package matching
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Reference — ссылка на контракт, найденная в назначении платежа
+type Reference struct {
+	Raw      string // Фрагмент назначения платежа
+	Number   string // Номер контракта или реестровый номер
+	Date     string // Дата контракта ГГГГ-ММ-ДД, если указана
+	Registry bool   // Number — реестровый номер контракта в ЕИС
+}
+
+var (
+	// contractKeyword находит упоминание контракта или договора, в том числе сокращённое
+	contractKeyword = regexp.MustCompile(`(?i)(?:гос\.?\s*)?(?:контракт[а-яё]*|договор[а-яё]*|дог-р[а-яё]*|дог\.|к-т[а-яё]*|г/к|д/г)`)
+	// numberAfterSign — номер после знака номера. Между словом «договор» и знаком номера допускаются
+	// уточняющие слова: «договор поставки №», «контракт на оказание услуг №»
+	numberAfterSign = regexp.MustCompile(`(?i)^[\s:,]*(` + qualifierWords + `?)(?:№|N|No\.?|#)\s*([0-9A-Za-zА-Яа-яЁё][0-9A-Za-zА-Яа-яЁё/\\\-_.]*)`)
+	// onlyQualifiers — текст между словом «договор» и датой состоит только из уточняющих слов
+	onlyQualifiers = regexp.MustCompile(`^[\s:,]*` + qualifierWords + `$`)
+	// otherDocument — слова, после которых знак номера относится не к договору, а к другому документу:
+	// «по договору и счёту № 15», «по договору спецификация № 3»
+	otherDocument = regexp.MustCompile(`(?i)(?:^|[^а-яё])(?:сч|сч[её]т[а-яё]*|с-ф|акт[а-яё]*|упд|накладн[а-яё]*|ттн|заявк[а-яё]*|письм[а-яё]*|спецификац[а-яё]*|приложени[а-яё]*|доп|дополнительн[а-яё]*|соглашени[а-яё]*|заказ[а-яё]*)(?:$|[^а-яё])`)
+	// numberBare — номер сразу после слова «контракт» без знака номера
+	numberBare = regexp.MustCompile(`^[\s:]*([0-9][0-9A-Za-zА-Яа-яЁё/\\\-_.]*)`)
+	// referenceDate — дата контракта, обычно после «от»
+	referenceDate = regexp.MustCompile(`(?i)(?:^|\s)(?:от\s*)?(\d{1,2})[./](\d{1,2})[./](\d{4}|\d{2})(?:\s*г\.?)?`)
+	// registryNumber — реестровый номер контракта в ЕИС из 19 цифр
+	registryNumber = regexp.MustCompile(`(?:^|\D)(\d{19})(?:\D|$)`)
+)
+
+// qualifierWords — до шести слов без цифр, уточняющих вид договора
+const qualifierWords = `(?:[А-Яа-яЁё][А-Яа-яЁё\-]*\.?[\s,]+){0,6}`
+
+// referenceWindow — сколько символов после ключевого слова просматривается в поисках номера и даты
+const referenceWindow = 80
+
+// ParseReferences находит в назначении платежа ссылки на контракты: номер с датой после слов
+// «контракт», «договор» и их сокращений, а также реестровые номера ЕИС. Повторы не возвращаются
+func ParseReferences(description string) []Reference {
+	var refs []Reference
+	seen := make(map[string]bool)
+	add := func(ref Reference) {
+		key := fmt.Sprintf("%t|%s|%s", ref.Registry, NormalizeNumber(ref.Number), ref.Date)
+		if !seen[key] {
+			seen[key] = true
+			refs = append(refs, ref)
+		}
+	}
+
+	keywords := contractKeyword.FindAllStringIndex(description, -1)
+	for i, loc := range keywords {
+		// Фрагмент ограничен следующим упоминанием контракта и окном просмотра
+		end := len(description)
+		if i+1 < len(keywords) {
+			end = keywords[i+1][0]
+		}
+		tail := truncate(description[loc[1]:end], referenceWindow)
+
+		ref := Reference{}
+		rest := tail
+		if number, end, ok := signedNumber(tail); ok {
+			ref.Number = number
+			rest = tail[end:]
+		} else if m := referenceDate.FindStringSubmatchIndex(tail); m != nil && isQualifiers(tail[:m[0]]) {
+			// «договор от 01.02.2024 № 12»: номер после даты
+			ref.Date = parseDate(tail[m[2]:m[3]], tail[m[4]:m[5]], tail[m[6]:m[7]])
+			rest = tail[m[1]:]
+			if number, end, ok := signedNumber(rest); ok {
+				ref.Number = number
+				rest = rest[end:]
+			}
+		} else if m := numberBare.FindStringSubmatchIndex(tail); m != nil {
+			ref.Number = cleanNumber(tail[m[2]:m[3]])
+			rest = tail[m[1]:]
+		}
+		if ref.Date == "" {
+			if m := referenceDate.FindStringSubmatchIndex(rest); m != nil && strings.TrimSpace(rest[:m[0]]) == "" {
+				ref.Date = parseDate(rest[m[2]:m[3]], rest[m[4]:m[5]], rest[m[6]:m[7]])
+				rest = rest[m[1]:]
+			}
+		}
+		if !strings.ContainsAny(ref.Number, "0123456789") || utf8.RuneCountInString(ref.Number) > 50 {
+			continue
+		}
+		ref.Raw = strings.TrimSpace(description[loc[0] : loc[1]+len(tail)-len(rest)])
+		add(ref)
+	}
+
+	for _, m := range registryNumber.FindAllStringSubmatch(description, -1) {
+		add(Reference{Raw: m[1], Number: m[1], Registry: true})
+	}
+	return refs
+}
+
+// signedNumber находит номер со знаком номера в начале s и возвращает его вместе с концом найденного фрагмента
+func signedNumber(s string) (number string, end int, ok bool) {
+	m := numberAfterSign.FindStringSubmatchIndex(s)
+	if m == nil || otherDocument.MatchString(s[m[2]:m[3]]) {
+		return "", 0, false
+	}
+	return cleanNumber(s[m[4]:m[5]]), m[1], true
+}
+
+// isQualifiers сообщает, что s состоит только из слов, уточняющих вид договора, и не называет другой документ
+func isQualifiers(s string) bool {
+	return onlyQualifiers.MatchString(s+" ") && !otherDocument.MatchString(s)
+}
+
+// lookalikes заменяет кириллические буквы, совпадающие по написанию с латинскими, и разные тире
+var lookalikes = strings.NewReplacer(
+	"А", "A", "В", "B", "Е", "E", "К", "K", "М", "M", "Н", "H", "О", "O",
+	"Р", "P", "С", "C", "Т", "T", "Х", "X", "У", "Y", "–", "-", "—", "-",
+)
+
+// normalizedNumberSQL — выражение SQL, приводящее номер контракта к виду NormalizeNumber
+const normalizedNumberSQL = `translate(upper(regexp_replace(c.contract_number, '\s', '', 'g')), 'АВЕКМНОРСТХУ–—', 'ABEKMHOPCTXY--')`
+
+// NormalizeNumber приводит номер контракта к виду для сравнения: без пробелов, в верхнем регистре,
+// с латинскими буквами вместо одинаковых по написанию кириллических
+func NormalizeNumber(number string) string {
+	return lookalikes.Replace(strings.ToUpper(strings.Join(strings.Fields(number), "")))
+}
+
+// cleanNumber убирает знаки препинания, попавшие в конец номера
+func cleanNumber(number string) string {
+	return strings.TrimRight(number, `./\-_`)
+}
+
+// parseDate собирает дату ГГГГ-ММ-ДД из дня, месяца и года; двузначный год относится к 2000-м.
+// Для невозможной даты возвращает пустую строку
+func parseDate(day, month, year string) string {
+	if len(year) == 2 {
+		year = "20" + year
+	}
+	date, err := time.Parse("2.1.2006", day+"."+month+"."+year)
+	if err != nil {
+		return ""
+	}
+	return date.Format(time.DateOnly)
+}
+
+// truncate обрезает строку до n символов, не разрывая многобайтовые символы
+func truncate(s string, n int) string {
+	for i := range s {
+		if n == 0 {
+			return s[:i]
+		}
+		n--
+	}
+	return s
+}
@@ -0,0 +1,109 @@
+package matching
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseReferences(t *testing.T) {
+	tests := []struct {
+		description string
+		want        []Reference
+	}{
+		{
+			"Оплата по договору поставки № 12/3 от 01.02.2024 за товар. В т.ч. НДС 20% - 1000.00",
+			[]Reference{{Raw: "договору поставки № 12/3 от 01.02.2024", Number: "12/3", Date: "2024-02-01"}},
+		},
+		{
+			"Оплата по договору оказания услуг № 45-У от 15.03.2023г. Сумма 12000-00 Без налога (НДС)",
+			[]Reference{{Raw: "договору оказания услуг № 45-У от 15.03.2023г.", Number: "45-У", Date: "2023-03-15"}},
+		},
+		{
+			"Аванс 30% по договору подряда №СМР-7/2024 от 10.01.2024 НДС не облагается",
+			[]Reference{{Raw: "договору подряда №СМР-7/2024 от 10.01.2024", Number: "СМР-7/2024", Date: "2024-01-10"}},
+		},
+		{
+			"Оплата по контракту на поставку товара № 15 от 02.02.2024, акт № 3 от 29.02.2024",
+			[]Reference{{Raw: "контракту на поставку товара № 15 от 02.02.2024", Number: "15", Date: "2024-02-02"}},
+		},
+		{
+			"Оплата по договору на выполнение работ по ремонту кровли N 7-П от 05.06.2024",
+			[]Reference{{Raw: "договору на выполнение работ по ремонту кровли N 7-П от 05.06.2024", Number: "7-П", Date: "2024-06-05"}},
+		},
+		{
+			"Оплата по дог. поставки, № 88 от 1.2.24 Сумма 5000-00",
+			[]Reference{{Raw: "дог. поставки, № 88 от 1.2.24", Number: "88", Date: "2024-02-01"}},
+		},
+		{
+			"Оплата по договору № 12 спецификация № 3 от 01.03.2024",
+			[]Reference{{Raw: "договору № 12", Number: "12"}},
+		},
+		{
+			"Оплата по счету № 123 от 01.02.2024 по договору № 77 от 01.01.2024",
+			[]Reference{{Raw: "договору № 77 от 01.01.2024", Number: "77", Date: "2024-01-01"}},
+		},
+		{
+			"Оплата по договору от 01.02.2024 № 12/П за услуги связи",
+			[]Reference{{Raw: "договору от 01.02.2024 № 12/П", Number: "12/П", Date: "2024-02-01"}},
+		},
+		{
+			"Оплата по договору поставки от 01.02.2024 № 12 за январь",
+			[]Reference{{Raw: "договору поставки от 01.02.2024 № 12", Number: "12", Date: "2024-02-01"}},
+		},
+		{
+			"Оплата по дог. 15/2 от 3.2.24 за февраль",
+			[]Reference{{Raw: "дог. 15/2 от 3.2.24", Number: "15/2", Date: "2024-02-03"}},
+		},
+		{
+			"Оплата по г/к № 123-ЭА от 01.12.2023; по договору № 45",
+			[]Reference{
+				{Raw: "г/к № 123-ЭА от 01.12.2023", Number: "123-ЭА", Date: "2023-12-01"},
+				{Raw: "договору № 45", Number: "45"},
+			},
+		},
+		{
+			"Оплата по гос. контракту № 0373200041523000123 от 20.04.2023",
+			[]Reference{
+				{Raw: "гос. контракту № 0373200041523000123 от 20.04.2023", Number: "0373200041523000123", Date: "2023-04-20"},
+				{Raw: "0373200041523000123", Number: "0373200041523000123", Registry: true},
+			},
+		},
+		{
+			"Оплата по договору и счету № 55 от 01.02.2024",
+			nil,
+		},
+		{
+			"Оплата по договору согласно дополнительному соглашению № 2 от 01.04.2024",
+			nil,
+		},
+		{
+			"Оплата по счету № 55 от 01.02.2024 за канцтовары",
+			nil,
+		},
+		{
+			"Перечисление заработной платы за март 2024 г.",
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			if got := ParseReferences(tt.description); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseReferences() = %+v\nожидалось %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeNumber(t *testing.T) {
+	tests := map[string]string{
+		"12/3":       "12/3",
+		"сМр-7/2024": "CMP-7/2024",
+		" 45 – У ":   "45-Y",
+		"А-15":       "A-15",
+	}
+	for in, want := range tests {
+		if got := NormalizeNumber(in); got != want {
+			t.Errorf("NormalizeNumber(%q) = %q, ожидалось %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package matching
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"statements/internal/contracts"
+	"statements/internal/database"
+	"statements/internal/models"
+)
+
+// Способ создания связи транзакции с контрактом
+const (
+	MethodAuto   = "auto"
+	MethodManual = "manual"
+)
+
+// Статусы записи очереди ручной проверки
+const (
+	ReviewPending   = "pending"
+	ReviewLinked    = "linked"
+	ReviewDismissed = "dismissed"
+)
+
+// transactionAmountSQL — сумма транзакции: списание или поступление
+const transactionAmountSQL = `COALESCE(NULLIF(t.debit, 0), t.credit, 0)::float8`
+
+var (
+	// ErrReviewNotFound возвращается, если запись очереди не найдена или уже разобрана
+	ErrReviewNotFound = errors.New("запись очереди сопоставления не найдена или уже разобрана")
+)
+
+// Link связывает транзакцию с контрактом. Повторная связь с тем же контрактом не создаётся
+func Link(ctx context.Context, db database.DBTX, transactionID int, contractID string, confidence float64, method, reference, createdBy string) error {
+	_, err := db.ExecContext(ctx,
+		`INSERT INTO transaction_contract_links (transaction_id, contract_uid, confidence, method, reference, created_by)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''))
+		ON CONFLICT (transaction_id, contract_uid) DO NOTHING`,
+		transactionID, contractID, confidence, method, reference, createdBy)
+	if err != nil {
+		return fmt.Errorf("ошибка связи транзакции %d с контрактом %s: %w", transactionID, contractID, err)
+	}
+	return nil
+}
+
+// addReview ставит транзакцию в очередь ручной проверки вместе с контрактами-кандидатами
+func addReview(ctx context.Context, db database.DBTX, transactionID int, reason, reference string, candidates []candidate) error {
+	var reviewID int
+	err := db.QueryRowContext(ctx,
+		`INSERT INTO transaction_match_reviews (transaction_id, reason, reference)
+		VALUES ($1, $2, NULLIF($3, ''))
+		ON CONFLICT (transaction_id) DO NOTHING
+		RETURNING id`,
+		transactionID, reason, reference).Scan(&reviewID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка постановки транзакции %d на проверку: %w", transactionID, err)
+	}
+
+	for _, cand := range candidates {
+		_, err := db.ExecContext(ctx,
+			`INSERT INTO transaction_match_candidates (review_id, contract_uid, confidence) VALUES ($1, $2, $3)`,
+			reviewID, cand.contractID, cand.confidence)
+		if err != nil {
+			return fmt.Errorf("ошибка сохранения кандидата для транзакции %d: %w", transactionID, err)
+		}
+	}
+	return nil
+}
+
+// Reviews возвращает очередь ручной проверки; onlyPending ограничивает выборку неразобранными записями
+func Reviews(ctx context.Context, db database.DBTX, onlyPending bool, limit, offset int) ([]models.MatchReview, int, error) {
+	where := ""
+	if onlyPending {
+		where = "WHERE r.status = 'pending'"
+	}
+
+	var total int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM transaction_match_reviews r "+where).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("ошибка подсчёта записей очереди сопоставления: %w", err)
+	}
+
+	rows, err := db.QueryContext(ctx,
+		`SELECT r.id, r.transaction_id, t.date::text, t.document_number, `+transactionAmountSQL+`,
+		        COALESCE(t.payment_description, ''), COALESCE(t.inn, ''), COALESCE(t.name, ''),
+		        COALESCE(t.inn_c, ''), COALESCE(t.name_c, ''),
+		        r.reason, COALESCE(r.reference, ''), r.status, r.created_at::text,
+		        COALESCE(r.resolved_at::text, ''), COALESCE(r.resolved_by, ''), COALESCE(r.resolution_comment, '')
+		FROM transaction_match_reviews r
+		JOIN transactions t ON t.id = r.transaction_id
+		`+where+`
+		ORDER BY r.created_at DESC, r.id DESC
+		LIMIT $1 OFFSET $2`, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ошибка получения очереди сопоставления: %w", err)
+	}
+	defer rows.Close()
+
+	reviews := make([]models.MatchReview, 0)
+	for rows.Next() {
+		var r models.MatchReview
+		if err := rows.Scan(&r.ID, &r.TransactionID, &r.Date, &r.DocumentNumber, &r.Amount,
+			&r.PaymentDescription, &r.Inn, &r.Name, &r.InnC, &r.NameC,
+			&r.Reason, &r.Reference, &r.Status, &r.CreatedAt,
+			&r.ResolvedAt, &r.ResolvedBy, &r.ResolutionComment); err != nil {
+			return nil, 0, fmt.Errorf("ошибка чтения записи очереди сопоставления: %w", err)
+		}
+		reviews = append(reviews, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("ошибка чтения очереди сопоставления: %w", err)
+	}
+
+	for i := range reviews {
+		reviews[i].Candidates, err = reviewCandidates(ctx, db, reviews[i].ID)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	return reviews, total, nil
+}
+
+// reviewCandidates возвращает контракты-кандидаты записи очереди по убыванию уверенности
+func reviewCandidates(ctx context.Context, db database.DBTX, reviewID int) ([]models.MatchCandidate, error) {
+	rows, err := db.QueryContext(ctx,
+		`SELECT c.uid::text, c.contract_number, c.contract_date::text, cp.name, COALESCE(tm.amount, c.amount)::float8,
+		        m.confidence::float8
+		FROM transaction_match_candidates m
+		JOIN contracts c ON c.uid = m.contract_uid AND c.deleted_at IS NULL
+		JOIN counterparties cp ON cp.id = c.counterparty_id
+		CROSS JOIN LATERAL contract_amended_terms(c.uid, CURRENT_DATE) tm
+		WHERE m.review_id = $1
+		ORDER BY m.confidence DESC, c.contract_date DESC`, reviewID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения кандидатов записи %d: %w", reviewID, err)
+	}
+	defer rows.Close()
+
+	candidates := make([]models.MatchCandidate, 0)
+	for rows.Next() {
+		var m models.MatchCandidate
+		if err := rows.Scan(&m.ContractID, &m.ContractNumber, &m.ContractDate, &m.CounterpartyName, &m.Amount, &m.Confidence); err != nil {
+			return nil, fmt.Errorf("ошибка чтения кандидата: %w", err)
+		}
+		candidates = append(candidates, m)
+	}
+	return candidates, rows.Err()
+}
+
+// ResolveReview связывает транзакцию из очереди с выбранным контрактом и закрывает запись.
+// Контракт не обязан быть среди кандидатов. Вызывать нужно в транзакции
+func ResolveReview(ctx context.Context, db database.DBTX, reviewID int, contractID, resolvedBy, comment string) error {
+	if _, err := contracts.Get(ctx, db, contractID); err != nil {
+		return err
+	}
+
+	var transactionID int
+	var reference string
+	err := db.QueryRowContext(ctx,
+		`UPDATE transaction_match_reviews
+		SET status = 'linked', resolved_at = now(), resolved_by = NULLIF($2, ''), resolution_comment = NULLIF($3, '')
+		WHERE id = $1 AND status = 'pending'
+		RETURNING transaction_id, COALESCE(reference, '')`,
+		reviewID, resolvedBy, comment).Scan(&transactionID, &reference)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrReviewNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка закрытия записи очереди %d: %w", reviewID, err)
+	}
+
+	return Link(ctx, db, transactionID, contractID, 1, MethodManual, reference, resolvedBy)
+}
+
+// DismissReview закрывает запись очереди без связи с контрактом
+func DismissReview(ctx context.Context, db database.DBTX, reviewID int, resolvedBy, comment string) error {
+	res, err := db.ExecContext(ctx,
+		`UPDATE transaction_match_reviews
+		SET status = 'dismissed', resolved_at = now(), resolved_by = NULLIF($2, ''), resolution_comment = NULLIF($3, '')
+		WHERE id = $1 AND status = 'pending'`,
+		reviewID, resolvedBy, comment)
+	if err != nil {
+		return fmt.Errorf("ошибка закрытия записи очереди %d: %w", reviewID, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrReviewNotFound
+	}
+	return nil
+}
+
+// ContractPayments возвращает транзакции, связанные с контрактом, от новых к старым,
+// вместе с суммой, отнесённой на контракт
+func ContractPayments(ctx context.Context, db database.DBTX, contractID string) ([]models.ContractPayment, error) {
+	rows, err := db.QueryContext(ctx,
+		`SELECT l.id, t.id, t.date::text, t.document_number, `+transactionAmountSQL+`,
+		        COALESCE(t.payment_description, ''), COALESCE(t.name, ''), COALESCE(t.name_c, ''),
+		        COALESCE(p.amount, 0)::float8, l.confidence::float8, l.method, COALESCE(l.reference, ''),
+		        COALESCE(l.created_by, ''), l.created_at::text
+		FROM transaction_contract_links l
+		JOIN transactions t ON t.id = l.transaction_id
+		LEFT JOIN contract_payment_amounts p ON p.transaction_id = l.transaction_id AND p.contract_uid = l.contract_uid
+		WHERE l.contract_uid = $1
+		ORDER BY t.date DESC, t.id DESC`, contractID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения платежей по контракту %s: %w", contractID, err)
+	}
+	defer rows.Close()
+
+	payments := make([]models.ContractPayment, 0)
+	for rows.Next() {
+		var p models.ContractPayment
+		if err := rows.Scan(&p.LinkID, &p.TransactionID, &p.Date, &p.DocumentNumber, &p.Amount,
+			&p.PaymentDescription, &p.Name, &p.NameC, &p.Allocated,
+			&p.Confidence, &p.Method, &p.Reference, &p.CreatedBy, &p.CreatedAt); err != nil {
+			return nil, fmt.Errorf("ошибка чтения платежа по контракту: %w", err)
+		}
+		payments = append(payments, p)
+	}
+	return payments, rows.Err()
+}
@@ -0,0 +1,112 @@
+package models
+
+// Contract описывает контракт. ID — публичный идентификатор (uid), не зависящий от номера и даты контракта
+type Contract struct {
+	ID                  string  `json:"id"`
+	CounterpartyID      int     `json:"counterparty_id"`
+	CounterpartyName    string  `json:"counterparty_name,omitempty"`
+	CounterpartyInn     string  `json:"counterparty_inn,omitempty"`
+	ContractNumber      string  `json:"contract_number"`
+	ContractDate        string  `json:"contract_date"`
+	ExecutionPeriod     string  `json:"execution_period"`
+	Amount              float64 `json:"amount"`
+	EaistRegistryNumber string  `json:"eaist_registry_number,omitempty"`
+	PaymentDays         *int    `json:"payment_days,omitempty"`
+	ValidityPeriod      string  `json:"validity_period,omitempty"`
+	Subject             string  `json:"subject"`
+	ContractType        string  `json:"contract_type"`
+	WorkType            string  `json:"work_type,omitempty"`
+	ConclusionBasis     string  `json:"conclusion_basis,omitempty"`
+	ProcurementType     string  `json:"procurement_type,omitempty"`
+	Initiator           string  `json:"initiator,omitempty"`
+	EaistStatus         string  `json:"eaist_status,omitempty"`
+	EaistLink           string  `json:"eaist_link,omitempty"`
+	CreatedAt           string  `json:"created_at,omitempty"`
+	UpdatedAt           string  `json:"updated_at,omitempty"`
+	SignatureStatus     string  `json:"signature_status,omitempty"` // Результат последней проверки ЭЦП, только для чтения
+
+	Execution ContractExecution `json:"execution"` // Исполнение по связанным платежам, только для чтения
+
+	// Сумма, сроки, предмет и дни на оплату выше — действующие на дату AsOf с учётом дополнительных соглашений.
+	// Initial — первоначальные условия; именно их меняет редактирование контракта
+	AsOf           string        `json:"as_of,omitempty"`
+	AmendmentCount int           `json:"amendment_count"` // Соглашений, вступивших в силу к дате AsOf
+	Initial        ContractTerms `json:"initial_terms"`
+}
+
+// EaistStatusChange описывает смену статуса ЕАИСТ контракта
+type EaistStatusChange struct {
+	ID        int    `json:"id"`
+	OldStatus string `json:"old_status"` // Пустая строка — статус не был задан
+	NewStatus string `json:"new_status"`
+	Reason    string `json:"reason,omitempty"`
+	ChangedBy string `json:"changed_by,omitempty"`
+	ChangedAt string `json:"changed_at"`
+}
+
+// ContractTerms — условия контракта, которые меняются дополнительными соглашениями
+type ContractTerms struct {
+	Amount          float64 `json:"amount"`
+	ExecutionPeriod string  `json:"execution_period"`
+	ValidityPeriod  string  `json:"validity_period,omitempty"`
+	Subject         string  `json:"subject"`
+	PaymentDays     *int    `json:"payment_days,omitempty"`
+}
+
+// ContractAmendment описывает дополнительное соглашение к контракту. Заполнены только изменяемые условия
+type ContractAmendment struct {
+	ID              int               `json:"id"`
+	ContractID      string            `json:"contract_id"`
+	Number          string            `json:"number"`
+	Date            string            `json:"date"`           // Дата заключения соглашения
+	EffectiveDate   string            `json:"effective_date"` // Дата вступления изменений в силу
+	Amount          *float64          `json:"amount,omitempty"`
+	ExecutionPeriod *string           `json:"execution_period,omitempty"`
+	ValidityPeriod  *string           `json:"validity_period,omitempty"`
+	Subject         *string           `json:"subject,omitempty"`
+	PaymentDays     *int              `json:"payment_days,omitempty"`
+	Comment         string            `json:"comment,omitempty"`
+	DocumentID      *int              `json:"document_id,omitempty"` // Документ контракта с текстом соглашения
+	Changes         []AmendmentChange `json:"changes"`               // Изменённые условия с прежними значениями, только для чтения
+	CreatedBy       string            `json:"created_by,omitempty"`
+	CreatedAt       string            `json:"created_at,omitempty"`
+}
+
+// AmendmentChange описывает изменение одного условия контракта соглашением
+type AmendmentChange struct {
+	Field string      `json:"field"`
+	Title string      `json:"title"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// ContractExecution описывает исполнение контракта по связанным с ним платежам
+type ContractExecution struct {
+	Paid            float64 `json:"paid"`                        // Оплачено
+	Remaining       float64 `json:"remaining"`                   // Остаток к оплате; 0 при переплате
+	Overpaid        float64 `json:"overpaid"`                    // Сумма переплаты
+	PercentExecuted float64 `json:"percent_executed"`            // Процент исполнения от суммы контракта
+	PaymentCount    int     `json:"payment_count"`               // Количество платежей
+	LastPaymentDate string  `json:"last_payment_date,omitempty"` // Дата последнего платежа
+	Status          string  `json:"status"`                      // not_paid, partial, paid, overpaid или overdue
+	IsOverpaid      bool    `json:"is_overpaid"`                 // Оплачено больше суммы контракта
+	IsOverdue       bool    `json:"is_overdue"`                  // Срок исполнения прошёл, а контракт оплачен не полностью
+}
+
+// ContractDocument описывает версию документа контракта. ID общий для всех версий документа,
+// VersionID указывает на конкретную версию
+type ContractDocument struct {
+	ID          int    `json:"id"`
+	VersionID   int    `json:"version_id"`
+	Type        string `json:"type"` // Вид документа: contract, memo, ecp, technical_task, additional и т.д.
+	Title       string `json:"title"`
+	Version     int    `json:"version"`
+	Current     bool   `json:"current"`
+	FileName    string `json:"file_name"` // Исходное имя файла
+	ContentType string `json:"content_type,omitempty"`
+	Size        int64  `json:"size,omitempty"`
+	SHA256      string `json:"sha256,omitempty"` // Хеш содержимого; у файлов, перенесённых из старых колонок, отсутствует
+	UploadedBy  string `json:"uploaded_by,omitempty"`
+	UploadedAt  string `json:"uploaded_at,omitempty"`
+	Key         string `json:"-"` // Ключ в хранилище документов
+}
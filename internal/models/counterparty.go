@@ -0,0 +1,93 @@
+package models
+
+// Counterparty описывает контрагента
+type Counterparty struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	Inn     string `json:"inn"`
+	Kpp     string `json:"kpp,omitempty"`
+	Address string `json:"address,omitempty"`
+
+	// Сведения из выписки ЕГРЮЛ/ЕГРИП
+	FullName         string `json:"full_name,omitempty"`
+	ShortName        string `json:"short_name,omitempty"`
+	Ogrn             string `json:"ogrn,omitempty"`
+	Director         string `json:"director,omitempty"`
+	RegistrationDate string `json:"registration_date,omitempty"`
+	LiquidationDate  string `json:"liquidation_date,omitempty"`
+}
+
+// Liquidated сообщает, прекратил ли контрагент деятельность по данным ЕГРЮЛ/ЕГРИП
+func (cp Counterparty) Liquidated() bool {
+	return cp.LiquidationDate != ""
+}
+
+// CounterpartyContract описывает контракт в карточке контрагента
+type CounterpartyContract struct {
+	ID              string  `json:"id"`
+	ContractNumber  string  `json:"contract_number"`
+	ContractDate    string  `json:"contract_date"`
+	ExecutionPeriod string  `json:"execution_period"`
+	Amount          float64 `json:"amount"`
+	ContractType    string  `json:"contract_type"`
+	Subject         string  `json:"subject"`
+	EaistStatus     string  `json:"eaist_status,omitempty"`
+}
+
+// CounterpartyPayments описывает итоги платежей по контрагенту
+type CounterpartyPayments struct {
+	PaidTo           float64 `json:"paid_to"`           // Перечислено контрагенту
+	ReceivedFrom     float64 `json:"received_from"`     // Получено от контрагента
+	TransactionCount int     `json:"transaction_count"` // Количество транзакций
+	LastPaymentDate  string  `json:"last_payment_date,omitempty"`
+}
+
+// CounterpartyOverview объединяет карточку контрагента, его контракты и итоги платежей
+type CounterpartyOverview struct {
+	Counterparty Counterparty           `json:"counterparty"`
+	Contracts    []CounterpartyContract `json:"contracts"`
+	Payments     CounterpartyPayments   `json:"payments"`
+	Accounts     []CounterpartyAccount  `json:"accounts"`
+}
+
+// CounterpartyAccount описывает банковский счёт контрагента, выявленный по платежам
+type CounterpartyAccount struct {
+	ID             int    `json:"id"`
+	CounterpartyID int    `json:"counterparty_id"`
+	Account        string `json:"account"`
+	Bik            string `json:"bik,omitempty"`
+	FirstSeenDate  string `json:"first_seen_date,omitempty"`
+	LastSeenDate   string `json:"last_seen_date,omitempty"`
+	Occurrences    int    `json:"occurrences"`
+}
+
+// AccountAlert описывает оповещение о платеже контрагенту на ранее не встречавшийся счёт
+type AccountAlert struct {
+	ID                int     `json:"id"`
+	CounterpartyID    int     `json:"counterparty_id"`
+	CounterpartyName  string  `json:"counterparty_name"`
+	CounterpartyInn   string  `json:"counterparty_inn"`
+	Account           string  `json:"account"`
+	Bik               string  `json:"bik,omitempty"`
+	TransactionID     int     `json:"transaction_id,omitempty"`
+	Amount            float64 `json:"amount"`
+	PaymentDate       string  `json:"payment_date,omitempty"`
+	CreatedAt         string  `json:"created_at"`
+	ResolvedAt        string  `json:"resolved_at,omitempty"`
+	ResolvedBy        string  `json:"resolved_by,omitempty"`
+	ResolutionComment string  `json:"resolution_comment,omitempty"`
+}
+
+// CounterpartyRegistration описывает сведения о контрагенте из выписки ЕГРЮЛ/ЕГРИП
+type CounterpartyRegistration struct {
+	Inn              string `json:"inn"`
+	Kpp              string `json:"kpp,omitempty"`
+	Ogrn             string `json:"ogrn"`
+	FullName         string `json:"full_name"`
+	ShortName        string `json:"short_name,omitempty"`
+	Address          string `json:"address,omitempty"`
+	Director         string `json:"director,omitempty"`
+	RegistrationDate string `json:"registration_date,omitempty"`
+	LiquidationDate  string `json:"liquidation_date,omitempty"`
+	Entrepreneur     bool   `json:"entrepreneur"` // Индивидуальный предприниматель (ЕГРИП)
+}
@@ -0,0 +1,65 @@
+package models
+
+// PaymentRequest описывает заявку на оплату по контракту
+type PaymentRequest struct {
+	ID               int     `json:"id"`
+	ContractID       string  `json:"contract_id"` // Публичный идентификатор контракта
+	ContractNumber   string  `json:"contract_number"`
+	ContractDate     string  `json:"contract_date"`
+	CounterpartyID   int     `json:"counterparty_id"`
+	CounterpartyName string  `json:"counterparty_name,omitempty"`
+	Amount           float64 `json:"amount"`
+	DocumentType     string  `json:"document_type"` // Вид документа на оплату: счёт, акт, УПД и т.д.
+	DocumentNumber   string  `json:"document_number"`
+	DocumentDate     string  `json:"document_date"`
+	PaymentPurpose   string  `json:"payment_purpose"`
+	Address          string  `json:"address,omitempty"`
+	Status           string  `json:"status"`                 // pending, approved, rejected
+	CurrentStep      string  `json:"current_step,omitempty"` // Этап согласования, ожидающий решения
+	Paid             float64 `json:"paid"`                   // Оплачено по распределениям платежей
+	CreatedBy        string  `json:"created_by,omitempty"`
+	CreatedAt        string  `json:"created_at,omitempty"`
+	UpdatedAt        string  `json:"updated_at,omitempty"`
+
+	Documents []PaymentRequestDocument `json:"documents,omitempty"`
+}
+
+// PaymentRequestDocument описывает файл, приложенный к заявке на оплату
+type PaymentRequestDocument struct {
+	ID          int    `json:"id"`
+	Type        string `json:"type"` // request — документ на оплату, supporting — дополнительный документ
+	FileName    string `json:"file_name"`
+	ContentType string `json:"content_type,omitempty"`
+	Size        int64  `json:"size,omitempty"`
+	SHA256      string `json:"sha256,omitempty"`
+	UploadedBy  string `json:"uploaded_by,omitempty"`
+	UploadedAt  string `json:"uploaded_at,omitempty"`
+	Key         string `json:"-"` // Ключ в хранилище документов
+}
+
+// PaymentRequestApproval описывает ход согласования заявки
+type PaymentRequestApproval struct {
+	RequestID   int            `json:"request_id"`
+	Status      string         `json:"status"`
+	CurrentStep *ApprovalStep  `json:"current_step,omitempty"` // Этап, ожидающий решения; nil, если согласование завершено
+	Steps       []ApprovalStep `json:"steps"`
+}
+
+// ApprovalStep описывает этап согласования заявки и решение по нему
+type ApprovalStep struct {
+	ID       int               `json:"id"`
+	Order    int               `json:"order"`
+	Code     string            `json:"code"`
+	Title    string            `json:"title"`
+	Role     string            `json:"role,omitempty"`
+	Decision *ApprovalDecision `json:"decision,omitempty"`
+}
+
+// ApprovalDecision описывает решение по этапу согласования
+type ApprovalDecision struct {
+	ID        int    `json:"id"`
+	Decision  string `json:"decision"` // approve или reject
+	Comment   string `json:"comment,omitempty"`
+	DecidedBy string `json:"decided_by"`
+	DecidedAt string `json:"decided_at"`
+}
@@ -0,0 +1,54 @@
+package models
+
+// PaymentSchedule описывает график платежей контракта в сравнении с фактической оплатой
+type PaymentSchedule struct {
+	ContractID     string         `json:"contract_id"`
+	ContractAmount float64        `json:"contract_amount"` // Действующая сумма контракта
+	Planned        float64        `json:"planned"`         // Сумма этапов графика
+	Unscheduled    float64        `json:"unscheduled"`     // Часть суммы контракта, не распределённая по этапам
+	Paid           float64        `json:"paid"`            // Фактически оплачено по контракту
+	Stages         []PaymentStage `json:"stages"`
+}
+
+// PaymentStage описывает этап графика платежей. Фактическая оплата относится на этапы по порядку плановых дат
+type PaymentStage struct {
+	Number      int     `json:"number"`
+	Title       string  `json:"title,omitempty"`
+	PlannedDate string  `json:"planned_date"`
+	Amount      float64 `json:"amount"`    // Плановая сумма
+	Paid        float64 `json:"paid"`      // Оплачено по этапу, только для чтения
+	Remaining   float64 `json:"remaining"` // Осталось оплатить, только для чтения
+	Status      string  `json:"status"`    // planned, partial, paid или overdue, только для чтения
+}
+
+// PaymentForecast описывает прогноз выплат по графикам платежей действующих контрактов
+type PaymentForecast struct {
+	Period  string           `json:"period"` // week или month
+	From    string           `json:"from"`
+	To      string           `json:"to"`
+	Total   float64          `json:"total"`   // Всего ожидается выплат, включая просроченные
+	Overdue float64          `json:"overdue"` // Просроченные этапы, отнесённые на первый период
+	Buckets []ForecastBucket `json:"buckets"`
+}
+
+// ForecastBucket описывает ожидаемые выплаты за неделю или месяц
+type ForecastBucket struct {
+	Start   string         `json:"start"`
+	End     string         `json:"end"`
+	Amount  float64        `json:"amount"`
+	Overdue float64        `json:"overdue"` // В том числе просроченные этапы
+	Items   []ForecastItem `json:"items"`
+}
+
+// ForecastItem описывает ожидаемую выплату по этапу графика
+type ForecastItem struct {
+	ContractID       string  `json:"contract_id"`
+	ContractNumber   string  `json:"contract_number"`
+	ContractDate     string  `json:"contract_date"`
+	CounterpartyName string  `json:"counterparty_name"`
+	StageNumber      int     `json:"stage_number"`
+	StageTitle       string  `json:"stage_title,omitempty"`
+	PlannedDate      string  `json:"planned_date"`
+	Amount           float64 `json:"amount"` // Неоплаченная часть этапа
+	Overdue          bool    `json:"overdue"`
+}
@@ -0,0 +1,35 @@
+package models
+
+// SignatureCheck описывает результат проверки отсоединённой ЭЦП контракта
+type SignatureCheck struct {
+	ID                  int               `json:"id"`
+	ContractID          string            `json:"contract_id"`
+	SignatureDocumentID int               `json:"signature_document_id"` // Версия файла подписи (ContractDocument.VersionID)
+	ContentDocumentID   int               `json:"content_document_id"`   // Версия файла контракта (ContractDocument.VersionID)
+	Status              string            `json:"status"`                // valid, expired, invalid, unsupported, malformed
+	Message             string            `json:"message,omitempty"`
+	CheckedBy           string            `json:"checked_by,omitempty"`
+	CheckedAt           string            `json:"checked_at"`
+	Signers             []SignatureSigner `json:"signers"`
+}
+
+// SignatureSigner описывает подписанта и сертификат из проверенной подписи
+type SignatureSigner struct {
+	Status             string `json:"status"`
+	Message            string `json:"message,omitempty"`
+	ContentMatch       *bool  `json:"content_match"`   // null — соответствие файлу не проверялось
+	SignatureValid     *bool  `json:"signature_valid"` // null — алгоритм подписи не поддерживается
+	CertificateExpired bool   `json:"certificate_expired"`
+	Subject            string `json:"subject,omitempty"`
+	CommonName         string `json:"common_name,omitempty"`
+	Organization       string `json:"organization,omitempty"`
+	Inn                string `json:"inn,omitempty"`
+	Ogrn               string `json:"ogrn,omitempty"`
+	SerialNumber       string `json:"serial_number,omitempty"`
+	Issuer             string `json:"issuer,omitempty"`
+	ValidFrom          string `json:"valid_from,omitempty"`
+	ValidTo            string `json:"valid_to,omitempty"`
+	SigningTime        string `json:"signing_time,omitempty"`
+	DigestAlgorithm    string `json:"digest_algorithm,omitempty"`
+	SignatureAlgorithm string `json:"signature_algorithm,omitempty"`
+}
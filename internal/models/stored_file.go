@@ -0,0 +1,12 @@
+package models
+
+// StoredFile описывает файл в хранилище документов
+type StoredFile struct {
+	Key          string `json:"key"`
+	OriginalName string `json:"original_name"`
+	ContentType  string `json:"content_type,omitempty"`
+	Size         int64  `json:"size"`
+	UploadedBy   string `json:"uploaded_by,omitempty"`
+	UploadedAt   string `json:"uploaded_at,omitempty"`
+	SHA256       string `json:"sha256,omitempty"` // Хеш содержимого, вычисляется при загрузке и в stored_files не хранится
+}
@@ -0,0 +1,75 @@
+package models
+
+// ContractPayment описывает банковскую транзакцию, связанную с контрактом
+type ContractPayment struct {
+	LinkID             int     `json:"link_id"`
+	TransactionID      int     `json:"transaction_id"`
+	Date               string  `json:"date"`
+	DocumentNumber     string  `json:"document_number"`
+	Amount             float64 `json:"amount"`    // Сумма транзакции
+	Allocated          float64 `json:"allocated"` // Часть суммы, отнесённая на контракт
+	PaymentDescription string  `json:"payment_description,omitempty"`
+	Name               string  `json:"name,omitempty"`   // Плательщик
+	NameC              string  `json:"name_c,omitempty"` // Получатель
+	Confidence         float64 `json:"confidence"`       // Уверенность сопоставления от 0 до 1
+	Method             string  `json:"method"`           // auto или manual
+	Reference          string  `json:"reference,omitempty"`
+	CreatedBy          string  `json:"created_by,omitempty"`
+	CreatedAt          string  `json:"created_at"`
+}
+
+// MatchReview описывает транзакцию в очереди ручного сопоставления с контрактами
+type MatchReview struct {
+	ID                 int              `json:"id"`
+	TransactionID      int              `json:"transaction_id"`
+	Date               string           `json:"date"`
+	DocumentNumber     string           `json:"document_number"`
+	Amount             float64          `json:"amount"`
+	PaymentDescription string           `json:"payment_description"`
+	Inn                string           `json:"inn,omitempty"`
+	Name               string           `json:"name,omitempty"`
+	InnC               string           `json:"inn_c,omitempty"`
+	NameC              string           `json:"name_c,omitempty"`
+	Reason             string           `json:"reason"`    // ambiguous, low_confidence или not_found
+	Reference          string           `json:"reference"` // Ссылки на контракт из назначения платежа
+	Status             string           `json:"status"`    // pending, linked или dismissed
+	Candidates         []MatchCandidate `json:"candidates"`
+	CreatedAt          string           `json:"created_at"`
+	ResolvedAt         string           `json:"resolved_at,omitempty"`
+	ResolvedBy         string           `json:"resolved_by,omitempty"`
+	ResolutionComment  string           `json:"resolution_comment,omitempty"`
+}
+
+// MatchCandidate описывает контракт, предложенный для сопоставления с транзакцией
+type MatchCandidate struct {
+	ContractID       string  `json:"contract_id"`
+	ContractNumber   string  `json:"contract_number"`
+	ContractDate     string  `json:"contract_date"`
+	CounterpartyName string  `json:"counterparty_name"`
+	Amount           float64 `json:"amount"`
+	Confidence       float64 `json:"confidence"`
+}
+
+// AllocationSet описывает версию распределения суммы транзакции по контрактам и заявкам на оплату
+type AllocationSet struct {
+	TransactionID     int                     `json:"transaction_id"`
+	TransactionAmount float64                 `json:"transaction_amount"`
+	Version           int                     `json:"version"` // 0 — транзакция не распределялась
+	Current           bool                    `json:"current"`
+	Comment           string                  `json:"comment,omitempty"`
+	CreatedBy         string                  `json:"created_by,omitempty"`
+	CreatedAt         string                  `json:"created_at,omitempty"`
+	Allocations       []TransactionAllocation `json:"allocations"`
+}
+
+// TransactionAllocation описывает часть суммы транзакции, отнесённую на контракт
+type TransactionAllocation struct {
+	ID                   int     `json:"id"`
+	ContractID           string  `json:"contract_id"`
+	ContractNumber       string  `json:"contract_number"`
+	ContractDate         string  `json:"contract_date"`
+	PaymentRequestID     int     `json:"payment_request_id,omitempty"`
+	PaymentRequestNumber string  `json:"payment_request_number,omitempty"` // Номер документа заявки на оплату
+	Amount               float64 `json:"amount"`
+	Comment              string  `json:"comment,omitempty"`
+}
@@ -0,0 +1,223 @@
+package paymentrequests
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"statements/internal/config"
+	"statements/internal/database"
+	"statements/internal/models"
+	"strings"
+)
+
+// Статусы согласования заявки
+const (
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+	StatusRejected = "rejected"
+)
+
+// Решения по этапу согласования
+const (
+	DecisionApprove = "approve"
+	DecisionReject  = "reject"
+)
+
+// currentStepCondition отбирает этап заявки r, ожидающий решения: первый по порядку этап без решения
+const currentStepCondition = `s.payment_request_id = r.id AND r.status = 'pending'
+	AND NOT EXISTS (SELECT 1 FROM payment_request_decisions d WHERE d.step_id = s.id)`
+
+var (
+	// ErrNotPending возвращается при попытке принять решение по заявке, согласование которой завершено
+	ErrNotPending = errors.New("заявка не находится на согласовании")
+	// ErrForbidden возвращается, если у пользователя нет роли, которой разрешено решение на текущем этапе
+	ErrForbidden = errors.New("решение на текущем этапе принимает пользователь с другой ролью")
+)
+
+// Decision — решение пользователя по текущему этапу согласования
+type Decision struct {
+	Decision string   // approve или reject
+	Comment  string   // Обязателен при отклонении
+	User     string   // Пользователь, принимающий решение
+	Roles    []string // Роли пользователя из JWT-токена
+}
+
+// Route фиксирует этапы согласования заявки. Если ни один этап не требуется, заявка сразу считается согласованной
+func Route(ctx context.Context, db database.DBTX, requestID int, steps []config.ApprovalStep) error {
+	for i, step := range steps {
+		_, err := db.ExecContext(ctx,
+			`INSERT INTO payment_request_steps (payment_request_id, step_order, code, title, role)
+			VALUES ($1, $2, $3, $4, NULLIF($5, ''))`,
+			requestID, i+1, step.Code, step.Title, step.Role)
+		if err != nil {
+			return fmt.Errorf("ошибка сохранения этапа %s заявки %d: %w", step.Code, requestID, err)
+		}
+	}
+	if len(steps) == 0 {
+		return setStatus(ctx, db, requestID, StatusApproved)
+	}
+	return nil
+}
+
+// Approval возвращает статус заявки, этапы согласования с принятыми решениями и этап, ожидающий решения
+func Approval(ctx context.Context, db database.DBTX, requestID int) (models.PaymentRequestApproval, error) {
+	approval := models.PaymentRequestApproval{RequestID: requestID}
+	err := db.QueryRowContext(ctx, `SELECT status FROM payment_requests WHERE id = $1`, requestID).Scan(&approval.Status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return approval, ErrNotFound
+	}
+	if err != nil {
+		return approval, fmt.Errorf("ошибка получения статуса заявки %d: %w", requestID, err)
+	}
+
+	approval.Steps, err = steps(ctx, db, requestID)
+	if err != nil {
+		return approval, err
+	}
+	if approval.Status == StatusPending {
+		approval.CurrentStep = currentStep(approval.Steps)
+	}
+	return approval, nil
+}
+
+// Decide записывает решение по текущему этапу согласования и пересчитывает статус заявки:
+// отклонение на любом этапе завершает согласование, одобрение последнего этапа согласует заявку.
+// Заявки, созданные до появления цепочки, получают этапы по конфигурации steps.
+// Заявка блокируется до конца транзакции, поэтому вызывать нужно в транзакции.
+// Ошибки содержимого решения возвращаются как *ValidationError
+func Decide(ctx context.Context, db database.DBTX, requestID int, d Decision, chain config.ApprovalConfig) (models.PaymentRequestApproval, error) {
+	verr := &ValidationError{}
+	d.Comment = strings.TrimSpace(d.Comment)
+	switch d.Decision {
+	case DecisionApprove:
+	case DecisionReject:
+		if d.Comment == "" {
+			verr.Add("comment", "Укажите причину отклонения")
+		}
+	default:
+		verr.Add("decision", "Решение должно быть approve или reject")
+	}
+	if len([]rune(d.Comment)) > 2000 {
+		verr.Add("comment", "Не более 2000 символов")
+	}
+	if err := verr.Err(); err != nil {
+		return models.PaymentRequestApproval{}, err
+	}
+
+	var status string
+	var amount float64
+	err := db.QueryRowContext(ctx,
+		`SELECT status, amount::float8 FROM payment_requests WHERE id = $1 FOR UPDATE`, requestID).Scan(&status, &amount)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.PaymentRequestApproval{}, ErrNotFound
+	}
+	if err != nil {
+		return models.PaymentRequestApproval{}, fmt.Errorf("ошибка блокировки заявки %d: %w", requestID, err)
+	}
+	if status != StatusPending {
+		return models.PaymentRequestApproval{}, ErrNotPending
+	}
+
+	requestSteps, err := steps(ctx, db, requestID)
+	if err != nil {
+		return models.PaymentRequestApproval{}, err
+	}
+	if len(requestSteps) == 0 {
+		if err := Route(ctx, db, requestID, chain.StepsFor(amount)); err != nil {
+			return models.PaymentRequestApproval{}, err
+		}
+		if requestSteps, err = steps(ctx, db, requestID); err != nil {
+			return models.PaymentRequestApproval{}, err
+		}
+	}
+
+	step := currentStep(requestSteps)
+	if step == nil {
+		return models.PaymentRequestApproval{}, ErrNotPending
+	}
+	if step.Role != "" && !slices.Contains(d.Roles, step.Role) {
+		return models.PaymentRequestApproval{}, ErrForbidden
+	}
+
+	_, err = db.ExecContext(ctx,
+		`INSERT INTO payment_request_decisions (payment_request_id, step_id, decision, comment, decided_by)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5)`,
+		requestID, step.ID, d.Decision, d.Comment, d.User)
+	if err != nil {
+		return models.PaymentRequestApproval{}, fmt.Errorf("ошибка записи решения по заявке %d: %w", requestID, err)
+	}
+
+	switch {
+	case d.Decision == DecisionReject:
+		status = StatusRejected
+	case step.Order == requestSteps[len(requestSteps)-1].Order:
+		status = StatusApproved
+	}
+	if err := setStatus(ctx, db, requestID, status); err != nil {
+		return models.PaymentRequestApproval{}, err
+	}
+
+	return Approval(ctx, db, requestID)
+}
+
+// steps возвращает этапы согласования заявки по порядку вместе с решениями
+func steps(ctx context.Context, db database.DBTX, requestID int) ([]models.ApprovalStep, error) {
+	rows, err := db.QueryContext(ctx,
+		`SELECT s.id, s.step_order, s.code, s.title, COALESCE(s.role, ''),
+			d.id, d.decision, COALESCE(d.comment, ''), d.decided_by, d.decided_at::text
+		FROM payment_request_steps s
+		LEFT JOIN payment_request_decisions d ON d.step_id = s.id
+		WHERE s.payment_request_id = $1
+		ORDER BY s.step_order`, requestID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения этапов согласования заявки %d: %w", requestID, err)
+	}
+	defer rows.Close()
+
+	items := make([]models.ApprovalStep, 0)
+	for rows.Next() {
+		var step models.ApprovalStep
+		var decisionID sql.NullInt64
+		var decision, comment, decidedBy, decidedAt sql.NullString
+		if err := rows.Scan(&step.ID, &step.Order, &step.Code, &step.Title, &step.Role,
+			&decisionID, &decision, &comment, &decidedBy, &decidedAt); err != nil {
+			return nil, fmt.Errorf("ошибка чтения этапа согласования: %w", err)
+		}
+		if decisionID.Valid {
+			step.Decision = &models.ApprovalDecision{
+				ID:        int(decisionID.Int64),
+				Decision:  decision.String,
+				Comment:   comment.String,
+				DecidedBy: decidedBy.String,
+				DecidedAt: decidedAt.String,
+			}
+		}
+		items = append(items, step)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения этапов согласования заявки %d: %w", requestID, err)
+	}
+	return items, nil
+}
+
+// currentStep возвращает первый этап без решения или nil, если решения приняты по всем этапам
+func currentStep(steps []models.ApprovalStep) *models.ApprovalStep {
+	for i := range steps {
+		if steps[i].Decision == nil {
+			return &steps[i]
+		}
+	}
+	return nil
+}
+
+// setStatus обновляет статус согласования заявки
+func setStatus(ctx context.Context, db database.DBTX, requestID int, status string) error {
+	_, err := db.ExecContext(ctx,
+		`UPDATE payment_requests SET status = $2, updated_at = now() WHERE id = $1`, requestID, status)
+	if err != nil {
+		return fmt.Errorf("ошибка обновления статуса заявки %d: %w", requestID, err)
+	}
+	return nil
+}
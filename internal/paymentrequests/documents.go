@@ -0,0 +1,78 @@
+package paymentrequests
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"statements/internal/database"
+	"statements/internal/models"
+)
+
+// documentColumns — список колонок файла заявки в порядке, ожидаемом scanDocument
+const documentColumns = `d.id, d.document_type, d.storage_key,
+	COALESCE(f.original_name, regexp_replace(d.storage_key, '^.*/', '')), COALESCE(f.content_type, ''),
+	COALESCE(f.size, 0), COALESCE(d.sha256, ''), COALESCE(d.uploaded_by, ''), d.uploaded_at::text`
+
+// AddDocument привязывает к заявке сохранённый файл. Метаданные файла должны быть уже записаны в stored_files
+func AddDocument(ctx context.Context, db database.DBTX, requestID int, documentType string, f models.StoredFile) error {
+	_, err := db.ExecContext(ctx,
+		`INSERT INTO payment_request_documents (payment_request_id, document_type, storage_key, sha256, uploaded_by)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''))`,
+		requestID, documentType, f.Key, f.SHA256, f.UploadedBy)
+	if err != nil {
+		return fmt.Errorf("ошибка добавления документа к заявке %d: %w", requestID, err)
+	}
+	return nil
+}
+
+// Documents возвращает файлы заявки: сначала документ на оплату, затем дополнительные
+func Documents(ctx context.Context, db database.DBTX, requestID int) ([]models.PaymentRequestDocument, error) {
+	rows, err := db.QueryContext(ctx,
+		`SELECT `+documentColumns+`
+		FROM payment_request_documents d
+		LEFT JOIN stored_files f ON f.storage_key = d.storage_key
+		WHERE d.payment_request_id = $1
+		ORDER BY d.document_type = 'supporting', d.id`, requestID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения документов заявки %d: %w", requestID, err)
+	}
+	defer rows.Close()
+
+	docs := make([]models.PaymentRequestDocument, 0)
+	for rows.Next() {
+		doc, err := scanDocument(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения документа заявки: %w", err)
+		}
+		docs = append(docs, doc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения документов заявки %d: %w", requestID, err)
+	}
+	return docs, nil
+}
+
+// Document возвращает файл заявки по идентификатору
+func Document(ctx context.Context, db database.DBTX, requestID, documentID int) (models.PaymentRequestDocument, error) {
+	doc, err := scanDocument(db.QueryRowContext(ctx,
+		`SELECT `+documentColumns+`
+		FROM payment_request_documents d
+		LEFT JOIN stored_files f ON f.storage_key = d.storage_key
+		WHERE d.payment_request_id = $1 AND d.id = $2`, requestID, documentID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return doc, ErrDocumentNotFound
+	}
+	if err != nil {
+		return doc, fmt.Errorf("ошибка получения документа %d заявки %d: %w", documentID, requestID, err)
+	}
+	return doc, nil
+}
+
+// scanDocument читает файл заявки из строки, выбранной по documentColumns
+func scanDocument(row database.RowScanner) (models.PaymentRequestDocument, error) {
+	var doc models.PaymentRequestDocument
+	err := row.Scan(&doc.ID, &doc.Type, &doc.Key, &doc.FileName, &doc.ContentType, &doc.Size,
+		&doc.SHA256, &doc.UploadedBy, &doc.UploadedAt)
+	return doc, err
+}
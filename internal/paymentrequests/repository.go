@@ -0,0 +1,206 @@
+package paymentrequests
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"statements/internal/database"
+	"statements/internal/models"
+	"strings"
+)
+
+// requestColumns — список колонок заявки в порядке, ожидаемом scanRequest
+const requestColumns = `r.id, r.contract_uid::text, c.contract_number, c.contract_date::text,
+	r.counterparty_id, cp.name, r.amount::float8, r.document_type, r.document_number, r.document_date::text,
+	r.payment_purpose, COALESCE(r.address, ''), COALESCE(r.created_by, ''), r.created_at::text, r.updated_at::text, r.status,
+	COALESCE((SELECT s.title FROM payment_request_steps s WHERE ` + currentStepCondition + ` ORDER BY s.step_order LIMIT 1), ''),
+	COALESCE((SELECT SUM(a.amount) FROM transaction_allocations a
+		JOIN transaction_allocation_sets s ON s.id = a.set_id AND s.is_current
+		WHERE a.payment_request_id = r.id), 0)::float8`
+
+// requestFrom — источник выборки заявок с реквизитами договора и наименованием контрагента
+const requestFrom = `FROM payment_requests r
+	JOIN contracts c ON c.uid = r.contract_uid
+	JOIN counterparties cp ON cp.id = r.counterparty_id
+	WHERE TRUE`
+
+var (
+	// ErrNotFound возвращается, если заявка не найдена
+	ErrNotFound = errors.New("заявка на оплату не найдена")
+	// ErrDuplicate возвращается, если документ контрагента уже предъявлен к оплате
+	ErrDuplicate = errors.New("заявка по этому документу уже существует")
+	// ErrDocumentNotFound возвращается, если у заявки нет файла с указанным идентификатором
+	ErrDocumentNotFound = errors.New("документ заявки не найден")
+)
+
+// ListFilter описывает параметры отбора и постраничного вывода заявок
+type ListFilter struct {
+	ContractID     string
+	CounterpartyID int
+	DateFrom       string // Дата документа не раньше, ГГГГ-ММ-ДД
+	DateTo         string // Дата документа не позже, ГГГГ-ММ-ДД
+	Query          string // Часть номера документа на оплату
+	Status         string // Статус согласования
+	AwaitingStep   string // Код этапа, на котором заявка ожидает решения
+	Limit          int
+	Offset         int
+}
+
+// balance — сумма договора и сумма заявок по нему с учётом новой заявки
+type balance struct {
+	ContractAmount float64
+	Requested      float64 // Сумма уже поданных и не отклонённых заявок
+	Exceeded       bool    // Новая заявка превысит сумму договора
+}
+
+// addTo дописывает в verr ошибку суммы, если лимит договора превышен
+func (b balance) addTo(verr *ValidationError) {
+	if b.Exceeded {
+		verr.Add("amount", fmt.Sprintf("Сумма заявок превысит сумму договора %.2f: уже заявлено %.2f, доступно %.2f",
+			b.ContractAmount, b.Requested, max(b.ContractAmount-b.Requested, 0)))
+	}
+}
+
+// List возвращает страницу заявок по фильтру и общее количество найденных записей
+func List(ctx context.Context, db database.DBTX, filter ListFilter) ([]models.PaymentRequest, int, error) {
+	where := ""
+	args := []interface{}{}
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		where += fmt.Sprintf(" AND "+condition, len(args))
+	}
+	if filter.ContractID != "" {
+		addCondition("r.contract_uid::text = $%d", filter.ContractID)
+	}
+	if filter.CounterpartyID > 0 {
+		addCondition("r.counterparty_id = $%d", filter.CounterpartyID)
+	}
+	if filter.DateFrom != "" {
+		addCondition("r.document_date >= $%d::date", filter.DateFrom)
+	}
+	if filter.DateTo != "" {
+		addCondition("r.document_date <= $%d::date", filter.DateTo)
+	}
+	if q := strings.TrimSpace(filter.Query); q != "" {
+		addCondition("r.document_number ILIKE '%%' || $%d || '%%'", database.EscapeLike(q))
+	}
+	if filter.Status != "" {
+		addCondition("r.status = $%d", filter.Status)
+	}
+	if filter.AwaitingStep != "" {
+		addCondition("(SELECT s.code FROM payment_request_steps s WHERE "+currentStepCondition+
+			" ORDER BY s.step_order LIMIT 1) = $%d", filter.AwaitingStep)
+	}
+
+	var total int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) "+requestFrom+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("ошибка подсчёта заявок на оплату: %w", err)
+	}
+
+	query := fmt.Sprintf(
+		`SELECT %s
+		%s%s
+		ORDER BY r.document_date DESC, r.id DESC
+		LIMIT $%d OFFSET $%d`,
+		requestColumns, requestFrom, where, len(args)+1, len(args)+2)
+	rows, err := db.QueryContext(ctx, query, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ошибка получения списка заявок на оплату: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]models.PaymentRequest, 0)
+	for rows.Next() {
+		pr, err := scanRequest(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("ошибка чтения заявки на оплату: %w", err)
+		}
+		items = append(items, pr)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("ошибка чтения списка заявок на оплату: %w", err)
+	}
+
+	return items, total, nil
+}
+
+// Get возвращает заявку вместе с приложенными файлами
+func Get(ctx context.Context, db database.DBTX, id int) (models.PaymentRequest, error) {
+	pr, err := scanRequest(db.QueryRowContext(ctx, "SELECT "+requestColumns+" "+requestFrom+" AND r.id = $1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return pr, ErrNotFound
+	}
+	if err != nil {
+		return pr, fmt.Errorf("ошибка получения заявки на оплату %d: %w", id, err)
+	}
+
+	pr.Documents, err = Documents(ctx, db, id)
+	if err != nil {
+		return pr, err
+	}
+	return pr, nil
+}
+
+// Create сохраняет заявку и возвращает её идентификатор. Договор блокируется до конца транзакции,
+// чтобы параллельные заявки не превысили его сумму, поэтому вызывать нужно в транзакции.
+// Превышение суммы договора возвращается как *ValidationError по полю amount
+func Create(ctx context.Context, db database.DBTX, pr models.PaymentRequest) (int, error) {
+	if _, err := db.ExecContext(ctx,
+		`SELECT 1 FROM contracts WHERE uid = $1 AND deleted_at IS NULL FOR UPDATE`, pr.ContractID); err != nil {
+		return 0, fmt.Errorf("ошибка блокировки договора %s: %w", pr.ContractID, err)
+	}
+	b, err := contractBalance(ctx, db, pr.ContractID, pr.Amount)
+	if err != nil {
+		return 0, err
+	}
+	if b.Exceeded {
+		verr := &ValidationError{}
+		b.addTo(verr)
+		return 0, verr
+	}
+
+	var id int
+	err = db.QueryRowContext(ctx,
+		`INSERT INTO payment_requests
+			(contract_uid, counterparty_id, amount, document_type, document_number, document_date,
+			 payment_purpose, address, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), NULLIF($9, ''))
+		RETURNING id`,
+		pr.ContractID, pr.CounterpartyID, pr.Amount, strings.TrimSpace(pr.DocumentType), strings.TrimSpace(pr.DocumentNumber),
+		pr.DocumentDate, strings.TrimSpace(pr.PaymentPurpose), strings.TrimSpace(pr.Address), pr.CreatedBy).Scan(&id)
+	if database.IsUniqueViolation(err) {
+		return 0, ErrDuplicate
+	}
+	if err != nil {
+		return 0, fmt.Errorf("ошибка создания заявки на оплату: %w", err)
+	}
+	return id, nil
+}
+
+// contractBalance возвращает действующую сумму договора с учётом дополнительных соглашений и сумму неотклонённых
+// заявок по нему. Сравнение выполняется в NUMERIC, чтобы погрешность float64 не влияла на проверку лимита
+func contractBalance(ctx context.Context, db database.DBTX, contractID string, amount float64) (balance, error) {
+	var b balance
+	err := db.QueryRowContext(ctx,
+		`SELECT COALESCE(tm.amount, c.amount)::float8, COALESCE(SUM(r.amount), 0)::float8,
+		        COALESCE(SUM(r.amount), 0) + $2::numeric > COALESCE(tm.amount, c.amount)
+		FROM contracts c
+		CROSS JOIN LATERAL contract_amended_terms(c.uid, CURRENT_DATE) tm
+		LEFT JOIN payment_requests r ON r.contract_uid = c.uid AND r.status <> 'rejected'
+		WHERE c.uid = $1 AND c.deleted_at IS NULL
+		GROUP BY c.amount, tm.amount`, contractID, amount).Scan(&b.ContractAmount, &b.Requested, &b.Exceeded)
+	if err != nil {
+		return b, fmt.Errorf("ошибка расчёта остатка по договору %s: %w", contractID, err)
+	}
+	return b, nil
+}
+
+// scanRequest читает заявку из строки, выбранной по requestColumns
+func scanRequest(row database.RowScanner) (models.PaymentRequest, error) {
+	var pr models.PaymentRequest
+	err := row.Scan(&pr.ID, &pr.ContractID, &pr.ContractNumber, &pr.ContractDate,
+		&pr.CounterpartyID, &pr.CounterpartyName, &pr.Amount, &pr.DocumentType, &pr.DocumentNumber, &pr.DocumentDate,
+		&pr.PaymentPurpose, &pr.Address, &pr.CreatedBy, &pr.CreatedAt, &pr.UpdatedAt, &pr.Status, &pr.CurrentStep, &pr.Paid)
+	return pr, err
+}
@@ -0,0 +1,134 @@
+package paymentrequests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"statements/internal/contracts"
+	"statements/internal/database"
+	"statements/internal/models"
+	"strings"
+	"time"
+)
+
+const (
+	// maxAmount — граница суммы, которую вмещает колонка amount NUMERIC(15, 2)
+	maxAmount = 1e13
+	// maxPaymentPurposeLength — назначение платежа в платёжном поручении ограничено 210 символами
+	maxPaymentPurposeLength = 210
+)
+
+// ValidationError содержит ошибки по отдельным полям заявки
+type ValidationError struct {
+	database.FieldErrors
+}
+
+func (e *ValidationError) Error() string {
+	return "некорректные данные заявки на оплату: " + e.Describe()
+}
+
+// Err возвращает nil, если ошибок нет, иначе саму ошибку
+func (e *ValidationError) Err() error {
+	if e.Empty() {
+		return nil
+	}
+	return e
+}
+
+// Validate проверяет обязательные поля, длины и форматы дат заявки.
+// Возвращает *ValidationError со всеми найденными ошибками
+func Validate(pr models.PaymentRequest) error {
+	verr := &ValidationError{}
+	validateInto(verr, pr)
+	return verr.Err()
+}
+
+// validateInto дописывает в verr ошибки полей заявки
+func validateInto(verr *ValidationError, pr models.PaymentRequest) {
+	if pr.CounterpartyID <= 0 {
+		verr.Add("counterparty_id", "Выберите контрагента")
+	}
+	switch {
+	case math.IsNaN(pr.Amount) || pr.Amount <= 0:
+		verr.Add("amount", "Сумма должна быть больше нуля")
+	case pr.Amount >= maxAmount:
+		verr.Add("amount", "Слишком большая сумма")
+	}
+	checkText(verr, "document_type", pr.DocumentType, "Укажите тип документа на оплату", 100)
+	checkText(verr, "document_number", pr.DocumentNumber, "Укажите номер документа", 50)
+	checkText(verr, "payment_purpose", pr.PaymentPurpose, "Укажите назначение платежа", maxPaymentPurposeLength)
+	checkText(verr, "contract_number", pr.ContractNumber, "Укажите номер договора", 50)
+
+	if documentDate, ok := parseDateField(verr, "document_date", pr.DocumentDate, "Укажите дату документа"); ok && documentDate.After(time.Now()) {
+		verr.Add("document_date", "Дата документа не может быть в будущем")
+	}
+	parseDateField(verr, "contract_date", pr.ContractDate, "Укажите дату договора")
+}
+
+// ValidateReferences проверяет поля заявки и её соответствие договору: договор существует, контрагент совпадает,
+// а общая сумма заявок по договору не превышает его сумму. contractAmount — сумма договора из формы, если указана.
+// При успехе заполняет pr.ContractID
+func ValidateReferences(ctx context.Context, db database.DBTX, pr *models.PaymentRequest, contractAmount *float64) error {
+	verr := &ValidationError{}
+	validateInto(verr, *pr)
+	if _, ok := verr.Fields["contract_number"]; ok {
+		return verr.Err()
+	}
+	if _, ok := verr.Fields["contract_date"]; ok {
+		return verr.Err()
+	}
+
+	ct, err := contracts.FindByNumber(ctx, db, pr.ContractNumber, pr.ContractDate)
+	if errors.Is(err, contracts.ErrNotFound) {
+		verr.Add("contract_number", "Договор с таким номером и датой не найден")
+		return verr.Err()
+	}
+	if err != nil {
+		return err
+	}
+	pr.ContractID = ct.ID
+
+	if pr.CounterpartyID > 0 && pr.CounterpartyID != ct.CounterpartyID {
+		verr.Add("counterparty_id", fmt.Sprintf("Договор заключён с другим контрагентом: %s", ct.CounterpartyName))
+	}
+	if contractAmount != nil && math.Abs(*contractAmount-ct.Amount) >= 0.005 {
+		verr.Add("contract_amount", fmt.Sprintf("Сумма договора не совпадает с реестром: %.2f", ct.Amount))
+	}
+	if pr.DocumentDate != "" && pr.DocumentDate < ct.ContractDate {
+		verr.Add("document_date", "Дата документа раньше даты договора")
+	}
+
+	if _, ok := verr.Fields["amount"]; !ok {
+		balance, err := contractBalance(ctx, db, ct.ID, pr.Amount)
+		if err != nil {
+			return err
+		}
+		balance.addTo(verr)
+	}
+	return verr.Err()
+}
+
+// checkText проверяет обязательное текстовое поле и его длину в символах
+func checkText(verr *ValidationError, field, value, requiredMessage string, maxLength int) {
+	switch value = strings.TrimSpace(value); {
+	case value == "":
+		verr.Add(field, requiredMessage)
+	case len([]rune(value)) > maxLength:
+		verr.Add(field, fmt.Sprintf("Не более %d символов", maxLength))
+	}
+}
+
+// parseDateField проверяет обязательную дату в формате ГГГГ-ММ-ДД
+func parseDateField(verr *ValidationError, field, value, requiredMessage string) (time.Time, bool) {
+	if value == "" {
+		verr.Add(field, requiredMessage)
+		return time.Time{}, false
+	}
+	date, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		verr.Add(field, "Дата должна быть в формате ГГГГ-ММ-ДД")
+		return time.Time{}, false
+	}
+	return date, true
+}
@@ -0,0 +1,321 @@
+package registry
+
+import (
+	"context"
+	"database/sql"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"statements/internal/contracts"
+	"statements/internal/counterparties"
+	"statements/internal/models"
+	"statements/internal/utils"
+	"strings"
+	"time"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// ErrNoEISContracts возвращается, если в файле XML нет ни одной карточки контракта
+var ErrNoEISContracts = errors.New("в файле нет сведений о контрактах 44-ФЗ/223-ФЗ")
+
+// Имена элементов карточек контрактов ЕИС. В схемах 44-ФЗ и 223-ФЗ и в разных их версиях одни и те же
+// сведения называются по-разному, поэтому для каждого поля перечислены все известные варианты
+var (
+	eisContractElements  = []string{"contract", "fcsContract"}
+	eisRegNumElements    = []string{"regNum", "registrationNumber", "contractRegNum"}
+	eisNumberElements    = []string{"number", "contractNumber"}
+	eisSignDateElements  = []string{"signDate", "contractDate"}
+	eisPriceElements     = []string{"price", "sum", "contractPrice"}
+	eisSubjectElements   = []string{"contractSubject", "subjectContract", "subject"}
+	eisEndDateElements   = []string{"executionEndDate", "fulfilmentDate", "fulfillmentDate"}
+	eisSupplierElements  = []string{"supplier", "supplierInfo"}
+	eisStageElements     = []string{"stage", "stages", "contractStage"}
+	eisStageDateElements = []string{"endDate", "paymentDate", "stageEndDate", "planDate"}
+	eisStageSumElements  = []string{"stagePrice", "price", "paymentSum", "stageSum", "sum"}
+
+	// eisDetailElements — вложенные сведения карточки, в которых встречаются одноимённые реквизитам контракта элементы:
+	// цены позиций и этапов, номера документов и гарантий, реестровые номера заказчика. Реквизиты контракта в них не ищутся
+	eisDetailElements = []string{
+		"products", "product", "contractPositions", "contractPosition", "stages", "stage", "contractStage",
+		"finances", "enforcement", "bankGuarantee", "executionObligationGuarantee", "guaranteeReturns",
+		"attachments", "attachment", "documentInfo", "modification", "foundation",
+		"customer", "placer", "suppliers", "supplier", "supplierInfo",
+	}
+)
+
+// EISContract — сведения карточки контракта из ЕИС (zakupki.gov.ru) по 44-ФЗ или 223-ФЗ
+type EISContract struct {
+	RegistryNumber  string
+	Number          string
+	SignDate        string // ГГГГ-ММ-ДД
+	ExecutionPeriod string // Дата окончания исполнения, ГГГГ-ММ-ДД
+	Subject         string
+	Price           string // Как в файле, разбирается при загрузке
+	Supplier        models.Counterparty
+	Stages          []EISStage
+}
+
+// EISStage — этап исполнения контракта из карточки ЕИС
+type EISStage struct {
+	Title   string
+	EndDate string
+	Amount  string
+}
+
+// xmlNode — элемент XML с текстом и вложенными элементами; пространства имён не учитываются
+type xmlNode struct {
+	name     string
+	text     string
+	children []*xmlNode
+}
+
+// ParseEIS читает карточки контрактов из XML, выгруженного из ЕИС. Поддерживаются кодировки UTF-8 и windows-1251
+func ParseEIS(r io.Reader) ([]EISContract, error) {
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = charsetReader
+
+	var result []EISContract
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения XML: %w", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || !nameIn(start.Name.Local, eisContractElements) {
+			continue
+		}
+		node, err := readNode(decoder, start)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка разбора контракта: %w", err)
+		}
+		result = append(result, node.eisContract())
+	}
+
+	if len(result) == 0 {
+		return nil, ErrNoEISContracts
+	}
+	return result, nil
+}
+
+// eisContract извлекает сведения карточки контракта. В 223-ФЗ сведения вложены в элемент contractData
+func (n *xmlNode) eisContract() EISContract {
+	if data := n.find("contractData"); data != nil {
+		n = data
+	}
+	ct := EISContract{
+		RegistryNumber: n.contractValue(eisRegNumElements...),
+		Number:         n.contractValue(eisNumberElements...),
+		SignDate:       xmlDate(n.contractValue(eisSignDateElements...)),
+		Subject:        n.contractValue(eisSubjectElements...),
+		Price:          n.contractValue(eisPriceElements...),
+	}
+	if period := n.findOutside(eisDetailElements, "executionPeriod"); period != nil {
+		ct.ExecutionPeriod = xmlDate(period.childValue("endDate"))
+	}
+	if ct.ExecutionPeriod == "" {
+		ct.ExecutionPeriod = xmlDate(n.contractValue(eisEndDateElements...))
+	}
+
+	if supplier := n.find(eisSupplierElements...); supplier != nil {
+		ct.Supplier = models.Counterparty{
+			Inn:       supplier.value("INN", "inn"),
+			Kpp:       supplier.value("KPP", "kpp"),
+			FullName:  supplier.value("fullName", "organizationName", "name"),
+			ShortName: supplier.value("shortName"),
+		}
+		if ct.Supplier.FullName == "" {
+			// Индивидуальный предприниматель или физическое лицо
+			ct.Supplier.FullName = utils.JoinNonEmpty(" ",
+				supplier.value("lastName"), supplier.value("firstName"), supplier.value("middleName"))
+		}
+		ct.Supplier.Name = utils.FirstNonEmpty(ct.Supplier.ShortName, ct.Supplier.FullName)
+	}
+
+	n.walk(func(stage *xmlNode) {
+		if !nameIn(stage.name, eisStageElements) || stage.find(eisStageElements...) != nil {
+			return
+		}
+		s := EISStage{
+			Title:   stage.value("name", "stageName", "title"),
+			EndDate: xmlDate(stage.value(eisStageDateElements...)),
+			Amount:  stage.value(eisStageSumElements...),
+		}
+		if s.EndDate != "" && s.Amount != "" {
+			ct.Stages = append(ct.Stages, s)
+		}
+	})
+	return ct
+}
+
+// ImportEIS загружает карточки контрактов ЕИС так же, как строки реестра: контракт ищется по реестровому номеру,
+// затем по номеру и дате, поставщик — по ИНН. Этапы исполнения заменяют график платежей контракта
+func ImportEIS(ctx context.Context, db *sql.DB, file string, items []EISContract, opts Options) (Report, error) {
+	entries := make([]entry, 0, len(items))
+	for i, item := range items {
+		verr := &contracts.ValidationError{}
+		ct := models.Contract{
+			ContractNumber:      utils.FirstNonEmpty(item.Number, item.RegistryNumber),
+			ContractDate:        item.SignDate,
+			ExecutionPeriod:     item.ExecutionPeriod,
+			EaistRegistryNumber: item.RegistryNumber,
+			Subject:             item.Subject,
+			ContractType:        strings.TrimSpace(opts.ContractType),
+			Amount:              parseAmount(verr, item.Price),
+		}
+
+		supplier := item.Supplier
+		supplier.Name = counterparties.NormalizeName(utils.FirstNonEmpty(supplier.Name, supplier.Inn))
+		if supplier.Inn == "" {
+			verr.Add(FieldSupplierInn, "Не указан ИНН поставщика")
+		} else if err := counterparties.Validate(supplier); err != nil {
+			verr.Add(FieldSupplierInn, err.Error())
+		}
+
+		var stages []models.PaymentStage
+		for j, s := range item.Stages {
+			amount, ok := parseNumber(s.Amount)
+			if !ok {
+				verr.Add(fmt.Sprintf("stages[%d].amount", j), fmt.Sprintf("Не удалось распознать сумму этапа %q", s.Amount))
+			}
+			stages = append(stages, models.PaymentStage{Number: j + 1, Title: s.Title, PlannedDate: s.EndDate, Amount: amount})
+		}
+
+		validateImported(verr, ct)
+		entries = append(entries, entry{line: i + 1, contract: ct, supplier: supplier, stages: stages, errors: verr.Fields})
+	}
+	return importEntries(ctx, db, file, entries, opts)
+}
+
+// readNode читает элемент start со всем содержимым
+func readNode(decoder *xml.Decoder, start xml.StartElement) (*xmlNode, error) {
+	node := &xmlNode{name: start.Name.Local}
+	var text strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			child, err := readNode(decoder, t)
+			if err != nil {
+				return nil, err
+			}
+			node.children = append(node.children, child)
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			node.text = strings.TrimSpace(text.String())
+			return node, nil
+		}
+	}
+}
+
+// find возвращает ближайший к n вложенный элемент с одним из имён: поиск идёт по уровням вложенности,
+// поэтому, например, цена контракта находится раньше цен этапов и позиций
+func (n *xmlNode) find(names ...string) *xmlNode {
+	return n.findOutside(nil, names...)
+}
+
+// findOutside ищет элемент как find, не заходя в элементы с именами из skip
+func (n *xmlNode) findOutside(skip []string, names ...string) *xmlNode {
+	level := n.children
+	for len(level) > 0 {
+		var next []*xmlNode
+		for _, child := range level {
+			if nameIn(child.name, names) {
+				return child
+			}
+			if !nameIn(child.name, skip) {
+				next = append(next, child.children...)
+			}
+		}
+		level = next
+	}
+	return nil
+}
+
+// value возвращает текст ближайшего вложенного элемента без потомков с одним из имён
+func (n *xmlNode) value(names ...string) string {
+	return n.valueOutside(nil, names...)
+}
+
+// contractValue возвращает реквизит контракта: текст ближайшего элемента с одним из имён вне eisDetailElements
+func (n *xmlNode) contractValue(names ...string) string {
+	return n.valueOutside(eisDetailElements, names...)
+}
+
+// valueOutside возвращает текст ближайшего вложенного элемента без потомков с одним из имён,
+// не заходя в элементы с именами из skip
+func (n *xmlNode) valueOutside(skip []string, names ...string) string {
+	level := n.children
+	for len(level) > 0 {
+		var next []*xmlNode
+		for _, child := range level {
+			if len(child.children) == 0 && child.text != "" && nameIn(child.name, names) {
+				return child.text
+			}
+			if !nameIn(child.name, skip) {
+				next = append(next, child.children...)
+			}
+		}
+		level = next
+	}
+	return ""
+}
+
+// childValue возвращает текст непосредственно вложенного элемента с одним из имён
+func (n *xmlNode) childValue(names ...string) string {
+	for _, child := range n.children {
+		if child.text != "" && nameIn(child.name, names) {
+			return child.text
+		}
+	}
+	return ""
+}
+
+// walk обходит все вложенные элементы n в порядке документа
+func (n *xmlNode) walk(fn func(*xmlNode)) {
+	for _, child := range n.children {
+		fn(child)
+		child.walk(fn)
+	}
+}
+
+// xmlDate приводит дату XML (в том числе с часовым поясом или временем) к формату ГГГГ-ММ-ДД.
+// Нераспознанное значение возвращается как есть, чтобы ошибку показала проверка контракта
+func xmlDate(value string) string {
+	if len(value) >= 10 {
+		if t, err := time.Parse(time.DateOnly, value[:10]); err == nil {
+			return t.Format(time.DateOnly)
+		}
+	}
+	return value
+}
+
+// nameIn сообщает, входит ли имя элемента в список
+func nameIn(name string, names []string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// charsetReader поддерживает кодировку windows-1251, встречающуюся в старых выгрузках ЕИС
+func charsetReader(label string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(label) {
+	case "windows-1251", "cp1251":
+		return charmap.Windows1251.NewDecoder().Reader(input), nil
+	case "utf-8", "utf8":
+		return input, nil
+	}
+	return nil, fmt.Errorf("неподдерживаемая кодировка XML: %s", label)
+}
@@ -0,0 +1,116 @@
+package registry
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"statements/internal/models"
+	"strings"
+	"testing"
+)
+
+func TestParseEIS(t *testing.T) {
+	tests := []struct {
+		file string
+		want []EISContract
+	}{
+		// Номер и цена контракта вложены в commonInfo, а раньше по документу идут цены позиций,
+		// номер извещения, реестровый номер заказчика и номер банковской гарантии
+		{"fcsContract_44fz.xml", []EISContract{
+			{
+				RegistryNumber:  "2770100000024000001",
+				Number:          "12/3-ЭА",
+				SignDate:        "2024-02-01",
+				ExecutionPeriod: "2024-12-31",
+				Subject:         "Поставка бумаги для офисной техники",
+				Price:           "150000.50",
+				Supplier: models.Counterparty{
+					Name:      `ООО "РОМАШКА"`,
+					Inn:       "7701234567",
+					Kpp:       "770101001",
+					FullName:  `ОБЩЕСТВО С ОГРАНИЧЕННОЙ ОТВЕТСТВЕННОСТЬЮ "РОМАШКА"`,
+					ShortName: `ООО "РОМАШКА"`,
+				},
+				// Этап без суммы не загружается
+				Stages: []EISStage{
+					{Title: "Первая поставка", EndDate: "2024-03-31", Amount: "100000.00"},
+					{Title: "Вторая поставка", EndDate: "2024-06-30", Amount: "50000.50"},
+				},
+			},
+			{
+				RegistryNumber:  "2770100000024000002",
+				SignDate:        "2024-03-15",
+				ExecutionPeriod: "2024-04-30",
+				Price:           "9999.99",
+				Supplier: models.Counterparty{
+					Name:     "Семёнов Семён Семёнович",
+					Inn:      "771234567890",
+					FullName: "Семёнов Семён Семёнович",
+				},
+			},
+		}},
+		{"contractData_223fz_cp1251.xml", []EISContract{{
+			RegistryNumber:  "57701234567240000030000",
+			SignDate:        "2024-04-10",
+			ExecutionPeriod: "2024-10-31",
+			Subject:         "Выполнение работ по ремонту кровли",
+			Price:           "2500000.00",
+			Supplier: models.Counterparty{
+				Name:     `АКЦИОНЕРНОЕ ОБЩЕСТВО "ВАСИЛЁК"`,
+				Inn:      "7702000002",
+				Kpp:      "770201001",
+				FullName: `АКЦИОНЕРНОЕ ОБЩЕСТВО "ВАСИЛЁК"`,
+			},
+			Stages: []EISStage{
+				{Title: "Аванс", EndDate: "2024-05-01", Amount: "750000.00"},
+				{Title: "Окончательный расчёт", EndDate: "2024-11-15", Amount: "1750000.00"},
+			},
+		}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			f, err := os.Open("testdata/" + tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			got, err := ParseEIS(f)
+			if err != nil {
+				t.Fatalf("ParseEIS: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("контрактов %d, ожидалось %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if !reflect.DeepEqual(got[i], tt.want[i]) {
+					t.Errorf("контракт %d:\n%+v\nожидалось\n%+v", i+1, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseEISErrors(t *testing.T) {
+	if _, err := ParseEIS(strings.NewReader(`<?xml version="1.0"?><export><notification/></export>`)); !errors.Is(err, ErrNoEISContracts) {
+		t.Errorf("файл без контрактов: %v, ожидалась ErrNoEISContracts", err)
+	}
+	if _, err := ParseEIS(strings.NewReader(`<export><fcsContract><regNum>1</regNum>`)); err == nil || errors.Is(err, ErrNoEISContracts) {
+		t.Errorf("обрезанный файл: %v, ожидалась ошибка разбора", err)
+	}
+}
+
+func TestXMLDate(t *testing.T) {
+	tests := map[string]string{
+		"2024-02-01":                "2024-02-01",
+		"2024-02-01+03:00":          "2024-02-01",
+		"2024-02-01T10:15:00+03:00": "2024-02-01",
+		"01.02.2024":                "01.02.2024",
+		"":                          "",
+	}
+	for in, want := range tests {
+		if got := xmlDate(in); got != want {
+			t.Errorf("xmlDate(%q) = %q, ожидалось %q", in, got, want)
+		}
+	}
+}
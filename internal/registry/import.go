@@ -0,0 +1,577 @@
+package registry
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"statements/internal/contracts"
+	"statements/internal/counterparties"
+	"statements/internal/database"
+	"statements/internal/matching"
+	"statements/internal/models"
+	"statements/internal/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Результат обработки строки реестра
+const (
+	ActionCreated   = "created"   // Создан новый контракт
+	ActionUpdated   = "updated"   // Существующий контракт обновлён
+	ActionUnchanged = "unchanged" // Данные контракта совпадают с реестром
+	ActionFailed    = "failed"    // Строка не загружена из-за ошибок
+)
+
+// statusReason — причина смены статуса ЕАИСТ при загрузке реестра
+const statusReason = "Загрузка реестра ЕАИСТ"
+
+// dateLayouts — форматы дат, встречающиеся в выгрузках реестров
+var dateLayouts = []string{"02.01.2006", "2.1.2006", "2006-01-02", "02.01.06", "02.01.2006 15:04:05", "2006-01-02 15:04:05"}
+
+// Options описывает параметры загрузки реестра
+type Options struct {
+	DryRun       bool   // Только проверить строки, ничего не сохраняя
+	ContractType string // Тип контракта для строк, где он не указан
+	User         string // Пользователь, загружающий реестр
+}
+
+// RowResult описывает результат загрузки строки реестра
+type RowResult struct {
+	Line           int               `json:"line"` // Номер строки реестра или порядковый номер контракта в файле XML
+	RegistryNumber string            `json:"registry_number,omitempty"`
+	ContractNumber string            `json:"contract_number,omitempty"`
+	ContractDate   string            `json:"contract_date,omitempty"`
+	Action         string            `json:"action"`
+	ContractID     string            `json:"contract_id,omitempty"`
+	Errors         map[string]string `json:"errors,omitempty"` // Ключ — поле реестра или контракта
+	Warnings       []string          `json:"warnings,omitempty"`
+	Differences    []Difference      `json:"differences,omitempty"` // Расхождения с ранее сохранёнными данными
+}
+
+// Difference описывает расхождение загруженного значения с сохранённым в контракте
+type Difference struct {
+	Field    string `json:"field"`
+	Title    string `json:"title"`
+	Stored   string `json:"stored"`
+	Imported string `json:"imported"`
+}
+
+// Report описывает результат загрузки реестра
+type Report struct {
+	File      string      `json:"file"`
+	DryRun    bool        `json:"dry_run"`
+	Total     int         `json:"total"`
+	Created   int         `json:"created"`
+	Updated   int         `json:"updated"`
+	Unchanged int         `json:"unchanged"`
+	Failed    int         `json:"failed"`
+	Rows      []RowResult `json:"rows"`
+}
+
+// entry — контракт, подготовленный к загрузке из строки реестра или карточки ЕИС
+type entry struct {
+	line     int
+	contract models.Contract
+	supplier models.Counterparty
+	stages   []models.PaymentStage // nil — в источнике нет сведений об этапах
+	errors   map[string]string     // Ошибки разбора значений
+}
+
+// Import загружает строки реестра: создаёт новые контракты и обновляет уже известные. Контракт ищется
+// по реестровому номеру ЕАИСТ, затем по номеру и дате; контрагент — по ИНН, при отсутствии создаётся.
+// Каждая строка сохраняется в отдельной транзакции, поэтому ошибка в строке не мешает загрузке остальных.
+// Ошибки строк попадают в отчёт; возвращаемая ошибка означает сбой базы данных
+func Import(ctx context.Context, db *sql.DB, file string, records []Record, opts Options) (Report, error) {
+	entries := make([]entry, 0, len(records))
+	for _, rec := range records {
+		verr := &contracts.ValidationError{}
+		ct, supplier := recordContract(rec, opts, verr)
+		entries = append(entries, entry{line: rec.Line, contract: ct, supplier: supplier, errors: verr.Fields})
+	}
+	return importEntries(ctx, db, file, entries, opts)
+}
+
+// importEntries загружает подготовленные контракты и собирает отчёт
+func importEntries(ctx context.Context, db *sql.DB, file string, entries []entry, opts Options) (Report, error) {
+	report := Report{File: file, DryRun: opts.DryRun, Total: len(entries), Rows: make([]RowResult, 0, len(entries))}
+	var changed []models.Contract
+	if !opts.DryRun {
+		if err := ensurePartitions(ctx, db, entries); err != nil {
+			return report, err
+		}
+	}
+	for _, e := range entries {
+		result, err := importEntry(ctx, db, e, opts)
+		if err != nil {
+			return report, fmt.Errorf("строка %d: %w", e.line, err)
+		}
+		switch result.Action {
+		case ActionCreated:
+			report.Created++
+		case ActionUpdated:
+			report.Updated++
+		case ActionUnchanged:
+			report.Unchanged++
+		default:
+			report.Failed++
+		}
+		report.Rows = append(report.Rows, result)
+		if result.Action == ActionCreated || result.Action == ActionUpdated {
+			changed = append(changed, e.contract)
+		}
+	}
+
+	// Транзакции, для которых раньше не нашлось контракта, могли ссылаться на загруженные контракты
+	if !opts.DryRun && len(changed) > 0 {
+		if _, err := matching.RematchNotFound(ctx, db, changed); err != nil {
+			log.Printf("Ошибка повторного сопоставления транзакций после загрузки реестра %s: %v", file, err)
+		}
+	}
+	return report, nil
+}
+
+// ensurePartitions заранее создаёт партиции contracts за годы дат загружаемых контрактов,
+// чтобы строки не создавали их внутри своих транзакций. Создаются только встречающиеся годы:
+// опечатка в годе одной строки не должна порождать партиции за весь промежуток
+func ensurePartitions(ctx context.Context, db *sql.DB, entries []entry) error {
+	years := make(map[int]time.Time)
+	for _, e := range entries {
+		if len(e.errors) > 0 {
+			continue
+		}
+		if date, err := time.Parse(time.DateOnly, e.contract.ContractDate); err == nil {
+			years[date.Year()] = date
+		}
+	}
+	for _, date := range years {
+		if err := database.EnsureContractPartitionFor(ctx, db, date); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importEntry загружает один контракт в отдельной транзакции. Ошибки данных записываются в результат
+func importEntry(ctx context.Context, db *sql.DB, e entry, opts Options) (RowResult, error) {
+	ct := e.contract
+	result := RowResult{
+		Line:           e.line,
+		RegistryNumber: ct.EaistRegistryNumber,
+		ContractNumber: ct.ContractNumber,
+		ContractDate:   ct.ContractDate,
+		Action:         ActionFailed,
+	}
+	if len(e.errors) > 0 {
+		result.Errors = e.errors
+		return result, nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	existing, err := findContract(ctx, tx, ct)
+	if err != nil && !errors.Is(err, contracts.ErrNotFound) {
+		return result, err
+	}
+	found := err == nil
+
+	ct.CounterpartyID, _, err = counterparties.Resolve(ctx, tx, e.supplier)
+	if err != nil {
+		return result, err
+	}
+
+	if found {
+		err = updateContract(ctx, tx, existing, ct, e.supplier, opts, &result)
+	} else {
+		err = createContract(ctx, tx, ct, opts, &result)
+	}
+	if err == nil && e.stages != nil {
+		err = importStages(ctx, tx, result.ContractID, e.stages, opts, &result)
+	}
+	var cverr *contracts.ValidationError
+	if errors.As(err, &cverr) {
+		result.Action, result.Errors = ActionFailed, cverr.Fields
+		return result, nil
+	}
+	if err != nil {
+		return result, err
+	}
+
+	if !opts.DryRun {
+		if err := tx.Commit(); err != nil {
+			return result, fmt.Errorf("ошибка фиксации контракта: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// findContract ищет контракт по реестровому номеру ЕАИСТ, а если его нет — по номеру и дате
+func findContract(ctx context.Context, tx *sql.Tx, ct models.Contract) (models.Contract, error) {
+	if ct.EaistRegistryNumber != "" {
+		existing, err := contracts.FindByRegistryNumber(ctx, tx, ct.EaistRegistryNumber)
+		if !errors.Is(err, contracts.ErrNotFound) {
+			return existing, err
+		}
+	}
+	return contracts.FindByNumber(ctx, tx, ct.ContractNumber, ct.ContractDate)
+}
+
+// createContract создаёт контракт из строки реестра
+func createContract(ctx context.Context, tx *sql.Tx, ct models.Contract, opts Options, result *RowResult) error {
+	if err := contracts.ValidateReferences(ctx, tx, ct); err != nil {
+		return err
+	}
+	id, err := contracts.Create(ctx, tx, ct, opts.User)
+	if err != nil {
+		return err
+	}
+	result.Action, result.ContractID = ActionCreated, id
+	return nil
+}
+
+// updateContract переносит в существующий контракт заполненные поля импортированного контракта и записывает
+// в результат расхождения с сохранёнными данными. Условия контракта с дополнительными соглашениями
+// не перезаписываются: о расхождении с источником сообщается предупреждением
+func updateContract(ctx context.Context, tx *sql.Tx, existing, imported models.Contract, supplier models.Counterparty, opts Options, result *RowResult) error {
+	result.ContractID = existing.ID
+	result.Action = ActionUnchanged
+	result.Differences = compareContract(existing, imported, supplier)
+
+	merged, warnings := mergeContract(existing, imported)
+	result.Warnings = append(result.Warnings, warnings...)
+
+	if contractChanged(existing, merged) {
+		if err := contracts.ValidateReferences(ctx, tx, merged); err != nil {
+			return err
+		}
+		if err := contracts.Update(ctx, tx, merged); err != nil {
+			return err
+		}
+		result.Action = ActionUpdated
+	}
+
+	if imported.EaistStatus != "" && imported.EaistStatus != existing.EaistStatus {
+		_, err := contracts.ChangeEaistStatus(ctx, tx, existing.ID, imported.EaistStatus, statusReason, opts.User)
+		var terr *contracts.EaistTransitionError
+		switch {
+		case errors.As(err, &terr):
+			result.Warnings = append(result.Warnings, fmt.Sprintf(
+				"Статус ЕАИСТ %q не применён: переход из статуса %q недопустим", terr.To, terr.From))
+		case err != nil:
+			return err
+		default:
+			result.Action = ActionUpdated
+		}
+	}
+	return nil
+}
+
+// mergeContract переносит в сохранённый контракт заполненные поля импортированного. Пустые значения источника
+// не затирают сохранённые. Сумма и сроки контракта с дополнительными соглашениями не меняются — вместо этого
+// возвращаются предупреждения о расхождении с действующими условиями
+func mergeContract(existing, imported models.Contract) (merged models.Contract, warnings []string) {
+	// Update сохраняет первоначальные условия, поэтому за основу берутся они, а не действующие
+	merged = existing
+	merged.Amount = existing.Initial.Amount
+	merged.ExecutionPeriod = existing.Initial.ExecutionPeriod
+	merged.ValidityPeriod = existing.Initial.ValidityPeriod
+	merged.Subject = existing.Initial.Subject
+	merged.PaymentDays = existing.Initial.PaymentDays
+
+	setString := func(dst *string, value string) {
+		if value != "" {
+			*dst = value
+		}
+	}
+	setString(&merged.ContractNumber, imported.ContractNumber)
+	setString(&merged.ContractDate, imported.ContractDate)
+	setString(&merged.EaistRegistryNumber, imported.EaistRegistryNumber)
+	setString(&merged.ContractType, imported.ContractType)
+	setString(&merged.WorkType, imported.WorkType)
+	setString(&merged.ConclusionBasis, imported.ConclusionBasis)
+	setString(&merged.ProcurementType, imported.ProcurementType)
+	setString(&merged.Initiator, imported.Initiator)
+	setString(&merged.EaistLink, imported.EaistLink)
+	merged.CounterpartyID = imported.CounterpartyID
+
+	if existing.AmendmentCount > 0 {
+		if imported.Amount != existing.Amount {
+			warnings = append(warnings, fmt.Sprintf(
+				"Сумма %.2f отличается от действующей с учётом дополнительных соглашений %.2f; условия не изменены",
+				imported.Amount, existing.Amount))
+		}
+		if imported.ExecutionPeriod != existing.ExecutionPeriod {
+			warnings = append(warnings, fmt.Sprintf(
+				"Срок исполнения %s отличается от действующего %s; условия не изменены",
+				imported.ExecutionPeriod, existing.ExecutionPeriod))
+		}
+	} else {
+		merged.Amount = imported.Amount
+		merged.ExecutionPeriod = imported.ExecutionPeriod
+		setString(&merged.ValidityPeriod, imported.ValidityPeriod)
+		setString(&merged.Subject, imported.Subject)
+		if imported.PaymentDays != nil {
+			merged.PaymentDays = imported.PaymentDays
+		}
+	}
+	return merged, warnings
+}
+
+// compareContract перечисляет расхождения заполненных полей импортированного контракта
+// с действующими условиями сохранённого
+func compareContract(existing, imported models.Contract, supplier models.Counterparty) []Difference {
+	var diffs []Difference
+	add := func(field, title, stored, value string) {
+		if value != "" && value != stored {
+			diffs = append(diffs, Difference{Field: field, Title: title, Stored: stored, Imported: value})
+		}
+	}
+	add("contract_number", "Номер контракта", existing.ContractNumber, imported.ContractNumber)
+	add("contract_date", "Дата заключения", existing.ContractDate, imported.ContractDate)
+	add("eaist_registry_number", "Реестровый номер", existing.EaistRegistryNumber, imported.EaistRegistryNumber)
+	if math.Round(existing.Amount*100) != math.Round(imported.Amount*100) {
+		add("amount", "Сумма", fmt.Sprintf("%.2f", existing.Amount), fmt.Sprintf("%.2f", imported.Amount))
+	}
+	add("execution_period", "Срок исполнения", existing.ExecutionPeriod, imported.ExecutionPeriod)
+	add("validity_period", "Срок действия", existing.ValidityPeriod, imported.ValidityPeriod)
+	add("subject", "Предмет", existing.Subject, imported.Subject)
+	if imported.PaymentDays != nil {
+		stored := ""
+		if existing.PaymentDays != nil {
+			stored = strconv.Itoa(*existing.PaymentDays)
+		}
+		add("payment_days", "Дней на оплату", stored, strconv.Itoa(*imported.PaymentDays))
+	}
+	add("counterparty_inn", "ИНН поставщика", existing.CounterpartyInn, supplier.Inn)
+	add("eaist_status", "Статус ЕАИСТ", existing.EaistStatus, imported.EaistStatus)
+	return diffs
+}
+
+// importStages заменяет график платежей контракта этапами из источника, если они отличаются от сохранённых.
+// Этапы, не прошедшие проверку графика, не загружаются: контракт сохраняется, а ошибка попадает в предупреждения
+func importStages(ctx context.Context, tx *sql.Tx, id string, stages []models.PaymentStage, opts Options, result *RowResult) error {
+	schedule, err := contracts.Schedule(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	if sameStages(schedule.Stages, stages) {
+		return nil
+	}
+	if result.Action == ActionUpdated || result.Action == ActionUnchanged {
+		result.Differences = append(result.Differences, Difference{
+			Field:    "stages",
+			Title:    "График платежей",
+			Stored:   describeStages(schedule.Stages),
+			Imported: describeStages(stages),
+		})
+	}
+
+	_, err = contracts.SaveSchedule(ctx, tx, id, append([]models.PaymentStage(nil), stages...), opts.User)
+	var verr *contracts.ValidationError
+	if errors.As(err, &verr) {
+		result.Warnings = append(result.Warnings, "График платежей не загружен: "+verr.Error())
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if result.Action == ActionUnchanged {
+		result.Action = ActionUpdated
+	}
+	return nil
+}
+
+// sameStages сообщает, совпадают ли этапы графика по датам, суммам и описаниям без учёта порядка
+func sameStages(stored, imported []models.PaymentStage) bool {
+	if len(stored) != len(imported) {
+		return false
+	}
+	key := func(s models.PaymentStage) string {
+		return fmt.Sprintf("%s|%.0f|%s", s.PlannedDate, math.Round(s.Amount*100), strings.TrimSpace(s.Title))
+	}
+	counts := make(map[string]int, len(stored))
+	for _, s := range stored {
+		counts[key(s)]++
+	}
+	for _, s := range imported {
+		if counts[key(s)] == 0 {
+			return false
+		}
+		counts[key(s)]--
+	}
+	return true
+}
+
+// describeStages кратко описывает график платежей для отчёта о расхождениях
+func describeStages(stages []models.PaymentStage) string {
+	if len(stages) == 0 {
+		return "нет этапов"
+	}
+	var total float64
+	for _, s := range stages {
+		total += s.Amount
+	}
+	return fmt.Sprintf("этапов: %d на сумму %.2f", len(stages), total)
+}
+
+// contractChanged сообщает, отличаются ли сохраняемые Update поля контракта updated от исходного existing
+func contractChanged(existing, updated models.Contract) bool {
+	paymentDays := func(p *int) int {
+		if p == nil {
+			return -1
+		}
+		return *p
+	}
+	return existing.CounterpartyID != updated.CounterpartyID ||
+		existing.ContractNumber != updated.ContractNumber ||
+		existing.ContractDate != updated.ContractDate ||
+		existing.Initial.Amount != updated.Amount ||
+		existing.Initial.ExecutionPeriod != updated.ExecutionPeriod ||
+		existing.Initial.ValidityPeriod != updated.ValidityPeriod ||
+		existing.Initial.Subject != updated.Subject ||
+		paymentDays(existing.Initial.PaymentDays) != paymentDays(updated.PaymentDays) ||
+		existing.EaistRegistryNumber != updated.EaistRegistryNumber ||
+		existing.ContractType != updated.ContractType ||
+		existing.WorkType != updated.WorkType ||
+		existing.ConclusionBasis != updated.ConclusionBasis ||
+		existing.ProcurementType != updated.ProcurementType ||
+		existing.Initiator != updated.Initiator ||
+		existing.EaistLink != updated.EaistLink
+}
+
+// recordContract преобразует строку реестра в контракт и контрагента-поставщика.
+// Ошибки значений записываются в verr по полям реестра
+func recordContract(rec Record, opts Options, verr *contracts.ValidationError) (models.Contract, models.Counterparty) {
+	ct := models.Contract{
+		ContractNumber:      rec.Get(FieldContractNumber),
+		EaistRegistryNumber: rec.Get(FieldRegistryNumber),
+		Subject:             rec.Get(FieldSubject),
+		ContractType:        utils.FirstNonEmpty(rec.Get(FieldContractType), strings.TrimSpace(opts.ContractType)),
+		WorkType:            rec.Get(FieldWorkType),
+		ConclusionBasis:     rec.Get(FieldConclusionBasis),
+		ProcurementType:     rec.Get(FieldProcurementType),
+		Initiator:           rec.Get(FieldInitiator),
+		EaistLink:           rec.Get(FieldLink),
+	}
+	if ct.ContractNumber == "" {
+		// В реестрах без номера контракта номером служит реестровый номер
+		ct.ContractNumber = ct.EaistRegistryNumber
+	}
+	ct.ContractDate = parseDate(verr, FieldContractDate, rec.Get(FieldContractDate))
+	ct.ExecutionPeriod = parseDate(verr, FieldExecutionPeriod, rec.Get(FieldExecutionPeriod))
+	ct.ValidityPeriod = parseDate(verr, FieldValidityPeriod, rec.Get(FieldValidityPeriod))
+	ct.Amount = parseAmount(verr, rec.Get(FieldAmount))
+
+	if value := rec.Get(FieldPaymentDays); value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil {
+			verr.Add(FieldPaymentDays, "Количество дней на оплату должно быть целым числом")
+		} else {
+			ct.PaymentDays = &days
+		}
+	}
+	if value := rec.Get(FieldStatus); value != "" {
+		status, ok := eaistStatus(value)
+		if !ok {
+			verr.Add(FieldStatus, fmt.Sprintf("Неизвестный статус %q", value))
+		}
+		ct.EaistStatus = status
+	}
+
+	supplier := models.Counterparty{
+		Inn:  rec.Get(FieldSupplierInn),
+		Kpp:  rec.Get(FieldSupplierKpp),
+		Name: counterparties.NormalizeName(rec.Get(FieldSupplierName)),
+	}
+	if supplier.Name == "" {
+		supplier.Name = supplier.Inn
+	}
+	if supplier.Inn == "" {
+		verr.Add(FieldSupplierInn, "Не указан ИНН поставщика")
+	} else if err := counterparties.Validate(supplier); err != nil {
+		verr.Add(FieldSupplierInn, err.Error())
+	}
+
+	validateImported(verr, ct)
+	return ct, supplier
+}
+
+// validateImported проверяет импортированный контракт теми же правилами, что и при вводе вручную.
+// Контрагент ещё не найден, поэтому ошибка его выбора не учитывается
+func validateImported(verr *contracts.ValidationError, ct models.Contract) {
+	var cverr *contracts.ValidationError
+	if err := contracts.Validate(ct); errors.As(err, &cverr) {
+		for field, message := range cverr.Fields {
+			if field != "counterparty_id" {
+				verr.Add(field, message)
+			}
+		}
+	}
+}
+
+// parseDate разбирает дату в одном из форматов реестра или в виде числа дней Excel и возвращает её в формате ГГГГ-ММ-ДД
+func parseDate(verr *contracts.ValidationError, field, value string) string {
+	if value == "" {
+		return ""
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.Format(time.DateOnly)
+		}
+	}
+	if serial, err := strconv.ParseFloat(value, 64); err == nil && serial > 0 {
+		if t, err := excelize.ExcelDateToTime(serial, false); err == nil {
+			return t.Format(time.DateOnly)
+		}
+	}
+	verr.Add(field, fmt.Sprintf("Не удалось распознать дату %q", value))
+	return ""
+}
+
+// parseAmount разбирает обязательную цену контракта и записывает ошибку поля amount
+func parseAmount(verr *contracts.ValidationError, value string) float64 {
+	if value == "" {
+		verr.Add(FieldAmount, "Не указана цена контракта")
+		return 0
+	}
+	amount, ok := parseNumber(value)
+	if !ok {
+		verr.Add(FieldAmount, fmt.Sprintf("Не удалось распознать сумму %q", value))
+	}
+	return amount
+}
+
+// parseNumber разбирает сумму с пробелами между разрядами и запятой или точкой в качестве десятичного разделителя
+// и округляет её до копеек
+func parseNumber(value string) (float64, bool) {
+	cleaned := strings.NewReplacer(" ", "", "\u00a0", "", "\u202f", "", "руб.", "", "руб", "", "₽", "", ",", ".").Replace(value)
+	amount, err := strconv.ParseFloat(cleaned, 64)
+	if err != nil || math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return 0, false
+	}
+	return math.Round(amount*100) / 100, true
+}
+
+// eaistStatus сопоставляет статус из реестра статусу ЕАИСТ
+func eaistStatus(value string) (string, bool) {
+	v := normalizeHeader(value)
+	switch {
+	case strings.HasPrefix(v, "аннулир"):
+		return contracts.EaistCancelled, true
+	case strings.HasPrefix(v, "заверш"), v == "исполнен", v == "исполнение завершено",
+		v == "исполнение прекращено", strings.HasPrefix(v, "расторгнут"):
+		return contracts.EaistCompleted, true
+	case strings.HasPrefix(v, "активн"), strings.HasPrefix(v, "действ"), v == "исполнение", v == "на исполнении":
+		return contracts.EaistActive, true
+	}
+	return "", false
+}
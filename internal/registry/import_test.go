@@ -0,0 +1,229 @@
+package registry
+
+import (
+	"reflect"
+	"statements/internal/contracts"
+	"statements/internal/models"
+	"testing"
+)
+
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		value string
+		want  float64
+		ok    bool
+	}{
+		{"1000", 1000, true},
+		{"1 000 000,50", 1000000.5, true},
+		{"1 234,5", 1234.5, true},
+		{"1 234.56 руб.", 1234.56, true},
+		{"99,99 ₽", 99.99, true},
+		{"10.005", 10.01, true},
+		{"0,004", 0, true},
+		{"-150,25", -150.25, true},
+		{"1,234,56", 0, false},
+		{"сто рублей", 0, false},
+		{"NaN", 0, false},
+		{"Inf", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseNumber(tt.value)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseNumber(%q) = %v, %t, ожидалось %v, %t", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+		ok    bool
+	}{
+		{"01.02.2024", "2024-02-01", true},
+		{"1.2.2024", "2024-02-01", true},
+		{"2024-02-01", "2024-02-01", true},
+		{"01.02.24", "2024-02-01", true},
+		{"01.02.2024 00:00:00", "2024-02-01", true},
+		{"2024-02-01 13:45:00", "2024-02-01", true},
+		// Даты из Excel без форматирования — число дней с 30.12.1899, дробная часть — время
+		{"45323", "2024-02-01", true},
+		{"45323.75", "2024-02-01", true},
+		{"45657", "2024-12-31", true},
+		{"", "", true},
+		{"31.02.2024", "", false},
+		{"завтра", "", false},
+		{"0", "", false},
+		{"-1", "", false},
+	}
+	for _, tt := range tests {
+		verr := &contracts.ValidationError{}
+		got := parseDate(verr, FieldContractDate, tt.value)
+		_, failed := verr.Fields[FieldContractDate]
+		if got != tt.want || failed == tt.ok {
+			t.Errorf("parseDate(%q) = %q, ошибка %q, ожидалось %q", tt.value, got, verr.Fields[FieldContractDate], tt.want)
+		}
+	}
+}
+
+func TestEaistStatus(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+		ok    bool
+	}{
+		{"Активный", contracts.EaistActive, true},
+		{"действующий", contracts.EaistActive, true},
+		{"На исполнении", contracts.EaistActive, true},
+		{"Исполнение", contracts.EaistActive, true},
+		{"Завершён", contracts.EaistCompleted, true},
+		{"ЗАВЕРШЕН", contracts.EaistCompleted, true},
+		{"Исполнен", contracts.EaistCompleted, true},
+		{"Исполнение завершено", contracts.EaistCompleted, true},
+		{"Исполнение прекращено", contracts.EaistCompleted, true},
+		{"Расторгнут", contracts.EaistCompleted, true},
+		{"Аннулирован", contracts.EaistCancelled, true},
+		{"аннулирована запись", contracts.EaistCancelled, true},
+		{"Черновик", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := eaistStatus(tt.value)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("eaistStatus(%q) = %q, %t, ожидалось %q, %t", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestMergeContract(t *testing.T) {
+	days := func(n int) *int { return &n }
+	initial := models.ContractTerms{Amount: 1000, ExecutionPeriod: "2024-12-31", ValidityPeriod: "2025-01-31", Subject: "Поставка бумаги", PaymentDays: days(10)}
+	existing := models.Contract{
+		ID:                  "3f1c2a9e-0b7d-4c55-9a61-2f4b8d7e6c10",
+		CounterpartyID:      1,
+		ContractNumber:      "12/3",
+		ContractDate:        "2024-02-01",
+		EaistRegistryNumber: "2770100000024000001",
+		ContractType:        "Поставка",
+		WorkType:            "Канцтовары",
+		Initiator:           "Отдел снабжения",
+		Amount:              initial.Amount,
+		ExecutionPeriod:     initial.ExecutionPeriod,
+		ValidityPeriod:      initial.ValidityPeriod,
+		Subject:             initial.Subject,
+		PaymentDays:         initial.PaymentDays,
+		Initial:             initial,
+	}
+	amended := existing
+	amended.AmendmentCount = 1
+	amended.Amount, amended.ExecutionPeriod = 1200, "2025-03-31"
+
+	tests := []struct {
+		name     string
+		existing models.Contract
+		imported models.Contract
+		want     func(*models.Contract)
+		warnings int
+		changed  bool
+	}{
+		{
+			name:     "пустые значения не затирают сохранённые",
+			existing: existing,
+			imported: models.Contract{CounterpartyID: 2, Amount: 1000, ExecutionPeriod: "2024-12-31"},
+			want:     func(m *models.Contract) { m.CounterpartyID = 2 },
+			changed:  true,
+		},
+		{
+			name:     "заполненные значения переносятся",
+			existing: existing,
+			imported: models.Contract{
+				CounterpartyID: 1, ContractNumber: "12/3-А", ContractType: "Услуги", ProcurementType: "Электронный аукцион",
+				Amount: 1500, ExecutionPeriod: "2025-06-30", ValidityPeriod: "2025-07-31", Subject: "Поставка бумаги А4", PaymentDays: days(7),
+			},
+			want: func(m *models.Contract) {
+				m.ContractNumber, m.ContractType, m.ProcurementType = "12/3-А", "Услуги", "Электронный аукцион"
+				m.Amount, m.ExecutionPeriod, m.ValidityPeriod, m.Subject, m.PaymentDays = 1500, "2025-06-30", "2025-07-31", "Поставка бумаги А4", days(7)
+			},
+			changed: true,
+		},
+		{
+			// Update сохраняет первоначальные условия, поэтому в результате они, а не действующие
+			name:     "условия контракта с соглашениями не меняются",
+			existing: amended,
+			imported: models.Contract{CounterpartyID: 1, Amount: 1500, ExecutionPeriod: "2025-06-30", Subject: "Другой предмет", Initiator: "Дирекция"},
+			want: func(m *models.Contract) {
+				m.Amount, m.ExecutionPeriod, m.Initiator = initial.Amount, initial.ExecutionPeriod, "Дирекция"
+			},
+			warnings: 2,
+			changed:  true,
+		},
+		{
+			name:     "совпадение с действующими условиями без предупреждений",
+			existing: amended,
+			imported: models.Contract{CounterpartyID: 1, Amount: 1200, ExecutionPeriod: "2025-03-31"},
+			want:     func(m *models.Contract) { m.Amount, m.ExecutionPeriod = initial.Amount, initial.ExecutionPeriod },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := tt.existing
+			tt.want(&want)
+			got, warnings := mergeContract(tt.existing, tt.imported)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("mergeContract() =\n%+v\nожидалось\n%+v", got, want)
+			}
+			if len(warnings) != tt.warnings {
+				t.Errorf("предупреждения %q, ожидалось %d", warnings, tt.warnings)
+			}
+			if changed := contractChanged(tt.existing, got); changed != tt.changed {
+				t.Errorf("contractChanged() = %t, ожидалось %t", changed, tt.changed)
+			}
+		})
+	}
+}
+
+func TestCompareContract(t *testing.T) {
+	existing := models.Contract{ContractNumber: "12/3", ContractDate: "2024-02-01", Amount: 1000, ExecutionPeriod: "2024-12-31", CounterpartyInn: "7701234567", EaistStatus: contracts.EaistActive}
+	imported := models.Contract{ContractNumber: "12/3", ContractDate: "2024-02-01", Amount: 1000.004, ExecutionPeriod: "2025-01-31", EaistStatus: contracts.EaistCompleted}
+
+	got := compareContract(existing, imported, models.Counterparty{Inn: "7702000001"})
+	want := []Difference{
+		{Field: "execution_period", Title: "Срок исполнения", Stored: "2024-12-31", Imported: "2025-01-31"},
+		{Field: "counterparty_inn", Title: "ИНН поставщика", Stored: "7701234567", Imported: "7702000001"},
+		{Field: "eaist_status", Title: "Статус ЕАИСТ", Stored: contracts.EaistActive, Imported: contracts.EaistCompleted},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("compareContract() =\n%+v\nожидалось\n%+v", got, want)
+	}
+}
+
+func TestRecordContract(t *testing.T) {
+	rec := Record{Line: 2, Values: map[string]string{
+		FieldRegistryNumber:  "2770100000024000001",
+		FieldContractDate:    "45323",
+		FieldExecutionPeriod: "31.12.2024",
+		FieldAmount:          "1 000,50",
+		FieldSubject:         "Поставка бумаги",
+		FieldStatus:          "Исполнение",
+		FieldPaymentDays:     "десять",
+		FieldSupplierInn:     "7701234567",
+		FieldSupplierName:    "Ромашка, ООО",
+	}}
+	verr := &contracts.ValidationError{}
+	ct, supplier := recordContract(rec, Options{ContractType: " Поставка "}, verr)
+
+	// Номером контракта служит реестровый номер, тип берётся из параметров загрузки
+	if ct.ContractNumber != "2770100000024000001" || ct.ContractType != "Поставка" {
+		t.Errorf("номер %q, тип %q", ct.ContractNumber, ct.ContractType)
+	}
+	if ct.ContractDate != "2024-02-01" || ct.ExecutionPeriod != "2024-12-31" || ct.Amount != 1000.5 || ct.EaistStatus != contracts.EaistActive {
+		t.Errorf("контракт %+v", ct)
+	}
+	if supplier.Name != `ООО "Ромашка"` || supplier.Inn != "7701234567" {
+		t.Errorf("поставщик %+v", supplier)
+	}
+	if len(verr.Fields) != 1 || verr.Fields[FieldPaymentDays] == "" {
+		t.Errorf("ошибки %v, ожидалась только ошибка дней на оплату", verr.Fields)
+	}
+}
@@ -0,0 +1,268 @@
+package registry
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/xuri/excelize/v2"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Поля реестра контрактов, распознаваемые по заголовкам колонок
+const (
+	FieldRegistryNumber  = "registry_number"
+	FieldContractNumber  = "contract_number"
+	FieldContractDate    = "contract_date"
+	FieldExecutionPeriod = "execution_period"
+	FieldValidityPeriod  = "validity_period"
+	FieldAmount          = "amount"
+	FieldSubject         = "subject"
+	FieldContractType    = "contract_type"
+	FieldWorkType        = "work_type"
+	FieldProcurementType = "procurement_type"
+	FieldConclusionBasis = "conclusion_basis"
+	FieldInitiator       = "initiator"
+	FieldStatus          = "status"
+	FieldPaymentDays     = "payment_days"
+	FieldLink            = "eaist_link"
+	FieldSupplierInn     = "supplier_inn"
+	FieldSupplierKpp     = "supplier_kpp"
+	FieldSupplierName    = "supplier_name"
+)
+
+// headerAliases сопоставляет заголовки колонок выгрузок ЕАИСТ и реестров в Excel полям реестра.
+// Заголовки сравниваются после normalizeHeader
+var headerAliases = map[string]string{
+	"реестровый номер":                    FieldRegistryNumber,
+	"реестровый номер контракта":          FieldRegistryNumber,
+	"номер реестровой записи":             FieldRegistryNumber,
+	"реестровый номер еаист":              FieldRegistryNumber,
+	"номер контракта":                     FieldContractNumber,
+	"номер договора":                      FieldContractNumber,
+	"номер":                               FieldContractNumber,
+	"дата контракта":                      FieldContractDate,
+	"дата договора":                       FieldContractDate,
+	"дата заключения":                     FieldContractDate,
+	"дата заключения контракта":           FieldContractDate,
+	"срок исполнения":                     FieldExecutionPeriod,
+	"дата окончания исполнения":           FieldExecutionPeriod,
+	"дата окончания исполнения контракта": FieldExecutionPeriod,
+	"срок действия":                       FieldValidityPeriod,
+	"дата окончания срока действия":       FieldValidityPeriod,
+	"срок действия контракта":             FieldValidityPeriod,
+	"цена контракта":                      FieldAmount,
+	"цена контракта руб":                  FieldAmount,
+	"сумма":                               FieldAmount,
+	"сумма контракта":                     FieldAmount,
+	"сумма договора":                      FieldAmount,
+	"предмет":                             FieldSubject,
+	"предмет контракта":                   FieldSubject,
+	"наименование объекта закупки":        FieldSubject,
+	"тип контракта":                       FieldContractType,
+	"вид работ":                           FieldWorkType,
+	"способ закупки":                      FieldProcurementType,
+	"способ определения поставщика":       FieldProcurementType,
+	"вид закупки":                         FieldProcurementType,
+	"основание заключения":                FieldConclusionBasis,
+	"основание заключения контракта":      FieldConclusionBasis,
+	"инициатор":                           FieldInitiator,
+	"инициатор закупки":                   FieldInitiator,
+	"статус":                              FieldStatus,
+	"статус контракта":                    FieldStatus,
+	"статус еаист":                        FieldStatus,
+	"дней на оплату":                      FieldPaymentDays,
+	"срок оплаты дней":                    FieldPaymentDays,
+	"ссылка":                              FieldLink,
+	"ссылка на еаист":                     FieldLink,
+	"инн":                                 FieldSupplierInn,
+	"инн поставщика":                      FieldSupplierInn,
+	"инн контрагента":                     FieldSupplierInn,
+	"кпп":                                 FieldSupplierKpp,
+	"кпп поставщика":                      FieldSupplierKpp,
+	"кпп контрагента":                     FieldSupplierKpp,
+	"поставщик":                           FieldSupplierName,
+	"наименование поставщика":             FieldSupplierName,
+	"контрагент":                          FieldSupplierName,
+	"наименование поставщика подрядчика исполнителя": FieldSupplierName,
+}
+
+// maxHeaderRow — количество первых строк, среди которых ищется строка заголовков
+const maxHeaderRow = 20
+
+// minHeaderColumns — сколько колонок должно быть распознано в строке заголовков
+const minHeaderColumns = 3
+
+var (
+	// ErrNoHeader возвращается, если в файле не найдена строка заголовков реестра
+	ErrNoHeader = errors.New("не найдена строка заголовков: нужны колонки с номером или реестровым номером контракта")
+	// ErrNoRecords возвращается, если в реестре нет ни одной строки с данными
+	ErrNoRecords = errors.New("в реестре нет строк с контрактами")
+)
+
+// Record — строка реестра: значения распознанных колонок по полям реестра
+type Record struct {
+	Line   int               // Номер строки в файле начиная с 1
+	Values map[string]string // Ключ — поле реестра (Field*)
+}
+
+// Get возвращает значение поля без пробелов по краям
+func (r Record) Get(field string) string {
+	return strings.TrimSpace(r.Values[field])
+}
+
+// ParseXLSX читает реестр из первого листа книги Excel. Значения ячеек берутся без форматирования,
+// поэтому даты в формате Excel приходят числами и распознаются при разборе дат
+func ParseXLSX(r io.Reader) ([]Record, error) {
+	f, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения книги Excel: %w", err)
+	}
+	defer f.Close()
+
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, ErrNoRecords
+	}
+	rows, err := f.GetRows(sheets[0], excelize.Options{RawCellValue: true})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения листа %s: %w", sheets[0], err)
+	}
+	return parseRows(rows)
+}
+
+// csvDelimiters — разделители CSV в порядке проверки
+var csvDelimiters = []rune{';', ',', '\t'}
+
+// ParseCSV читает реестр из CSV. Кодировка (UTF-8 или Windows-1251) и разделитель (;, запятая или табуляция)
+// определяются автоматически: разделителем считается первый, с которым находится строка заголовков.
+// Заголовкам могут предшествовать строки с названием реестра, поэтому разделитель не определяется по первой строке
+func ParseCSV(r io.Reader) ([]Record, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла: %w", err)
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if !utf8.Valid(data) {
+		if data, err = charmap.Windows1251.NewDecoder().Bytes(data); err != nil {
+			return nil, fmt.Errorf("ошибка перекодирования файла из Windows-1251: %w", err)
+		}
+	}
+
+	err = ErrNoHeader
+	for _, delimiter := range csvDelimiters {
+		rows, readErr := readCSV(data, delimiter)
+		if readErr != nil {
+			err = fmt.Errorf("ошибка разбора CSV: %w", readErr)
+			continue
+		}
+		records, parseErr := parseRows(rows)
+		if errors.Is(parseErr, ErrNoHeader) {
+			continue
+		}
+		return records, parseErr
+	}
+	return nil, err
+}
+
+// readCSV читает строки CSV с разделителем delimiter. Индекс строки в результате совпадает с номером строки
+// в файле минус один: пропущенные при чтении пустые строки и продолжения многострочных значений заменяются пустыми
+func readCSV(data []byte, delimiter rune) ([][]string, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	var rows [][]string
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		for len(rows) < line-1 {
+			rows = append(rows, nil)
+		}
+		rows = append(rows, row)
+	}
+}
+
+// parseRows находит строку заголовков и преобразует следующие за ней строки в записи реестра
+func parseRows(rows [][]string) ([]Record, error) {
+	headerRow, columns := -1, map[int]string(nil)
+	for i := 0; i < len(rows) && i < maxHeaderRow; i++ {
+		if found := headerColumns(rows[i]); len(found) >= minHeaderColumns && hasKeyField(found) {
+			headerRow, columns = i, found
+			break
+		}
+	}
+	if headerRow < 0 {
+		return nil, ErrNoHeader
+	}
+
+	records := make([]Record, 0, len(rows)-headerRow-1)
+	for i := headerRow + 1; i < len(rows); i++ {
+		rec := Record{Line: i + 1, Values: make(map[string]string, len(columns))}
+		empty := true
+		for col, field := range columns {
+			if col < len(rows[i]) {
+				value := strings.TrimSpace(rows[i][col])
+				rec.Values[field] = value
+				empty = empty && value == ""
+			}
+		}
+		if !empty {
+			records = append(records, rec)
+		}
+	}
+	if len(records) == 0 {
+		return nil, ErrNoRecords
+	}
+	return records, nil
+}
+
+// headerColumns сопоставляет колонки строки полям реестра; для повторяющегося поля берётся первая колонка
+func headerColumns(row []string) map[int]string {
+	columns := make(map[int]string)
+	seen := make(map[string]bool)
+	for i, cell := range row {
+		field, ok := headerAliases[normalizeHeader(cell)]
+		if !ok || seen[field] {
+			continue
+		}
+		columns[i] = field
+		seen[field] = true
+	}
+	return columns
+}
+
+// hasKeyField сообщает, есть ли среди колонок номер или реестровый номер контракта
+func hasKeyField(columns map[int]string) bool {
+	for _, field := range columns {
+		if field == FieldContractNumber || field == FieldRegistryNumber {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeHeader приводит заголовок колонки к нижнему регистру, заменяет ё на е,
+// убирает знаки препинания и лишние пробелы: "Цена контракта, руб." → "цена контракта руб"
+func normalizeHeader(s string) string {
+	s = strings.ReplaceAll(strings.ToLower(s), "ё", "е")
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return ' '
+	}, s)
+	return strings.Join(strings.Fields(s), " ")
+}
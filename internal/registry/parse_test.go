@@ -0,0 +1,122 @@
+package registry
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+func TestParseCSV(t *testing.T) {
+	cp1251, err := charmap.Windows1251.NewEncoder().String(
+		"Реестровый номер;Номер контракта;Дата контракта;Цена контракта;ИНН поставщика\r\n" +
+			"2770100000024000001;12/3;01.02.2024;1 000,50;7701234567\r\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Record{{Line: 2, Values: map[string]string{
+		FieldRegistryNumber: "2770100000024000001",
+		FieldContractNumber: "12/3",
+		FieldContractDate:   "01.02.2024",
+		FieldAmount:         "1 000,50",
+		FieldSupplierInn:    "7701234567",
+	}}}
+
+	tests := []struct {
+		name string
+		data string
+		want []Record
+		err  error
+	}{
+		{"точка с запятой и BOM",
+			"\xef\xbb\xbfРеестровый номер;Номер контракта;Дата контракта;Цена контракта;ИНН поставщика\n" +
+				"2770100000024000001;12/3;01.02.2024;1 000,50;7701234567\n",
+			want, nil},
+		{"windows-1251", cp1251, want, nil},
+		{"табуляция",
+			"Реестровый номер\tНомер контракта\tДата контракта\tЦена контракта\tИНН поставщика\n" +
+				"2770100000024000001\t12/3\t01.02.2024\t1 000,50\t7701234567\n",
+			want, nil},
+		// По первой строке разделителем выглядела бы точка с запятой. Номер строки считается с учётом пустой строки
+		{"запятая после строк с названием реестра",
+			"Реестр контрактов на 01.02.2024; выгрузка ЕАИСТ\n" +
+				"\n" +
+				`Реестровый номер,Номер контракта,Дата контракта,"Цена контракта, руб.",ИНН поставщика` + "\n" +
+				`2770100000024000001,12/3,01.02.2024,"1 000,50",7701234567` + "\n",
+			[]Record{{Line: 4, Values: want[0].Values}}, nil},
+		{"многострочное значение",
+			"Номер контракта;Дата контракта;Предмет\n" +
+				"1;01.02.2024;\"Поставка\nбумаги\"\n" +
+				"2;02.02.2024;Ремонт\n",
+			[]Record{
+				{Line: 2, Values: map[string]string{FieldContractNumber: "1", FieldContractDate: "01.02.2024", FieldSubject: "Поставка\nбумаги"}},
+				{Line: 4, Values: map[string]string{FieldContractNumber: "2", FieldContractDate: "02.02.2024", FieldSubject: "Ремонт"}},
+			}, nil},
+		{"нет заголовков", "Номер;Сумма\n12;100\n", nil, ErrNoHeader},
+		{"только заголовки", "Реестровый номер;Номер контракта;Дата контракта\n;;\n", nil, ErrNoRecords},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCSV(strings.NewReader(tt.data))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ошибка %v, ожидалась %v", err, tt.err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseCSV() = %+v\nожидалось %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRows(t *testing.T) {
+	rows := [][]string{
+		{"Реестр контрактов"},
+		{"Номер", "Номер договора", "Дата заключения", "Сумма", "Статус"},
+		{"12/3", "Д-1", "01.02.2024", "100", "Активный"},
+		{"", "", "", "", ""},
+		{"15"},
+	}
+	got, err := parseRows(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Для повторяющегося поля берётся первая колонка; пустые строки пропускаются, короткие дополняются пустыми значениями
+	want := []Record{
+		{Line: 3, Values: map[string]string{FieldContractNumber: "12/3", FieldContractDate: "01.02.2024", FieldAmount: "100", FieldStatus: "Активный"}},
+		{Line: 5, Values: map[string]string{FieldContractNumber: "15"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseRows() = %+v\nожидалось %+v", got, want)
+	}
+
+	// Строка заголовков ищется только среди первых maxHeaderRow строк
+	late := make([][]string, maxHeaderRow, maxHeaderRow+2)
+	late = append(late, rows[1], rows[2])
+	if _, err := parseRows(late); !errors.Is(err, ErrNoHeader) {
+		t.Errorf("заголовки после %d строк: %v, ожидалась ErrNoHeader", maxHeaderRow, err)
+	}
+
+	// Без номера или реестрового номера строка не считается заголовками
+	if _, err := parseRows([][]string{{"Дата контракта", "Сумма", "Предмет", "ИНН"}, {"01.02.2024", "1", "Бумага", "7701234567"}}); !errors.Is(err, ErrNoHeader) {
+		t.Errorf("заголовки без номера: %v, ожидалась ErrNoHeader", err)
+	}
+}
+
+func TestNormalizeHeader(t *testing.T) {
+	tests := map[string]string{
+		"Цена контракта, руб.":  "цена контракта руб",
+		"  Реестровый  номер\n": "реестровый номер",
+		"Статус ЕАИСТ":          "статус еаист",
+		"Наименование поставщика (подрядчика, исполнителя)": "наименование поставщика подрядчика исполнителя",
+		"Срок исполнения (дата)":                            "срок исполнения дата",
+		"Объём": "объем",
+	}
+	for in, want := range tests {
+		if got := normalizeHeader(in); got != want {
+			t.Errorf("normalizeHeader(%q) = %q, ожидалось %q", in, got, want)
+		}
+	}
+}
@@ -7,10 +7,11 @@ import (
 	"statements/internal/database"
 	"statements/internal/handlers"
 	"statements/internal/middleware"
+	"statements/internal/storage"
 )
 
 // RegisterRoutes регистрирует маршруты с использованием Gin
-func RegisterRoutes(cfg *config.Config) *gin.Engine {
+func RegisterRoutes(cfg *config.Config, store storage.BlobStore) *gin.Engine {
 	// Инициализируем роутер
 	router := gin.Default()
 
@@ -22,9 +23,9 @@ func RegisterRoutes(cfg *config.Config) *gin.Engine {
 	router.Use(middleware.AuthMiddleware()) // Защищённые маршруты требуют JWT
 
 	// Регистрация маршрутов
-	registerStaticRoutes(router)
-	registerAPIRoutes(router, cfg, database.DB) // Используем глобальный объект базы данных
-	registerFileUploadRoutes(router, cfg)
+	registerStaticRoutes(router, cfg, store, database.DB)
+	registerAPIRoutes(router, cfg, store, database.DB) // Используем глобальный объект базы данных
+	registerFileUploadRoutes(router, cfg, store)
 	registerDownloadRoutes(router) // Новый маршрут для скачивания Excel
 
 	// Статические файлы
@@ -34,39 +35,202 @@ func RegisterRoutes(cfg *config.Config) *gin.Engine {
 }
 
 // registerStaticRoutes регистрирует маршруты для статических страниц
-func registerStaticRoutes(router *gin.Engine) {
+func registerStaticRoutes(router *gin.Engine, cfg *config.Config, store storage.BlobStore, db *sql.DB) {
 	static := router.Group("/")
 	{
 		static.GET("/", handlers.HandleHomePageGin)
 		static.GET("/add-contract", handlers.HandleAddContractPage)
 		static.POST("/submit-contract", func(c *gin.Context) {
-			handlers.HandleContractSubmission(c, nil, database.DB)
+			handlers.HandleContractSubmission(c, cfg, store, db)
+		})
+		static.GET("/add-request", handlers.HandleAddRequestPage)
+		static.POST("/submit-request", func(c *gin.Context) {
+			handlers.HandlePaymentRequestSubmission(c, cfg, store, db)
+		})
+		static.GET("/counterparties", func(c *gin.Context) {
+			handlers.HandleCounterpartiesPage(c, db)
+		})
+		static.GET("/counterparties/:id", func(c *gin.Context) {
+			handlers.HandleCounterpartyPage(c, db)
 		})
 	}
 }
 
 // registerAPIRoutes регистрирует маршруты для API
-func registerAPIRoutes(router *gin.Engine, cfg *config.Config, db *sql.DB) {
+func registerAPIRoutes(router *gin.Engine, cfg *config.Config, store storage.BlobStore, db *sql.DB) {
 	api := router.Group("/api/v1")
 	{
-		// Пример API эндпоинта для получения списка контрагентов
+		// Контрагенты
 		api.GET("/counterparties", func(c *gin.Context) {
 			handlers.HandleCounterpartiesList(c, db)
 		})
+		api.POST("/counterparties", func(c *gin.Context) {
+			handlers.HandleCounterpartyCreate(c, db)
+		})
+		api.POST("/counterparties/egrul", func(c *gin.Context) {
+			handlers.HandleEgrulImport(c, cfg, db)
+		})
+		api.GET("/counterparties/duplicates", func(c *gin.Context) {
+			handlers.HandleCounterpartyDuplicates(c, db)
+		})
+		api.GET("/counterparties/:id", func(c *gin.Context) {
+			handlers.HandleCounterpartyGet(c, db)
+		})
+		api.GET("/counterparties/:id/overview", func(c *gin.Context) {
+			handlers.HandleCounterpartyOverview(c, db)
+		})
+		api.PUT("/counterparties/:id", func(c *gin.Context) {
+			handlers.HandleCounterpartyUpdate(c, db)
+		})
+		api.DELETE("/counterparties/:id", func(c *gin.Context) {
+			handlers.HandleCounterpartyDelete(c, db)
+		})
+		api.POST("/counterparties/:id/merge", func(c *gin.Context) {
+			handlers.HandleCounterpartyMerge(c, db)
+		})
+		api.GET("/counterparties/:id/merges", func(c *gin.Context) {
+			handlers.HandleCounterpartyMerges(c, db)
+		})
+		api.GET("/counterparties/:id/accounts", func(c *gin.Context) {
+			handlers.HandleCounterpartyAccounts(c, db)
+		})
+
+		// Контракты
+		api.GET("/contracts", func(c *gin.Context) {
+			handlers.HandleContractsList(c, db)
+		})
+		api.POST("/contracts", func(c *gin.Context) {
+			handlers.HandleContractCreate(c, db)
+		})
+		api.POST("/contracts/import", func(c *gin.Context) {
+			handlers.HandleContractRegistryImport(c, cfg, db)
+		})
+		api.POST("/contracts/import-eis", func(c *gin.Context) {
+			handlers.HandleContractEISImport(c, cfg, db)
+		})
+		api.GET("/contracts/:id", func(c *gin.Context) {
+			handlers.HandleContractGet(c, db)
+		})
+		api.PUT("/contracts/:id", func(c *gin.Context) {
+			handlers.HandleContractUpdate(c, db)
+		})
+		api.DELETE("/contracts/:id", func(c *gin.Context) {
+			handlers.HandleContractDelete(c, db)
+		})
+		api.GET("/contracts/:id/documents", func(c *gin.Context) {
+			handlers.HandleContractDocuments(c, db)
+		})
+		api.POST("/contracts/:id/documents", func(c *gin.Context) {
+			handlers.HandleContractDocumentAdd(c, cfg, store, db)
+		})
+		api.GET("/contracts/:id/documents/:doc", func(c *gin.Context) {
+			handlers.HandleContractDocumentDownload(c, store, db)
+		})
+		api.GET("/contracts/:id/documents/:doc/versions", func(c *gin.Context) {
+			handlers.HandleContractDocumentVersions(c, db)
+		})
+		api.POST("/contracts/:id/documents/:doc/versions", func(c *gin.Context) {
+			handlers.HandleContractDocumentReplace(c, cfg, store, db)
+		})
+		api.GET("/contracts/:id/signature", func(c *gin.Context) {
+			handlers.HandleContractSignature(c, db)
+		})
+		api.POST("/contracts/:id/signature/verify", func(c *gin.Context) {
+			handlers.HandleContractSignatureVerify(c, store, db)
+		})
+		api.GET("/contracts/:id/archive", func(c *gin.Context) {
+			handlers.HandleContractDocumentsArchive(c, store, db)
+		})
+		api.GET("/contracts/:id/payments", func(c *gin.Context) {
+			handlers.HandleContractPayments(c, db)
+		})
+		api.GET("/contracts/:id/amendments", func(c *gin.Context) {
+			handlers.HandleContractAmendments(c, db)
+		})
+		api.POST("/contracts/:id/amendments", func(c *gin.Context) {
+			handlers.HandleContractAmendmentAdd(c, db)
+		})
+		api.GET("/contracts/:id/eaist-status", func(c *gin.Context) {
+			handlers.HandleContractEaistStatus(c, db)
+		})
+		api.POST("/contracts/:id/eaist-status", func(c *gin.Context) {
+			handlers.HandleContractEaistStatusChange(c, db)
+		})
+		api.GET("/contracts/:id/schedule", func(c *gin.Context) {
+			handlers.HandleContractSchedule(c, db)
+		})
+		api.PUT("/contracts/:id/schedule", func(c *gin.Context) {
+			handlers.HandleContractScheduleSave(c, db)
+		})
+		api.GET("/payment-forecast", func(c *gin.Context) {
+			handlers.HandlePaymentForecast(c, db)
+		})
+
+		// Сопоставление транзакций с контрактами
+		api.POST("/contract-matches/run", func(c *gin.Context) {
+			handlers.HandleContractMatchRun(c, db)
+		})
+		api.GET("/contract-matches/reviews", func(c *gin.Context) {
+			handlers.HandleMatchReviewsList(c, db)
+		})
+		api.POST("/contract-matches/reviews/:id/link", func(c *gin.Context) {
+			handlers.HandleMatchReviewLink(c, db)
+		})
+		api.POST("/contract-matches/reviews/:id/dismiss", func(c *gin.Context) {
+			handlers.HandleMatchReviewDismiss(c, db)
+		})
+
+		// Распределение сумм транзакций по контрактам и заявкам на оплату
+		api.GET("/transactions/:id/allocations", func(c *gin.Context) {
+			handlers.HandleTransactionAllocations(c, db)
+		})
+		api.PUT("/transactions/:id/allocations", func(c *gin.Context) {
+			handlers.HandleTransactionAllocate(c, db)
+		})
+		api.GET("/transactions/:id/allocations/history", func(c *gin.Context) {
+			handlers.HandleTransactionAllocationHistory(c, db)
+		})
+
+		// Заявки на оплату
+		api.GET("/payment-requests", func(c *gin.Context) {
+			handlers.HandlePaymentRequestsList(c, db)
+		})
+		api.GET("/payment-requests/:id", func(c *gin.Context) {
+			handlers.HandlePaymentRequestGet(c, db)
+		})
+		api.GET("/payment-requests/:id/documents/:doc", func(c *gin.Context) {
+			handlers.HandlePaymentRequestDocumentDownload(c, store, db)
+		})
+		api.GET("/payment-requests/:id/approval", func(c *gin.Context) {
+			handlers.HandlePaymentRequestApproval(c, db)
+		})
+		api.POST("/payment-requests/:id/approval", func(c *gin.Context) {
+			handlers.HandlePaymentRequestDecision(c, cfg, db)
+		})
+
+		// Оповещения о платежах контрагентам на новые счета
+		api.GET("/account-alerts", func(c *gin.Context) {
+			handlers.HandleAccountAlertsList(c, db)
+		})
+		api.POST("/account-alerts/:id/resolve", func(c *gin.Context) {
+			handlers.HandleAccountAlertResolve(c, db)
+		})
 	}
 }
 
 // registerFileUploadRoutes регистрирует маршруты для загрузки файлов
-func registerFileUploadRoutes(router *gin.Engine, cfg *config.Config) {
+func registerFileUploadRoutes(router *gin.Engine, cfg *config.Config, store storage.BlobStore) {
 	upload := router.Group("/upload")
 	{
 		upload.POST("/", func(c *gin.Context) {
-			handlers.HandleFileUploadGin(c, cfg)
+			handlers.HandleFileUploadGin(c, cfg, store)
 		})
 	}
 }
 
-// registerDownloadRoutes регистрирует маршрут для скачивания Excel-файлов
+// registerDownloadRoutes регистрирует маршруты для скачивания Excel-файлов
 func registerDownloadRoutes(router *gin.Engine) {
 	router.GET("/download", handlers.HandleDownloadTransactionsExcel)
+	router.GET("/download/contracts", handlers.HandleDownloadContractsExcel)
+	router.GET("/download/payment-forecast", handlers.HandleDownloadPaymentForecastExcel)
 }
@@ -0,0 +1,100 @@
+package signature
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Certificate — сведения о сертификате подписанта
+type Certificate struct {
+	Subject      string // Владелец сертификата: все атрибуты имени
+	CommonName   string
+	Organization string
+	INN          string // ИНН организации, для ИП и физических лиц — ИНН владельца
+	OGRN         string // ОГРН или ОГРНИП
+	SerialNumber string // Серийный номер в шестнадцатеричном виде
+	Issuer       string
+	NotBefore    time.Time
+	NotAfter     time.Time
+}
+
+// OID атрибутов имени из квалифицированных сертификатов (приказ ФСБ России № 795)
+const (
+	oidINN    = "1.2.643.3.131.1.1"
+	oidINNLE  = "1.2.643.100.4"
+	oidOGRN   = "1.2.643.100.1"
+	oidOGRNIP = "1.2.643.100.5"
+	oidSNILS  = "1.2.643.100.3"
+)
+
+// nameLabels — обозначения атрибутов имени для строкового представления
+var nameLabels = map[string]string{
+	"2.5.4.3":              "CN",
+	"2.5.4.4":              "SN",
+	"2.5.4.42":             "G",
+	"2.5.4.12":             "T",
+	"2.5.4.10":             "O",
+	"2.5.4.11":             "OU",
+	"2.5.4.9":              "STREET",
+	"2.5.4.7":              "L",
+	"2.5.4.8":              "S",
+	"2.5.4.6":              "C",
+	"1.2.840.113549.1.9.1": "E",
+	oidINN:                 "ИНН",
+	oidINNLE:               "ИНН ЮЛ",
+	oidOGRN:                "ОГРН",
+	oidOGRNIP:              "ОГРНИП",
+	oidSNILS:               "СНИЛС",
+}
+
+// describeCertificate извлекает из сертификата сведения о владельце и сроке действия
+func describeCertificate(cert *x509.Certificate) *Certificate {
+	c := &Certificate{
+		Subject:      describeName(cert.Subject),
+		CommonName:   cert.Subject.CommonName,
+		Organization: strings.Join(cert.Subject.Organization, ", "),
+		SerialNumber: fmt.Sprintf("%X", cert.SerialNumber),
+		Issuer:       describeName(cert.Issuer),
+		NotBefore:    cert.NotBefore,
+		NotAfter:     cert.NotAfter,
+	}
+
+	var inn, innLE string
+	for _, attr := range cert.Subject.Names {
+		value := strings.TrimSpace(fmt.Sprint(attr.Value))
+		switch attr.Type.String() {
+		case oidINN:
+			inn = value
+		case oidINNLE:
+			innLE = value
+		case oidOGRN, oidOGRNIP:
+			c.OGRN = value
+		}
+	}
+
+	// До 2021 года ИНН организации записывался в атрибут ИНН, дополненный слева нулями до 12 цифр
+	c.INN = innLE
+	if c.INN == "" {
+		c.INN = inn
+		if len(inn) == 12 && strings.HasPrefix(inn, "00") {
+			c.INN = inn[2:]
+		}
+	}
+	return c
+}
+
+// describeName формирует строку из атрибутов имени в порядке их следования в сертификате
+func describeName(name pkix.Name) string {
+	parts := make([]string, 0, len(name.Names))
+	for _, attr := range name.Names {
+		label, ok := nameLabels[attr.Type.String()]
+		if !ok {
+			label = attr.Type.String()
+		}
+		parts = append(parts, label+"="+strings.TrimSpace(fmt.Sprint(attr.Value)))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,221 @@
+package signature
+
+import (
+	"bytes"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// ErrMalformed возвращается, если файл не является подписью CMS/PKCS#7
+var ErrMalformed = errors.New("файл не является подписью CMS/PKCS#7")
+
+var (
+	oidSignedData    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidSigningTime   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 5}
+)
+
+// contentInfo — внешняя структура CMS (RFC 5652, раздел 3)
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,tag:0"`
+}
+
+// signedData — SignedData (RFC 5652, раздел 5.1). Сертификаты и списки отзыва читаются как есть:
+// поле asn1.RawValue с необязательным тегом совпало бы с любым следующим элементом
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo encapsulatedContentInfo
+	Certificates     rawContent   `asn1:"optional,tag:0"`
+	CRLs             rawContent   `asn1:"optional,tag:1"`
+	SignerInfos      []signerInfo `asn1:"set"`
+}
+
+// encapsulatedContentInfo — подписанные данные; у отсоединённой подписи eContent отсутствует
+type encapsulatedContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"optional,explicit,tag:0"`
+}
+
+// signerInfo — сведения о подписанте (RFC 5652, раздел 5.3)
+type signerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        rawContent `asn1:"optional,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+	UnsignedAttrs      rawContent `asn1:"optional,tag:1"`
+}
+
+// rawContent сохраняет элемент целиком вместе с тегом
+type rawContent struct {
+	Raw asn1.RawContent
+}
+
+// issuerAndSerialNumber идентифицирует сертификат подписанта по издателю и серийному номеру
+type issuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+// attribute — атрибут подписанта, например messageDigest или signingTime
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue `asn1:"set"`
+}
+
+// signer — разобранные сведения об одном подписанте
+type signer struct {
+	info        signerInfo
+	certificate *x509.Certificate
+	signedAttrs []byte // Подписанные атрибуты в DER с тегом SET — именно они хешируются при подписи
+	digest      []byte // Значение атрибута messageDigest
+	signingTime time.Time
+}
+
+// MIMEType — тип файла отсоединённой подписи CMS/PKCS#7
+const MIMEType = "application/pkcs7-signature"
+
+// Check проверяет, что data — подпись CMS/PKCS#7, которую разберёт Verify: в DER, PEM или Base64.
+// Определение типа по содержимому распознаёт только DER и PEM с заголовком PKCS7, поэтому файлы подписей проверяются так
+func Check(data []byte) error {
+	_, err := parse(data)
+	return err
+}
+
+// parse разбирает подпись в DER, PEM или Base64 и возвращает подписантов с их сертификатами
+func parse(data []byte) ([]signer, error) {
+	der, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var ci contentInfo
+	if rest, err := asn1.Unmarshal(der, &ci); err != nil || len(bytes.TrimRight(rest, "\x00 \t\r\n")) > 0 {
+		return nil, ErrMalformed
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		return nil, fmt.Errorf("%w: тип содержимого %s вместо SignedData", ErrMalformed, ci.ContentType)
+	}
+	var sd signedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		return nil, ErrMalformed
+	}
+	if len(sd.SignerInfos) == 0 {
+		return nil, fmt.Errorf("%w: в подписи нет подписантов", ErrMalformed)
+	}
+
+	certificates := parseCertificates(sd.Certificates.Raw)
+	signers := make([]signer, 0, len(sd.SignerInfos))
+	for _, info := range sd.SignerInfos {
+		s := signer{info: info, certificate: findCertificate(certificates, info.SID)}
+		if len(info.SignedAttrs.Raw) > 0 {
+			if err := s.parseSignedAttrs(); err != nil {
+				return nil, err
+			}
+		}
+		signers = append(signers, s)
+	}
+	return signers, nil
+}
+
+// decode приводит подпись к DER. Криптопровайдеры сохраняют .sig как в двоичном виде, так и в Base64 с заголовками PEM или без них
+func decode(data []byte) ([]byte, error) {
+	// Справа DER не обрезается: последние байты подписи могут совпасть с пробельными символами.
+	// Переводы строк после двоичной подписи пропускает parse
+	if der := bytes.TrimLeft(data, " \t\r\n"); len(der) > 0 && der[0] == 0x30 {
+		return der, nil
+	}
+	data = bytes.TrimSpace(data)
+	if block, _ := pem.Decode(data); block != nil {
+		return block.Bytes, nil
+	}
+	der, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(string(data)), ""))
+	if err != nil || len(der) == 0 || der[0] != 0x30 {
+		return nil, ErrMalformed
+	}
+	return der, nil
+}
+
+// parseCertificates разбирает набор сертификатов из подписи. Нераспознанные сертификаты пропускаются,
+// чтобы посторонний сертификат цепочки не мешал найти сертификат подписанта
+func parseCertificates(raw []byte) []*x509.Certificate {
+	if len(raw) == 0 {
+		return nil
+	}
+	var set asn1.RawValue
+	if _, err := asn1.Unmarshal(raw, &set); err != nil {
+		return nil
+	}
+
+	var certificates []*x509.Certificate
+	for rest := set.Bytes; len(rest) > 0; {
+		var element asn1.RawValue
+		var err error
+		if rest, err = asn1.Unmarshal(rest, &element); err != nil {
+			break
+		}
+		if cert, err := x509.ParseCertificate(element.FullBytes); err == nil {
+			certificates = append(certificates, cert)
+		}
+	}
+	return certificates
+}
+
+// findCertificate находит сертификат подписанта по издателю и серийному номеру или по идентификатору ключа
+func findCertificate(certificates []*x509.Certificate, sid asn1.RawValue) *x509.Certificate {
+	switch {
+	case sid.Class == asn1.ClassUniversal && sid.Tag == asn1.TagSequence:
+		var ias issuerAndSerialNumber
+		if _, err := asn1.Unmarshal(sid.FullBytes, &ias); err != nil {
+			return nil
+		}
+		for _, cert := range certificates {
+			if cert.SerialNumber.Cmp(ias.SerialNumber) == 0 && bytes.Equal(cert.RawIssuer, ias.Issuer.FullBytes) {
+				return cert
+			}
+		}
+	case sid.Class == asn1.ClassContextSpecific && sid.Tag == 0:
+		for _, cert := range certificates {
+			if len(cert.SubjectKeyId) > 0 && bytes.Equal(cert.SubjectKeyId, sid.Bytes) {
+				return cert
+			}
+		}
+	}
+	return nil
+}
+
+// parseSignedAttrs читает подписанные атрибуты. Подписывается их кодировка с тегом SET вместо [0] IMPLICIT
+func (s *signer) parseSignedAttrs() error {
+	s.signedAttrs = append([]byte{0x31}, s.info.SignedAttrs.Raw[1:]...)
+
+	var attrs []attribute
+	if _, err := asn1.UnmarshalWithParams(s.signedAttrs, &attrs, "set"); err != nil {
+		return fmt.Errorf("%w: некорректные подписанные атрибуты: %v", ErrMalformed, err)
+	}
+	for _, attr := range attrs {
+		switch {
+		case attr.Type.Equal(oidMessageDigest):
+			if _, err := asn1.Unmarshal(attr.Values.Bytes, &s.digest); err != nil {
+				return fmt.Errorf("%w: некорректный атрибут messageDigest", ErrMalformed)
+			}
+		case attr.Type.Equal(oidSigningTime):
+			// Время подписания необязательно, ошибка его разбора не делает подпись недействительной
+			_, _ = asn1.Unmarshal(attr.Values.Bytes, &s.signingTime)
+		}
+	}
+	if s.digest == nil {
+		return fmt.Errorf("%w: нет атрибута messageDigest", ErrMalformed)
+	}
+	return nil
+}
@@ -0,0 +1,318 @@
+package signature
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"hash"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Подписи для тестов формируются так же, как их создают криптопровайдеры: отсоединённая подпись CMS
+// с подписанными атрибутами contentType, messageDigest и signingTime и сертификатом подписанта внутри
+
+var (
+	oidData        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidContentType = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidSHA256      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSHA256RSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}
+	oidECDSASHA256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+	oidStreebog256 = asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 2, 2}
+	oidGOST2012256 = asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 3, 2}
+	oidGOST94      = asn1.ObjectIdentifier{1, 2, 643, 2, 2, 9}
+)
+
+// testSigner — ключ и сертификат подписанта
+type testSigner struct {
+	key  crypto.Signer
+	cert *x509.Certificate
+}
+
+// signOptions — параметры формируемой подписи
+type signOptions struct {
+	digestOID    asn1.ObjectIdentifier
+	signatureOID asn1.ObjectIdentifier
+	newHash      func() hash.Hash
+	signingTime  time.Time
+	noAttrs      bool // Подписать хеш файла без подписанных атрибутов
+	bySKI        bool // Указать подписанта идентификатором ключа вместо издателя и серийного номера
+	noCert       bool // Не включать сертификат подписанта
+}
+
+func newRSASigner(t *testing.T, notBefore, notAfter time.Time) testSigner {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testSigner{key: key, cert: newTestCertificate(t, key, notBefore, notAfter)}
+}
+
+func newECDSASigner(t *testing.T, notBefore, notAfter time.Time) testSigner {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testSigner{key: key, cert: newTestCertificate(t, key, notBefore, notAfter)}
+}
+
+// newTestCertificate выпускает самоподписанный сертификат с атрибутами квалифицированного сертификата организации
+func newTestCertificate(t *testing.T, key crypto.Signer, notBefore, notAfter time.Time) *x509.Certificate {
+	t.Helper()
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(0x1A2B3C),
+		Subject: pkix.Name{
+			CommonName:   "Иванов Иван Иванович",
+			Organization: []string{`ООО "Ромашка"`},
+			ExtraNames: []pkix.AttributeTypeAndValue{
+				{Type: asn1.ObjectIdentifier{1, 2, 643, 3, 131, 1, 1}, Value: "007701234567"},
+				{Type: asn1.ObjectIdentifier{1, 2, 643, 100, 1}, Value: "1027700132195"},
+			},
+		},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		SubjectKeyId: []byte{1, 2, 3, 4, 5, 6, 7, 8},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+// Структуры для формирования подписи: элементы, уже закодированные в DER, передаются через asn1.RawValue
+type testContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue // [0] EXPLICIT: FullBytes записываются как есть, поэтому тег задаётся явно через explicitContent
+}
+
+type testSignedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo struct{ ContentType asn1.ObjectIdentifier }
+	Certificates     asn1.RawValue    `asn1:"optional"`
+	SignerInfos      []testSignerInfo `asn1:"set"`
+}
+
+type testSignerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue `asn1:"optional"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+}
+
+type testAttribute struct {
+	Type   asn1.ObjectIdentifier
+	Values []asn1.RawValue `asn1:"set"`
+}
+
+// sign формирует отсоединённую подпись content в DER
+func (s testSigner) sign(t *testing.T, content []byte, opts signOptions) []byte {
+	t.Helper()
+	if opts.newHash == nil {
+		opts.digestOID, opts.newHash = oidSHA256, sha256.New
+	}
+	if opts.signatureOID == nil {
+		opts.signatureOID = oidSHA256RSA
+		if _, ok := s.key.(*ecdsa.PrivateKey); ok {
+			opts.signatureOID = oidECDSASHA256
+		}
+	}
+	if opts.signingTime.IsZero() {
+		opts.signingTime = time.Now().UTC().Truncate(time.Second)
+	}
+
+	h := opts.newHash()
+	h.Write(content)
+	digest := h.Sum(nil)
+
+	info := testSignerInfo{
+		Version:            1,
+		SID:                asn1.RawValue{FullBytes: mustMarshal(t, issuerAndSerialNumber{Issuer: asn1.RawValue{FullBytes: s.cert.RawIssuer}, SerialNumber: s.cert.SerialNumber})},
+		DigestAlgorithm:    pkix.AlgorithmIdentifier{Algorithm: opts.digestOID},
+		SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: opts.signatureOID},
+	}
+	if opts.bySKI {
+		info.Version = 3
+		info.SID = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, Bytes: s.cert.SubjectKeyId}
+	}
+
+	if !opts.noAttrs {
+		attrs := []testAttribute{
+			{Type: oidContentType, Values: []asn1.RawValue{{FullBytes: mustMarshal(t, oidData)}}},
+			{Type: oidSigningTime, Values: []asn1.RawValue{{FullBytes: mustMarshal(t, opts.signingTime)}}},
+			{Type: oidMessageDigest, Values: []asn1.RawValue{{FullBytes: mustMarshal(t, digest)}}},
+		}
+		signedAttrs, err := asn1.MarshalWithParams(attrs, "set")
+		if err != nil {
+			t.Fatal(err)
+		}
+		// В SignerInfo атрибуты записываются с тегом [0] IMPLICIT, а подписывается их кодировка с тегом SET
+		info.SignedAttrs = asn1.RawValue{FullBytes: append([]byte{0xA0}, signedAttrs[1:]...)}
+		h := opts.newHash()
+		h.Write(signedAttrs)
+		digest = h.Sum(nil)
+	}
+
+	var err error
+	switch key := s.key.(type) {
+	case *rsa.PrivateKey:
+		if opts.signatureOID.Equal(oidSHA256RSA) {
+			info.Signature, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest)
+		} else {
+			// Подпись ГОСТ здесь не сформировать; проверка такой подписи всё равно не выполняется
+			info.Signature = make([]byte, 64)
+		}
+	case *ecdsa.PrivateKey:
+		info.Signature, err = ecdsa.SignASN1(rand.Reader, key, digest)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sd := testSignedData{
+		Version:          1,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{{Algorithm: opts.digestOID}},
+		SignerInfos:      []testSignerInfo{info},
+	}
+	sd.EncapContentInfo.ContentType = oidData
+	if !opts.noCert {
+		sd.Certificates = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: s.cert.Raw}
+	}
+	return mustMarshal(t, testContentInfo{
+		ContentType: oidSignedData,
+		Content:     explicitContent(mustMarshal(t, sd)),
+	})
+}
+
+// explicitContent оборачивает элемент в тег [0] EXPLICIT
+func explicitContent(der []byte) asn1.RawValue {
+	return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: der}
+}
+
+func mustMarshal(t *testing.T, v any) []byte {
+	t.Helper()
+	der, err := asn1.Marshal(v)
+	if err != nil {
+		t.Fatalf("asn1.Marshal(%T): %v", v, err)
+	}
+	return der
+}
+
+func TestParse(t *testing.T) {
+	now := time.Now()
+	signer := newRSASigner(t, now.Add(-time.Hour), now.Add(24*time.Hour))
+	signingTime := now.UTC().Add(-time.Minute).Truncate(time.Second)
+	der := signer.sign(t, []byte("договор"), signOptions{signingTime: signingTime})
+
+	b64 := base64.StdEncoding.EncodeToString(der)
+	var wrapped strings.Builder
+	for i := 0; i < len(b64); i += 64 {
+		wrapped.WriteString(b64[i:min(i+64, len(b64))] + "\r\n")
+	}
+
+	inputs := map[string][]byte{
+		"DER":                  der,
+		"DER с пробелами":      append(append([]byte("\n"), der...), '\n'),
+		"PEM":                  pem.EncodeToMemory(&pem.Block{Type: "PKCS7", Bytes: der}),
+		"PEM CMS":              pem.EncodeToMemory(&pem.Block{Type: "CMS", Bytes: der}),
+		"Base64":               []byte(b64),
+		"Base64 с переносами":  []byte(wrapped.String()),
+		"DER с нулями в конце": append(append([]byte{}, der...), 0, 0, 0),
+		"Base64 с пробелом в начале": []byte("  " + b64),
+	}
+	for name, data := range inputs {
+		t.Run(name, func(t *testing.T) {
+			signers, err := parse(data)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if len(signers) != 1 {
+				t.Fatalf("подписантов %d, ожидался 1", len(signers))
+			}
+			s := signers[0]
+			if s.certificate == nil || !s.certificate.Equal(signer.cert) {
+				t.Error("сертификат подписанта не найден")
+			}
+			if len(s.digest) != sha256.Size {
+				t.Errorf("messageDigest длиной %d байт", len(s.digest))
+			}
+			if !s.signingTime.Equal(signingTime) {
+				t.Errorf("signingTime %v, ожидалось %v", s.signingTime, signingTime)
+			}
+		})
+	}
+}
+
+func TestParseSubjectKeyIdentifier(t *testing.T) {
+	now := time.Now()
+	signer := newECDSASigner(t, now.Add(-time.Hour), now.Add(time.Hour))
+	signers, err := parse(signer.sign(t, []byte("договор"), signOptions{bySKI: true}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signers[0].certificate == nil || !signers[0].certificate.Equal(signer.cert) {
+		t.Error("сертификат подписанта не найден по идентификатору ключа")
+	}
+}
+
+func TestParseMalformed(t *testing.T) {
+	now := time.Now()
+	signer := newRSASigner(t, now.Add(-time.Hour), now.Add(time.Hour))
+	der := signer.sign(t, []byte("договор"), signOptions{})
+
+	noSigners := mustMarshal(t, testContentInfo{
+		ContentType: oidSignedData,
+		Content: explicitContent(mustMarshal(t, testSignedData{
+			Version:          1,
+			DigestAlgorithms: []pkix.AlgorithmIdentifier{{Algorithm: oidSHA256}},
+			EncapContentInfo: struct{ ContentType asn1.ObjectIdentifier }{oidData},
+			SignerInfos:      []testSignerInfo{},
+		})),
+	})
+	notSignedData := mustMarshal(t, testContentInfo{
+		ContentType: oidData,
+		Content:     explicitContent(mustMarshal(t, []byte("договор"))),
+	})
+
+	inputs := map[string][]byte{
+		"пустой файл":          nil,
+		"пробелы":              []byte(" \r\n\t"),
+		"текст":                []byte("Это не подпись"),
+		"PDF":                  []byte("%PDF-1.7\n%âãÏÓ\n1 0 obj"),
+		"обрезанный DER":       der[:len(der)/2],
+		"данные после подписи": append(append([]byte{}, der...), 0x30, 0x00),
+		"Base64 не DER":        []byte(base64.StdEncoding.EncodeToString([]byte("просто текст"))),
+		"повреждённый PEM":     []byte("-----BEGIN PKCS7-----\n!!!\n-----END PKCS7-----\n"),
+		"сертификат вместо подписи": signer.cert.Raw,
+		"не SignedData":   notSignedData,
+		"без подписантов": noSigners,
+	}
+	for name, data := range inputs {
+		t.Run(name, func(t *testing.T) {
+			if _, err := parse(data); !errors.Is(err, ErrMalformed) {
+				t.Errorf("ожидалась ErrMalformed, получено %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package signature
+
+import (
+	"bytes"
+	"encoding/hex"
+	"hash"
+	"testing"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Контрольные примеры RFC 6986, раздел 10. Хеши записаны младшим байтом вперёд, как их выдают криптопровайдеры
+func TestStreebog(t *testing.T) {
+	m2, err := charmap.Windows1251.NewEncoder().String("Се ветри, Стрибожи внуци, веютъ с моря стрелами на храбрыя плъкы Игоревы")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		message string
+		newHash func() hash.Hash
+		want    string
+	}{
+		{"M1, 512 бит", "012345678901234567890123456789012345678901234567890123456789012", newStreebog512,
+			"1b54d01a4af5b9d5cc3d86d68d285462b19abc2475222f35c085122be4ba1ffa00ad30f8767b3a82384c6574f024c311e2a481332b08ef7f41797891c1646f48"},
+		{"M1, 256 бит", "012345678901234567890123456789012345678901234567890123456789012", newStreebog256,
+			"9d151eefd8590b89daa6ba6cb74af9275dd051026bb149a452fd84e5e57b5500"},
+		{"M2, 512 бит", m2, newStreebog512,
+			"1e88e62226bfca6f9994f1f2d51569e0daf8475a3b0fe61a5300eee46d961376035fe83549ada2b8620fcd7c496ce5b33f0cb9dddc2b6460143b03dabac9fb28"},
+		{"M2, 256 бит", m2, newStreebog256,
+			"9dd2fe4e90409e5da87f53976d7405b0c0cac628fc669a741d50063c557e8f50"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := tt.newHash()
+			h.Write([]byte(tt.message))
+			if got := hex.EncodeToString(h.Sum(nil)); got != tt.want {
+				t.Errorf("получено %s, ожидалось %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// Результат не зависит от того, какими частями записываются данные, а Sum не меняет состояние
+func TestStreebogStreaming(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789abcdef"), 50)
+	whole := newStreebog512()
+	whole.Write(data)
+	want := whole.Sum(nil)
+
+	for _, chunk := range []int{1, 7, 63, 64, 65, 128} {
+		h := newStreebog512()
+		for rest := data; len(rest) > 0; {
+			n := min(chunk, len(rest))
+			h.Write(rest[:n])
+			h.Sum(nil)
+			rest = rest[n:]
+		}
+		if got := h.Sum(nil); !bytes.Equal(got, want) {
+			t.Errorf("запись частями по %d байт: %x, ожидалось %x", chunk, got, want)
+		}
+	}
+
+	whole.Reset()
+	whole.Write(data)
+	if got := whole.Sum(nil); !bytes.Equal(got, want) {
+		t.Errorf("после Reset: %x, ожидалось %x", got, want)
+	}
+}
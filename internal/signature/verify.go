@@ -0,0 +1,258 @@
+package signature
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"time"
+
+	// Регистрация алгоритмов хеширования для crypto.Hash.New
+	_ "crypto/sha1"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+)
+
+// Status — итог проверки подписи
+type Status string
+
+const (
+	// StatusValid — подпись относится к файлу, математически верна, сертификат действует
+	StatusValid Status = "valid"
+	// StatusExpired — подпись верна, но сертификат истёк, ещё не начал действовать или не действовал на момент подписания
+	StatusExpired Status = "expired"
+	// StatusInvalid — подпись не соответствует файлу или не прошла проверку
+	StatusInvalid Status = "invalid"
+	// StatusUnsupported — алгоритм подписи не поддерживается, соответствие файлу не проверено
+	StatusUnsupported Status = "unsupported"
+	// StatusMalformed — файл подписи не удалось разобрать
+	StatusMalformed Status = "malformed"
+)
+
+// severity задаёт порядок статусов при сведении результатов нескольких подписантов: побеждает худший
+var severity = map[Status]int{
+	StatusValid:       0,
+	StatusUnsupported: 1,
+	StatusExpired:     2,
+	StatusInvalid:     3,
+	StatusMalformed:   4,
+}
+
+// Result — результат проверки отсоединённой подписи
+type Result struct {
+	Status  Status
+	Message string
+	Signers []SignerResult
+}
+
+// SignerResult — результат проверки подписи одного подписанта
+type SignerResult struct {
+	Status             Status
+	Message            string
+	ContentMatch       *bool // Подпись относится к файлу; nil, если проверить не удалось
+	SignatureValid     *bool // Подпись математически верна; nil, если алгоритм не поддерживается
+	CertificateExpired bool  // Сертификат не действует на момент проверки или не действовал на момент подписания
+	Certificate        *Certificate
+	SigningTime        time.Time // Время подписания из атрибута signingTime, если он есть
+	DigestAlgorithm    string
+	SignatureAlgorithm string
+}
+
+// digestAlgorithms сопоставляет OID алгоритмов хеширования функциям стандартной библиотеки
+var digestAlgorithms = map[string]crypto.Hash{
+	"1.3.14.3.2.26":          crypto.SHA1,
+	"2.16.840.1.101.3.4.2.1": crypto.SHA256,
+	"2.16.840.1.101.3.4.2.2": crypto.SHA384,
+	"2.16.840.1.101.3.4.2.3": crypto.SHA512,
+}
+
+// gostDigestAlgorithms — алгоритмы хеширования ГОСТ Р 34.11-2012. Ими проверяется только соответствие подписи файлу:
+// алгоритмы подписи ГОСТ Р 34.10 не реализованы
+var gostDigestAlgorithms = map[string]func() hash.Hash{
+	"1.2.643.7.1.1.2.2": newStreebog256,
+	"1.2.643.7.1.1.2.3": newStreebog512,
+}
+
+// algorithmNames — названия алгоритмов хеширования и подписи для отчёта
+var algorithmNames = map[string]string{
+	"1.3.14.3.2.26":          "SHA-1",
+	"2.16.840.1.101.3.4.2.1": "SHA-256",
+	"2.16.840.1.101.3.4.2.2": "SHA-384",
+	"2.16.840.1.101.3.4.2.3": "SHA-512",
+	"1.2.643.2.2.9":          "ГОСТ Р 34.11-94",
+	"1.2.643.7.1.1.2.2":      "ГОСТ Р 34.11-2012 (256 бит)",
+	"1.2.643.7.1.1.2.3":      "ГОСТ Р 34.11-2012 (512 бит)",
+	"1.2.840.113549.1.1.1":   "RSA",
+	"1.2.840.113549.1.1.5":   "SHA-1 с RSA",
+	"1.2.840.113549.1.1.10":  "RSA-PSS",
+	"1.2.840.113549.1.1.11":  "SHA-256 с RSA",
+	"1.2.840.113549.1.1.12":  "SHA-384 с RSA",
+	"1.2.840.113549.1.1.13":  "SHA-512 с RSA",
+	"1.2.840.10045.2.1":      "ECDSA",
+	"1.2.840.10045.4.3.2":    "ECDSA с SHA-256",
+	"1.2.840.10045.4.3.3":    "ECDSA с SHA-384",
+	"1.2.840.10045.4.3.4":    "ECDSA с SHA-512",
+	"1.2.643.2.2.19":         "ГОСТ Р 34.10-2001",
+	"1.2.643.2.2.3":          "ГОСТ Р 34.10-2001 с ГОСТ Р 34.11-94",
+	"1.2.643.7.1.1.1.1":      "ГОСТ Р 34.10-2012 (256 бит)",
+	"1.2.643.7.1.1.1.2":      "ГОСТ Р 34.10-2012 (512 бит)",
+	"1.2.643.7.1.1.3.2":      "ГОСТ Р 34.10-2012 с ГОСТ Р 34.11-2012 (256 бит)",
+	"1.2.643.7.1.1.3.3":      "ГОСТ Р 34.10-2012 с ГОСТ Р 34.11-2012 (512 бит)",
+}
+
+// oidRSAPSS — RSA-PSS требует параметров, которые здесь не разбираются
+var oidRSAPSS = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 10}
+
+// Verify проверяет отсоединённую подпись sig для содержимого content на момент now.
+// Для каждого подписанта проверяется, что хеш файла совпадает с подписанным, подпись верна ключом
+// из сертификата и сертификат действует. Для подписей ГОСТ проверяется только совпадение хеша
+// ГОСТ Р 34.11-2012 с подписанным: подпись ГОСТ Р 34.10 не проверяется, и статус такой подписи — unsupported.
+// Ошибка возвращается только при сбое чтения content; непригодная подпись даёт статус malformed
+func Verify(sig []byte, content io.Reader, now time.Time) (Result, error) {
+	signers, err := parse(sig)
+	if err != nil {
+		return Result{Status: StatusMalformed, Message: "Не удалось разобрать файл подписи: " + err.Error()}, nil
+	}
+
+	// Файл читается один раз, хеши для всех подписантов считаются одновременно
+	hashes := make(map[string]hash.Hash)
+	var writers []io.Writer
+	for _, s := range signers {
+		oid := s.info.DigestAlgorithm.Algorithm.String()
+		if newHash := newDigest(oid); newHash != nil && hashes[oid] == nil {
+			hashes[oid] = newHash()
+			writers = append(writers, hashes[oid])
+		}
+	}
+	if _, err := io.Copy(io.MultiWriter(append(writers, io.Discard)...), content); err != nil {
+		return Result{}, fmt.Errorf("ошибка чтения подписанного файла: %w", err)
+	}
+
+	result := Result{Status: StatusValid}
+	for _, s := range signers {
+		var contentDigest []byte
+		if h, ok := hashes[s.info.DigestAlgorithm.Algorithm.String()]; ok {
+			contentDigest = h.Sum(nil)
+		}
+		sr := verifySigner(s, contentDigest, now)
+		if severity[sr.Status] > severity[result.Status] {
+			result.Status = sr.Status
+			result.Message = sr.Message
+		}
+		result.Signers = append(result.Signers, sr)
+	}
+	return result, nil
+}
+
+// verifySigner проверяет подпись одного подписанта. contentDigest равен nil, если алгоритм хеширования не поддерживается
+func verifySigner(s signer, contentDigest []byte, now time.Time) SignerResult {
+	sr := SignerResult{
+		Status:             StatusValid,
+		SigningTime:        s.signingTime,
+		DigestAlgorithm:    algorithmName(s.info.DigestAlgorithm.Algorithm),
+		SignatureAlgorithm: algorithmName(s.info.SignatureAlgorithm.Algorithm),
+	}
+	if s.certificate == nil {
+		sr.Status, sr.Message = StatusInvalid, "Сертификат подписанта не найден в подписи"
+		return sr
+	}
+	sr.Certificate = describeCertificate(s.certificate)
+
+	// Срок действия сертификата проверяется и для неподдерживаемых алгоритмов
+	cert := s.certificate
+	switch {
+	case !s.signingTime.IsZero() && (s.signingTime.Before(cert.NotBefore) || s.signingTime.After(cert.NotAfter)):
+		sr.CertificateExpired = true
+		sr.Status, sr.Message = StatusExpired, "Сертификат не действовал на момент подписания"
+	case now.After(cert.NotAfter):
+		sr.CertificateExpired = true
+		sr.Status, sr.Message = StatusExpired, "Срок действия сертификата истёк "+cert.NotAfter.Format("02.01.2006")
+	case now.Before(cert.NotBefore):
+		sr.CertificateExpired = true
+		sr.Status, sr.Message = StatusExpired, "Сертификат начинает действовать "+cert.NotBefore.Format("02.01.2006")
+	}
+
+	oid := s.info.DigestAlgorithm.Algorithm.String()
+	newHash := newDigest(oid)
+	if newHash == nil || contentDigest == nil {
+		return unsupported(sr, "Алгоритм хеширования "+sr.DigestAlgorithm+" не поддерживается, соответствие файлу не проверено")
+	}
+
+	// Без подписанных атрибутов подписывается непосредственно хеш файла
+	signedDigest := contentDigest
+	if s.signedAttrs != nil {
+		match := bytes.Equal(s.digest, contentDigest)
+		sr.ContentMatch = &match
+		if !match {
+			sr.Status, sr.Message = StatusInvalid, "Подпись не относится к загруженному файлу контракта"
+			return sr
+		}
+		h := newHash()
+		h.Write(s.signedAttrs)
+		signedDigest = h.Sum(nil)
+	}
+
+	hashFunc, ok := digestAlgorithms[oid]
+	if !ok {
+		// Хеш ГОСТ совпал с подписанным, но саму подпись ГОСТ Р 34.10 проверить нечем
+		if sr.ContentMatch != nil {
+			return unsupported(sr, "Подпись относится к файлу, но алгоритм подписи "+sr.SignatureAlgorithm+" не поддерживается, подпись не проверена")
+		}
+		return unsupported(sr, "Алгоритм подписи "+sr.SignatureAlgorithm+" не поддерживается, соответствие файлу не проверено")
+	}
+
+	var err error
+	switch pub := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		if s.info.SignatureAlgorithm.Algorithm.Equal(oidRSAPSS) {
+			return unsupported(sr, "Алгоритм подписи RSA-PSS не поддерживается")
+		}
+		err = rsa.VerifyPKCS1v15(pub, hashFunc, signedDigest, s.info.Signature)
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(pub, signedDigest, s.info.Signature) {
+			err = errors.New("подпись ECDSA не прошла проверку")
+		}
+	default:
+		return unsupported(sr, "Алгоритм подписи "+sr.SignatureAlgorithm+" не поддерживается")
+	}
+
+	valid := err == nil
+	sr.SignatureValid = &valid
+	if s.signedAttrs == nil {
+		// Без атрибутов верная подпись означает и совпадение с файлом
+		sr.ContentMatch = &valid
+	}
+	if !valid {
+		sr.Status, sr.Message = StatusInvalid, "Подпись не прошла проверку ключом сертификата"
+	}
+	return sr
+}
+
+// unsupported отмечает, что подпись не проверена. Истёкший сертификат остаётся более важным признаком
+func unsupported(sr SignerResult, message string) SignerResult {
+	if sr.Status == StatusValid {
+		sr.Status, sr.Message = StatusUnsupported, message
+	}
+	return sr
+}
+
+// newDigest возвращает конструктор хеша по OID алгоритма или nil, если алгоритм не поддерживается
+func newDigest(oid string) func() hash.Hash {
+	if h, ok := digestAlgorithms[oid]; ok {
+		return h.New
+	}
+	return gostDigestAlgorithms[oid]
+}
+
+// algorithmName возвращает название алгоритма по OID или сам OID
+func algorithmName(oid asn1.ObjectIdentifier) string {
+	if name, ok := algorithmNames[oid.String()]; ok {
+		return name
+	}
+	return oid.String()
+}
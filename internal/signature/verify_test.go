@@ -0,0 +1,122 @@
+package signature
+
+import (
+	"bytes"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	now := time.Now()
+	content := []byte("%PDF-1.7\nДоговор поставки № 12/3 от 01.02.2024")
+	other := []byte("%PDF-1.7\nДоговор поставки № 12/4 от 01.02.2024")
+
+	rsaSigner := newRSASigner(t, now.Add(-24*time.Hour), now.Add(365*24*time.Hour))
+	ecdsaSigner := newECDSASigner(t, now.Add(-24*time.Hour), now.Add(365*24*time.Hour))
+	expiredSigner := newRSASigner(t, now.Add(-2*365*24*time.Hour), now.Add(-24*time.Hour))
+
+	tampered := rsaSigner.sign(t, content, signOptions{noAttrs: true})
+	// Последний байт подписи — последний байт файла подписи
+	tampered[len(tampered)-1] ^= 0xFF
+
+	tests := []struct {
+		name           string
+		sig            []byte
+		content        []byte
+		want           Status
+		contentMatch   *bool
+		signatureValid *bool
+	}{
+		{"RSA", rsaSigner.sign(t, content, signOptions{}), content, StatusValid, ptr(true), ptr(true)},
+		{"ECDSA", ecdsaSigner.sign(t, content, signOptions{}), content, StatusValid, ptr(true), ptr(true)},
+		{"ECDSA по идентификатору ключа", ecdsaSigner.sign(t, content, signOptions{bySKI: true}), content, StatusValid, ptr(true), ptr(true)},
+		{"RSA без подписанных атрибутов", rsaSigner.sign(t, content, signOptions{noAttrs: true}), content, StatusValid, ptr(true), ptr(true)},
+		{"PEM", pem.EncodeToMemory(&pem.Block{Type: "PKCS7", Bytes: rsaSigner.sign(t, content, signOptions{})}), content, StatusValid, ptr(true), ptr(true)},
+		{"подпись другого файла", rsaSigner.sign(t, other, signOptions{}), content, StatusInvalid, ptr(false), nil},
+		{"подпись другого файла ECDSA", ecdsaSigner.sign(t, other, signOptions{}), content, StatusInvalid, ptr(false), nil},
+		{"подпись другого файла без атрибутов", rsaSigner.sign(t, other, signOptions{noAttrs: true}), content, StatusInvalid, ptr(false), ptr(false)},
+		{"повреждённая подпись", tampered, content, StatusInvalid, ptr(false), ptr(false)},
+		{"истёкший сертификат", expiredSigner.sign(t, content, signOptions{}), content, StatusExpired, ptr(true), ptr(true)},
+		{"подписано до начала действия сертификата", rsaSigner.sign(t, content, signOptions{signingTime: now.Add(-48 * time.Hour).UTC()}), content, StatusExpired, ptr(true), ptr(true)},
+		{"нет сертификата подписанта", rsaSigner.sign(t, content, signOptions{noCert: true}), content, StatusInvalid, nil, nil},
+		{"ГОСТ Р 34.11-2012", rsaSigner.sign(t, content, signOptions{digestOID: oidStreebog256, signatureOID: oidGOST2012256, newHash: newStreebog256}), content, StatusUnsupported, ptr(true), nil},
+		{"ГОСТ Р 34.11-2012, другой файл", rsaSigner.sign(t, other, signOptions{digestOID: oidStreebog256, signatureOID: oidGOST2012256, newHash: newStreebog256}), content, StatusInvalid, ptr(false), nil},
+		{"ГОСТ Р 34.11-94", rsaSigner.sign(t, content, signOptions{digestOID: oidGOST94, signatureOID: oidGOST2012256, newHash: newStreebog256}), content, StatusUnsupported, nil, nil},
+		{"не подпись", []byte("Это не подпись"), content, StatusMalformed, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Verify(tt.sig, bytes.NewReader(tt.content), now)
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if result.Status != tt.want {
+				t.Fatalf("статус %s (%s), ожидался %s", result.Status, result.Message, tt.want)
+			}
+			if tt.want == StatusMalformed {
+				if len(result.Signers) != 0 || result.Message == "" {
+					t.Errorf("неожиданный результат для неразобранной подписи: %+v", result)
+				}
+				return
+			}
+			if len(result.Signers) != 1 {
+				t.Fatalf("подписантов %d, ожидался 1", len(result.Signers))
+			}
+			sr := result.Signers[0]
+			if sr.Status != tt.want || (tt.want != StatusValid && sr.Message == "") {
+				t.Errorf("подписант: статус %s, сообщение %q", sr.Status, sr.Message)
+			}
+			checkFlag(t, "ContentMatch", sr.ContentMatch, tt.contentMatch)
+			checkFlag(t, "SignatureValid", sr.SignatureValid, tt.signatureValid)
+			if sr.CertificateExpired != (tt.want == StatusExpired) {
+				t.Errorf("CertificateExpired = %t", sr.CertificateExpired)
+			}
+		})
+	}
+}
+
+func TestVerifyCertificateDetails(t *testing.T) {
+	now := time.Now()
+	signer := newRSASigner(t, now.Add(-time.Hour), now.Add(time.Hour))
+	result, err := Verify(signer.sign(t, []byte("договор"), signOptions{}), strings.NewReader("договор"), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sr := result.Signers[0]
+	if sr.Certificate == nil {
+		t.Fatal("нет сведений о сертификате")
+	}
+	c := sr.Certificate
+	if c.CommonName != "Иванов Иван Иванович" || c.Organization != `ООО "Ромашка"` {
+		t.Errorf("владелец: %q, %q", c.CommonName, c.Organization)
+	}
+	// ИНН организации в старом формате дополнен нулями до 12 цифр
+	if c.INN != "7701234567" || c.OGRN != "1027700132195" {
+		t.Errorf("ИНН %q, ОГРН %q", c.INN, c.OGRN)
+	}
+	if c.SerialNumber != "1A2B3C" {
+		t.Errorf("серийный номер %q", c.SerialNumber)
+	}
+	if sr.DigestAlgorithm != "SHA-256" || sr.SignatureAlgorithm != "SHA-256 с RSA" {
+		t.Errorf("алгоритмы %q, %q", sr.DigestAlgorithm, sr.SignatureAlgorithm)
+	}
+	if sr.SigningTime.IsZero() {
+		t.Error("не прочитано время подписания")
+	}
+}
+
+func checkFlag(t *testing.T, name string, got, want *bool) {
+	t.Helper()
+	switch {
+	case want == nil && got != nil:
+		t.Errorf("%s = %t, ожидалось nil", name, *got)
+	case want != nil && got == nil:
+		t.Errorf("%s = nil, ожидалось %t", name, *want)
+	case want != nil && *got != *want:
+		t.Errorf("%s = %t, ожидалось %t", name, *got, *want)
+	}
+}
+
+func ptr(v bool) *bool { return &v }
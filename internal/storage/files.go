@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"statements/internal/database"
+	"statements/internal/models"
+)
+
+// RecordFile сохраняет метаданные файла: исходное имя, тип, размер и автора загрузки
+func RecordFile(ctx context.Context, db database.DBTX, f models.StoredFile) error {
+	_, err := db.ExecContext(ctx,
+		`INSERT INTO stored_files (storage_key, original_name, content_type, size, uploaded_by)
+		VALUES ($1, $2, NULLIF($3, ''), $4, NULLIF($5, ''))
+		ON CONFLICT (storage_key) DO UPDATE
+		SET original_name = EXCLUDED.original_name, content_type = EXCLUDED.content_type,
+		    size = EXCLUDED.size, uploaded_by = EXCLUDED.uploaded_by, uploaded_at = now()`,
+		f.Key, OriginalName(f.OriginalName), f.ContentType, f.Size, f.UploadedBy)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения сведений о файле %s: %w", f.Key, err)
+	}
+	return nil
+}
+
+// GetFile возвращает метаданные файла по ключу
+func GetFile(ctx context.Context, db database.DBTX, key string) (models.StoredFile, error) {
+	f := models.StoredFile{Key: key}
+	err := db.QueryRowContext(ctx,
+		`SELECT original_name, COALESCE(content_type, ''), size, COALESCE(uploaded_by, ''), uploaded_at::text
+		FROM stored_files WHERE storage_key = $1`, key).
+		Scan(&f.OriginalName, &f.ContentType, &f.Size, &f.UploadedBy, &f.UploadedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return f, ErrNotFound
+	}
+	if err != nil {
+		return f, fmt.Errorf("ошибка получения сведений о файле %s: %w", key, err)
+	}
+	return f, nil
+}
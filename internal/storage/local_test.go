@@ -0,0 +1,218 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// failingReader отдаёт часть данных, а затем ошибку, как оборвавшаяся загрузка
+type failingReader struct {
+	data []byte
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, errors.New("соединение разорвано")
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func newTestLocalStore(t *testing.T) (*LocalStore, string) {
+	t.Helper()
+	root := t.TempDir()
+	store, err := NewLocalStore(root)
+	if err != nil {
+		t.Fatalf("NewLocalStore: %v", err)
+	}
+	return store, root
+}
+
+func readObject(t *testing.T, store BlobStore, key string) string {
+	t.Helper()
+	r, err := store.Get(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Get(%q): %v", key, err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("чтение %q: %v", key, err)
+	}
+	return string(data)
+}
+
+func TestLocalStorePutGetDelete(t *testing.T) {
+	ctx := context.Background()
+	store, root := newTestLocalStore(t)
+	key := "contracts/0b6f7c1e/файл договора.pdf"
+
+	if err := store.Put(ctx, key, strings.NewReader("первая версия"), -1, "application/pdf"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if got := readObject(t, store, key); got != "первая версия" {
+		t.Fatalf("Get вернул %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(root, "contracts", "0b6f7c1e", "файл договора.pdf")); err != nil {
+		t.Fatalf("файл не найден в корневом каталоге: %v", err)
+	}
+
+	// Повторная запись заменяет объект
+	if err := store.Put(ctx, key, strings.NewReader("вторая"), -1, ""); err != nil {
+		t.Fatalf("повторный Put: %v", err)
+	}
+	if got := readObject(t, store, key); got != "вторая" {
+		t.Fatalf("после замены Get вернул %q", got)
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get удалённого объекта: ожидалась ErrNotFound, получено %v", err)
+	}
+	// Удаление отсутствующего объекта — не ошибка
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("повторный Delete: %v", err)
+	}
+}
+
+func TestLocalStoreGetMissing(t *testing.T) {
+	store, _ := newTestLocalStore(t)
+	if _, err := store.Get(context.Background(), "statements/нет/такого.pdf"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("ожидалась ErrNotFound, получено %v", err)
+	}
+}
+
+func TestLocalStorePutIsAtomic(t *testing.T) {
+	ctx := context.Background()
+	store, root := newTestLocalStore(t)
+	key := "contracts/id/document.pdf"
+
+	if err := store.Put(ctx, key, strings.NewReader("сохранённый документ"), -1, ""); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	// Оборвавшаяся запись не должна испортить уже сохранённый объект
+	err := store.Put(ctx, key, &failingReader{data: bytes.Repeat([]byte("x"), 64<<10)}, -1, "")
+	if err == nil {
+		t.Fatal("Put с ошибкой чтения завершился успешно")
+	}
+	if got := readObject(t, store, key); got != "сохранённый документ" {
+		t.Fatalf("после неудачной записи объект изменился: %q", got)
+	}
+
+	// Временные файлы удаляются и при успехе, и при ошибке
+	entries, err := os.ReadDir(filepath.Join(root, "contracts", "id"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if e.Name() != "document.pdf" {
+			t.Errorf("в каталоге остался лишний файл %s", e.Name())
+		}
+	}
+
+	// Новый объект при ошибке записи не появляется вовсе
+	if err := store.Put(ctx, "contracts/id/new.pdf", &failingReader{data: []byte("часть")}, -1, ""); err == nil {
+		t.Fatal("Put с ошибкой чтения завершился успешно")
+	}
+	if _, err := store.Get(ctx, "contracts/id/new.pdf"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("частично записанный объект доступен для чтения: %v", err)
+	}
+}
+
+func TestUploadRollback(t *testing.T) {
+	ctx := context.Background()
+	store, _ := newTestLocalStore(t)
+
+	upload := NewUpload(store)
+	for _, key := range []string{"contracts/a/1.pdf", "contracts/a/2.pdf"} {
+		if err := upload.Put(ctx, key, strings.NewReader(key), -1, ""); err != nil {
+			t.Fatalf("Put(%q): %v", key, err)
+		}
+	}
+	if err := upload.Rollback(ctx); err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+	for _, key := range []string{"contracts/a/1.pdf", "contracts/a/2.pdf"} {
+		if _, err := store.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+			t.Errorf("после отката объект %s остался: %v", key, err)
+		}
+	}
+
+	committed := NewUpload(store)
+	if err := committed.Put(ctx, "contracts/b/1.pdf", strings.NewReader("data"), -1, ""); err != nil {
+		t.Fatal(err)
+	}
+	committed.Commit()
+	if err := committed.Rollback(ctx); err != nil {
+		t.Fatalf("Rollback после Commit: %v", err)
+	}
+	if got := readObject(t, store, "contracts/b/1.pdf"); got != "data" {
+		t.Fatalf("подтверждённый объект изменился: %q", got)
+	}
+}
+
+func TestLocalStorePathJail(t *testing.T) {
+	ctx := context.Background()
+	parent := t.TempDir()
+	root := filepath.Join(parent, "uploads")
+	store, err := NewLocalStore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := []string{
+		"../config.yaml",
+		"../../app/config.yaml",
+		"contracts/../../config.yaml",
+		`..\config.yaml`,
+		`contracts\..\..\config.yaml`,
+		`contracts/..\../config.yaml`,
+		"/etc/passwd",
+		filepath.Join(parent, "config.yaml"),
+		`C:\Windows\win.ini`,
+		"contracts/id/file\x00.pdf",
+		"..",
+		".",
+		"",
+	}
+	for _, key := range keys {
+		t.Run(key, func(t *testing.T) {
+			if err := store.Put(ctx, key, strings.NewReader("вне корня"), -1, ""); !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("Put(%q): ожидалась ErrInvalidKey, получено %v", key, err)
+			}
+			if _, err := store.Get(ctx, key); !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("Get(%q): ожидалась ErrInvalidKey, получено %v", key, err)
+			}
+			if err := store.Delete(ctx, key); !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("Delete(%q): ожидалась ErrInvalidKey, получено %v", key, err)
+			}
+		})
+	}
+
+	// Рядом с корнем ничего не появилось
+	entries, err := os.ReadDir(parent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "uploads" {
+		t.Errorf("вне корневого каталога созданы файлы: %v", entries)
+	}
+
+	// Ключ с точками внутри имени каталога остаётся в корне
+	if err := store.Put(ctx, "contracts/№ 12..3/file.pdf", strings.NewReader("x"), -1, ""); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "contracts", "№ 12..3", "file.pdf")); err != nil {
+		t.Errorf("файл не найден в корневом каталоге: %v", err)
+	}
+}
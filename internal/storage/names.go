@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// maxOriginalNameLength — максимальная длина сохраняемого исходного имени файла в символах
+	maxOriginalNameLength = 255
+	// maxExtensionLength — максимальная длина расширения в имени хранения
+	maxExtensionLength = 10
+)
+
+// StorageName генерирует имя для хранения файла: случайный идентификатор и расширение исходного имени.
+// Имя, переданное клиентом, в путях и ключах не используется
+func StorageName(originalName string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("ошибка генерации имени файла: %w", err)
+	}
+	name := hex.EncodeToString(b)
+	if ext := safeExtension(originalName); ext != "" {
+		name += "." + ext
+	}
+	return name, nil
+}
+
+// NewKey возвращает ключ для нового объекта внутри префикса, например "contracts/<id>"
+func NewKey(prefix, originalName string) (string, error) {
+	name, err := StorageName(originalName)
+	if err != nil {
+		return "", err
+	}
+	return CleanKey(strings.TrimSuffix(prefix, "/") + "/" + name)
+}
+
+// OriginalName приводит имя файла от клиента к виду, пригодному для хранения как метаданные:
+// отбрасывает путь, управляющие символы и ограничивает длину
+func OriginalName(name string) string {
+	name = strings.ReplaceAll(name, `\`, "/")
+	name = path.Base(name)
+	name = strings.Map(func(r rune) rune {
+		if r == utf8.RuneError || unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+
+	if name == "" || name == "." || name == ".." || name == "/" {
+		return "file"
+	}
+	if runes := []rune(name); len(runes) > maxOriginalNameLength {
+		name = string(runes[:maxOriginalNameLength])
+	}
+	return name
+}
+
+// safeExtension возвращает расширение исходного имени в нижнем регистре, если оно состоит только из латиницы и цифр
+func safeExtension(name string) string {
+	ext := strings.ToLower(strings.TrimPrefix(path.Ext(OriginalName(name)), "."))
+	if ext == "" || len(ext) > maxExtensionLength {
+		return ""
+	}
+	for _, r := range ext {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			return ""
+		}
+	}
+	return ext
+}
@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// storageNamePattern — случайный идентификатор из 32 шестнадцатеричных символов и необязательное расширение
+var storageNamePattern = regexp.MustCompile(`^[0-9a-f]{32}(\.[0-9a-z]{1,10})?$`)
+
+func TestOriginalName(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"обычное имя", "Договор №12.pdf", "Договор №12.pdf"},
+		{"выход из каталога", "../../app/config.yaml", "config.yaml"},
+		{"абсолютный путь", "/etc/passwd", "passwd"},
+		{"путь Windows", `C:\Users\buh\Documents\выписка.txt`, "выписка.txt"},
+		{"смешанные разделители", `..\../..\app/config.yaml`, "config.yaml"},
+		{"UNC-путь", `\\server\share\акт.pdf`, "акт.pdf"},
+		{"NUL в имени", "invoice.pdf\x00.exe", "invoice.pdf.exe"},
+		{"управляющие символы", "акт\r\n\tсверки.xlsx", "актсверки.xlsx"},
+		{"некорректный UTF-8", "счёт\xff.pdf", "счёт.pdf"},
+		{"пробелы по краям", "  выписка.txt  ", "выписка.txt"},
+		{"только точки", "..", "file"},
+		{"точка", ".", "file"},
+		{"только разделители", `/\/`, "file"},
+		{"пустое имя", "", "file"},
+		{"каталог в конце", "../", "file"},
+		{"скрытый файл", ".htaccess", ".htaccess"},
+		{"длинное имя", strings.Repeat("я", 300) + ".pdf", strings.Repeat("я", maxOriginalNameLength)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := OriginalName(tt.in); got != tt.want {
+				t.Errorf("OriginalName(%q) = %q, ожидалось %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStorageName(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		wantExt string
+	}{
+		{"расширение в нижний регистр", "Выписка.TXT", ".txt"},
+		{"выход из каталога", "../../app/config.yaml", ".yaml"},
+		{"абсолютный путь", "/etc/passwd", ""},
+		{"путь Windows", `C:\Windows\system32\cmd.exe`, ".exe"},
+		{"смешанные разделители", `..\..\../config.yaml`, ".yaml"},
+		{"NUL в расширении", "invoice.pdf\x00.exe", ".exe"},
+		{"разделитель в расширении", `file.pdf\..\..`, ""},
+		{"кириллица в расширении", "договор.док", ""},
+		{"длинное расширение", "archive.verylongextension", ""},
+		{"расширение с символами", "file.p$f", ""},
+		{"без расширения", "README", ""},
+		{"пустое имя", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := StorageName(tt.in)
+			if err != nil {
+				t.Fatalf("StorageName(%q): %v", tt.in, err)
+			}
+			if !storageNamePattern.MatchString(got) {
+				t.Fatalf("StorageName(%q) = %q: имя не соответствует формату", tt.in, got)
+			}
+			if ext := strings.TrimPrefix(got, got[:32]); ext != tt.wantExt {
+				t.Errorf("StorageName(%q) = %q: расширение %q, ожидалось %q", tt.in, got, ext, tt.wantExt)
+			}
+		})
+	}
+}
+
+func TestStorageNameUnique(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		name, err := StorageName("выписка.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seen[name] {
+			t.Fatalf("имя %s сгенерировано повторно", name)
+		}
+		seen[name] = true
+	}
+}
+
+func TestNewKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		prefix     string
+		original   string
+		wantPrefix string
+		wantErr    bool
+	}{
+		{"каталог контракта", "contracts/0b6f7c1e", "../../app/config.yaml", "contracts/0b6f7c1e/", false},
+		{"префикс со слешем в конце", "statements/2024-02-01/", "выписка.txt", "statements/2024-02-01/", false},
+		{"номер контракта с точками", "payment-requests/№ 12..3", "счёт.pdf", "payment-requests/№ 12..3/", false},
+		{"номер контракта ..", "payment-requests/..", "счёт.pdf", "", true},
+		{"номер контракта с выходом из каталога", "contracts/../../etc", "счёт.pdf", "", true},
+		{"номер контракта с обратным слешем", `contracts/..\..\etc`, "счёт.pdf", "", true},
+		{"абсолютный префикс", "/etc", "passwd", "", true},
+		{"NUL в префиксе", "contracts/1\x00", "счёт.pdf", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewKey(tt.prefix, tt.original)
+			if tt.wantErr {
+				if err != ErrInvalidKey {
+					t.Fatalf("NewKey(%q, %q) = %q, %v: ожидалась ErrInvalidKey", tt.prefix, tt.original, got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewKey(%q, %q): %v", tt.prefix, tt.original, err)
+			}
+			if !strings.HasPrefix(got, tt.wantPrefix) || !storageNamePattern.MatchString(strings.TrimPrefix(got, tt.wantPrefix)) {
+				t.Errorf("NewKey(%q, %q) = %q, ожидался ключ вида %s<имя>", tt.prefix, tt.original, got, tt.wantPrefix)
+			}
+		})
+	}
+
+	first, _ := NewKey("contracts/1", "договор.pdf")
+	second, _ := NewKey("contracts/1", "договор.pdf")
+	if first == second {
+		t.Errorf("файлы с одинаковым именем получили один ключ %s", first)
+	}
+}
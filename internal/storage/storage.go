@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"path"
+	"statements/internal/config"
+	"statements/internal/database"
+	"statements/internal/models"
+	"strings"
+	"unicode"
+)
+
+var (
+	// ErrNotFound возвращается, если объекта с таким ключом нет в хранилище
+	ErrNotFound = errors.New("объект не найден в хранилище")
+	// ErrInvalidKey возвращается для ключа, выходящего за пределы хранилища
+	ErrInvalidKey = errors.New("недопустимый ключ объекта")
+)
+
+// BlobStore — хранилище документов. Объекты адресуются ключами вида "contracts/<id>/<файл>",
+// поэтому в базе хранятся ключи, а не абсолютные пути, и приложение может работать в нескольких экземплярах
+type BlobStore interface {
+	// Put сохраняет объект целиком. Существующий объект с тем же ключом заменяется
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get открывает объект для чтения. Вызывающий обязан закрыть результат
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete удаляет объект. Удаление отсутствующего объекта не считается ошибкой
+	Delete(ctx context.Context, key string) error
+}
+
+// New создаёт хранилище по конфигурации. Для локального хранилища корнем служит uploadDir
+func New(cfg config.StorageConfig, uploadDir string) (BlobStore, error) {
+	switch cfg.Backend {
+	case "", "local":
+		return NewLocalStore(uploadDir)
+	case "s3":
+		return NewS3Store(cfg.S3)
+	}
+	return nil, fmt.Errorf("неизвестный тип хранилища: %s", cfg.Backend)
+}
+
+// CleanKey проверяет ключ и приводит его к каноническому виду: относительный путь через "/", без "." и "..".
+// Ключи с управляющими символами, абсолютные пути и пути с ".." отклоняются, а не исправляются
+func CleanKey(key string) (string, error) {
+	key = strings.ReplaceAll(key, `\`, "/")
+	if key == "" || strings.HasPrefix(key, "/") || strings.ContainsFunc(key, unicode.IsControl) {
+		return "", ErrInvalidKey
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == ".." {
+			return "", ErrInvalidKey
+		}
+	}
+	// Имена дисков Windows (C:) на случай локального хранилища на такой системе
+	if len(key) >= 2 && key[1] == ':' {
+		return "", ErrInvalidKey
+	}
+	cleaned := path.Clean(key)
+	if cleaned == "." {
+		return "", ErrInvalidKey
+	}
+	return cleaned, nil
+}
+
+// Upload отслеживает объекты, сохранённые в рамках одной операции, чтобы удалить их, если операция не завершилась
+type Upload struct {
+	store     BlobStore
+	keys      []string
+	files     []models.StoredFile
+	committed bool
+}
+
+// NewUpload начинает операцию загрузки в хранилище
+func NewUpload(store BlobStore) *Upload {
+	return &Upload{store: store}
+}
+
+// Put сохраняет объект и запоминает его ключ
+func (u *Upload) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	if err := u.store.Put(ctx, key, r, size, contentType); err != nil {
+		return err
+	}
+	u.keys = append(u.keys, key)
+	return nil
+}
+
+// PutFile сохраняет загруженный файл под сгенерированным именем внутри prefix.
+// Исходное имя файла попадает только в метаданные результата, там же возвращается SHA-256 содержимого
+func (u *Upload) PutFile(ctx context.Context, prefix string, fileHeader *multipart.FileHeader, uploadedBy string) (models.StoredFile, error) {
+	key, err := NewKey(prefix, fileHeader.Filename)
+	if err != nil {
+		return models.StoredFile{}, err
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return models.StoredFile{}, fmt.Errorf("ошибка открытия файла %s: %w", fileHeader.Filename, err)
+	}
+	defer file.Close()
+
+	f := models.StoredFile{
+		Key:          key,
+		OriginalName: OriginalName(fileHeader.Filename),
+		ContentType:  fileHeader.Header.Get("Content-Type"),
+		Size:         fileHeader.Size,
+		UploadedBy:   uploadedBy,
+	}
+	hash := sha256.New()
+	if err := u.Put(ctx, key, io.TeeReader(file, hash), f.Size, f.ContentType); err != nil {
+		return models.StoredFile{}, err
+	}
+	f.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	u.files = append(u.files, f)
+	return f, nil
+}
+
+// Files возвращает метаданные файлов, сохранённых через PutFile
+func (u *Upload) Files() []models.StoredFile {
+	return u.files
+}
+
+// Record сохраняет в базе метаданные всех файлов, сохранённых через PutFile
+func (u *Upload) Record(ctx context.Context, db database.DBTX) error {
+	for _, f := range u.files {
+		if err := RecordFile(ctx, db, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Commit отмечает операцию успешной — сохранённые объекты остаются в хранилище
+func (u *Upload) Commit() {
+	u.committed = true
+}
+
+// Rollback удаляет сохранённые объекты, если операция не была подтверждена. Безопасно вызывать через defer
+func (u *Upload) Rollback(ctx context.Context) error {
+	if u.committed {
+		return nil
+	}
+	var errs []error
+	for _, key := range u.keys {
+		if err := u.store.Delete(ctx, key); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+		}
+	}
+	u.keys = nil
+	return errors.Join(errs...)
+}
@@ -0,0 +1,48 @@
+package storage
+
+import "testing"
+
+func TestCleanKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{"обычный ключ", "contracts/id/file.pdf", "contracts/id/file.pdf", false},
+		{"лишние разделители и точки", "contracts//id/./file.pdf", "contracts/id/file.pdf", false},
+		{"слеш в конце", "statements/2024-02-01/", "statements/2024-02-01", false},
+		{"обратные слеши", `contracts\id\file.pdf`, "contracts/id/file.pdf", false},
+		{"точки внутри имени", "contracts/№ 1..2/...pdf", "contracts/№ 1..2/...pdf", false},
+		{"кириллица и пробелы", "contracts/id/акт сверки.pdf", "contracts/id/акт сверки.pdf", false},
+		{"пустой ключ", "", "", true},
+		{"только точка", ".", "", true},
+		{"текущий каталог", "./", "", true},
+		{"выход из каталога", "../../app/config.yaml", "", true},
+		{"выход из каталога в середине", "contracts/../../app/config.yaml", "", true},
+		{"выход из каталога в конце", "contracts/..", "", true},
+		{"выход из каталога через обратные слеши", `contracts\..\..\app\config.yaml`, "", true},
+		{"смешанные разделители", `contracts/..\../config.yaml`, "", true},
+		{"абсолютный путь", "/etc/passwd", "", true},
+		{"абсолютный путь Windows", `\Windows\win.ini`, "", true},
+		{"UNC-путь", `\\server\share\file`, "", true},
+		{"диск Windows", "C:/Windows/win.ini", "", true},
+		{"диск Windows без разделителя", "C:file", "", true},
+		{"NUL", "contracts/id/file.pdf\x00.txt", "", true},
+		{"перевод строки", "contracts/id/\nfile.pdf", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CleanKey(tt.key)
+			if tt.wantErr {
+				if err != ErrInvalidKey {
+					t.Fatalf("CleanKey(%q) = %q, %v: ожидалась ErrInvalidKey", tt.key, got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("CleanKey(%q) = %q, %v, ожидалось %q", tt.key, got, err, tt.want)
+			}
+		})
+	}
+}
@@ -2,13 +2,21 @@ package transactions
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"log"
+	"statements/internal/counterparties"
 	"statements/internal/database"
 	"strings"
 	"time"
 )
 
+// Реквизиты собственной организации, от имени которой ведутся счета в выписках ВТБ
+const (
+	ownInn  = "7719034354"
+	ownName = `КАЗЕННОЕ ПРЕДПРИЯТИЕ "МОСКОВСКАЯ ЭНЕРГЕТИЧЕСКАЯ ДИРЕКЦИЯ"`
+)
+
 // isValidAccount проверяет корректность номера счета (ожидаемая длина — 20 символов)
 func isValidAccount(account string) bool {
 	return len(account) == 20
@@ -99,10 +107,20 @@ func saveTransaction(accountNumber, bank string, transaction map[string]interfac
 		return
 	}
 
-	err = insertTransaction(accountNumber, bank, transaction, debitAccount, creditAccount, inn, name, innC, nameC, documentNumber, paymentDescription)
+	// Сопоставляем стороны платежа с контрагентами
+	counterpartyID := resolveCounterparty(inn, name)
+	counterpartyCID := resolveCounterparty(innC, nameC)
+
+	bik := extractBik(transaction)
+	transactionID, err := insertTransaction(accountNumber, bank, transaction, debitAccount, creditAccount, inn, name, innC, nameC, documentNumber, paymentDescription, bik, counterpartyID, counterpartyCID)
 	if err != nil {
 		log.Printf("Ошибка вставки транзакции для счета %s: %v", accountNumber, err)
+		return
 	}
+
+	// Запоминаем реквизиты контрагентов и проверяем платежи на новые счета
+	recordCounterpartyAccounts(accountNumber, transactionID, getStringValue(transaction, "date"), getStringValue(transaction, "debit"),
+		debitAccount, creditAccount, bik, counterpartyID, counterpartyCID, true)
 }
 
 // processSberTransaction обрабатывает транзакции для Сбербанка
@@ -121,16 +139,16 @@ func processVTBTransaction(accountNumber string, transaction map[string]interfac
 		nameC = getStringValue(transaction, "name")
 
 		debitAccount = getStringValue(transaction, "account")
-		inn = "7719034354"
-		name = `КАЗЕННОЕ ПРЕДПРИЯТИЕ "МОСКОВСКАЯ ЭНЕРГЕТИЧЕСКАЯ ДИРЕКЦИЯ"`
+		inn = ownInn
+		name = ownName
 	} else {
 		debitAccount = accountNumber
 		inn = getStringValue(transaction, "inn")
 		name = getStringValue(transaction, "name")
 
 		creditAccount = getStringValue(transaction, "account")
-		innC = "7719034354"
-		nameC = `КАЗЕННОЕ ПРЕДПРИЯТИЕ "МОСКОВСКАЯ ЭНЕРГЕТИЧЕСКАЯ ДИРЕКЦИЯ"`
+		innC = ownInn
+		nameC = ownName
 	}
 	return
 }
@@ -151,21 +169,23 @@ func convertDateToISO(date string) (string, error) {
 }
 
 // insertTransaction вставляет транзакцию в базу данных
-func insertTransaction(accountNumber, bank string, transaction map[string]interface{}, debitAccount, creditAccount, inn, name, innC, nameC, documentNumber, paymentDescription string) error {
+func insertTransaction(accountNumber, bank string, transaction map[string]interface{}, debitAccount, creditAccount, inn, name, innC, nameC, documentNumber, paymentDescription, bik string, counterpartyID, counterpartyCID sql.NullInt64) (int, error) {
 	log.Printf("Вставляем транзакцию для счета %s, банк %s, дата %s", accountNumber, bank, getStringValue(transaction, "date"))
 
 	// Преобразование даты в формат YYYY-MM-DD
 	isoDate, err := convertDateToISO(getStringValue(transaction, "date"))
 	if err != nil {
 		log.Printf("Ошибка преобразования даты для счета %s: %v", accountNumber, err)
-		return err
+		return 0, err
 	}
 
 	log.Printf("Дата после преобразования: %s", isoDate)
 
-	_, err = database.DB.ExecContext(context.Background(),
-		`INSERT INTO transactions (account_number, bank, date, debit_account, credit_account, debit, credit, inn, name, inn_c, name_c, document_number, payment_description)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
+	var transactionID int
+	err = database.DB.QueryRowContext(context.Background(),
+		`INSERT INTO transactions (account_number, bank, date, debit_account, credit_account, debit, credit, inn, name, inn_c, name_c, document_number, payment_description, bik, counterparty_id, counterparty_c_id)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, NULLIF($14, ''), $15, $16)
+        RETURNING id`,
 		accountNumber,
 		bank,
 		isoDate, // Используем преобразованную дату
@@ -178,14 +198,26 @@ func insertTransaction(accountNumber, bank string, transaction map[string]interf
 		innC,
 		nameC,
 		documentNumber,
-		paymentDescription)
+		paymentDescription,
+		bik,
+		counterpartyID,
+		counterpartyCID).Scan(&transactionID)
 	if err != nil {
 		log.Printf("Ошибка вставки транзакции для счета %s: %v", accountNumber, err)
-		return err
+		return 0, err
 	}
 
 	log.Printf("Транзакция для счета %s успешно вставлена в базу данных.", accountNumber)
-	return nil
+	return transactionID, nil
+}
+
+// extractBik извлекает БИК банка, если он корректен (9 цифр)
+func extractBik(transaction map[string]interface{}) string {
+	bik := getStringValue(transaction, "bik")
+	if len(bik) != 9 || strings.Trim(bik, "0123456789") != "" {
+		return ""
+	}
+	return bik
 }
 
 // extractDocumentNumber извлекает номер документа
@@ -241,3 +273,131 @@ func existsTransaction(accountNumber, date, debit, credit, documentNumber, payme
 
 	return exists, nil
 }
+
+// resolveCounterparty возвращает идентификатор контрагента по ИНН, создавая его при необходимости.
+// Собственная организация и некорректные ИНН контрагентами не считаются.
+func resolveCounterparty(inn, name string) sql.NullInt64 {
+	if !isValidInn(inn) || inn == ownInn {
+		return sql.NullInt64{}
+	}
+
+	id, err := counterparties.Upsert(context.Background(), database.DB, inn, "", name)
+	if err != nil {
+		log.Printf("Ошибка сопоставления контрагента с ИНН %s: %v", inn, err)
+		if id == 0 {
+			return sql.NullInt64{}
+		}
+	}
+
+	return sql.NullInt64{Int64: int64(id), Valid: true}
+}
+
+// LinkCounterparties привязывает к контрагентам транзакции, сохранённые до появления автоматического сопоставления
+func LinkCounterparties(ctx context.Context) error {
+	rows, err := database.DB.QueryContext(ctx,
+		`SELECT id, account_number, date::text, COALESCE(debit::text, ''),
+		        COALESCE(debit_account, ''), COALESCE(credit_account, ''), COALESCE(bik, ''),
+		        CASE WHEN counterparty_id IS NULL THEN COALESCE(inn, '') ELSE '' END, COALESCE(name, ''),
+		        CASE WHEN counterparty_c_id IS NULL THEN COALESCE(inn_c, '') ELSE '' END, name_c
+		FROM transactions
+		WHERE (counterparty_id IS NULL AND inn IS NOT NULL AND inn <> $1)
+		   OR (counterparty_c_id IS NULL AND inn_c IS NOT NULL AND inn_c <> $1)`,
+		ownInn)
+	if err != nil {
+		return fmt.Errorf("ошибка выборки транзакций без контрагентов: %w", err)
+	}
+
+	type pendingTransaction struct {
+		id                               int
+		accountNumber, date, debit       string
+		debitAccount, creditAccount, bik string
+		inn, name, innC, nameC           string
+	}
+
+	var pending []pendingTransaction
+	for rows.Next() {
+		var t pendingTransaction
+		if err := rows.Scan(&t.id, &t.accountNumber, &t.date, &t.debit, &t.debitAccount, &t.creditAccount, &t.bik,
+			&t.inn, &t.name, &t.innC, &t.nameC); err != nil {
+			rows.Close()
+			return fmt.Errorf("ошибка чтения транзакции: %w", err)
+		}
+		pending = append(pending, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("ошибка чтения транзакций: %w", err)
+	}
+
+	for _, t := range pending {
+		counterpartyID := resolveCounterparty(t.inn, t.name)
+		counterpartyCID := resolveCounterparty(t.innC, t.nameC)
+
+		_, err := database.DB.ExecContext(ctx,
+			`UPDATE transactions
+			SET counterparty_id = COALESCE(counterparty_id, $2), counterparty_c_id = COALESCE(counterparty_c_id, $3)
+			WHERE id = $1`,
+			t.id, counterpartyID, counterpartyCID)
+		if err != nil {
+			return fmt.Errorf("ошибка привязки транзакции %d к контрагентам: %w", t.id, err)
+		}
+
+		// Исторические платежи пополняют реквизиты, но оповещений не создают
+		recordCounterpartyAccounts(t.accountNumber, t.id, t.date, t.debit, t.debitAccount, t.creditAccount, t.bik,
+			counterpartyID, counterpartyCID, false)
+	}
+
+	if len(pending) > 0 {
+		log.Printf("Привязано к контрагентам транзакций: %d", len(pending))
+	}
+	return nil
+}
+
+// recordCounterpartyAccounts сохраняет счета контрагентов по сторонам платежа.
+// БИК относится к счёту контрагента, то есть к стороне, не совпадающей с нашим счётом.
+func recordCounterpartyAccounts(accountNumber string, transactionID int, date, debit, debitAccount, creditAccount, bik string, counterpartyID, counterpartyCID sql.NullInt64, detectNew bool) {
+	isoDate, err := convertDateToISO(date)
+	if err != nil {
+		isoDate = ""
+	}
+	outgoing := debit != "" && debit != "0.00" && debit != "0"
+
+	sides := []struct {
+		counterpartyID sql.NullInt64
+		account        string
+		outgoing       bool
+	}{
+		{counterpartyID, debitAccount, false},
+		{counterpartyCID, creditAccount, outgoing},
+	}
+
+	for _, side := range sides {
+		if !side.counterpartyID.Valid || !isValidAccount(side.account) {
+			continue
+		}
+
+		usage := counterparties.AccountUsage{
+			CounterpartyID: int(side.counterpartyID.Int64),
+			Account:        side.account,
+			TransactionID:  transactionID,
+			Date:           isoDate,
+			Outgoing:       side.outgoing,
+		}
+		if side.account != accountNumber {
+			usage.Bik = bik
+		}
+		if side.outgoing {
+			usage.Amount = debit
+		}
+
+		alertID, err := counterparties.RecordAccount(context.Background(), database.DB, usage, detectNew)
+		if err != nil {
+			log.Printf("Ошибка сохранения счёта контрагента для транзакции %d: %v", transactionID, err)
+			continue
+		}
+		if alertID != 0 {
+			log.Printf("ВНИМАНИЕ: платёж контрагенту %d на новый счёт %s (транзакция %d, оповещение %d)",
+				usage.CounterpartyID, usage.Account, transactionID, alertID)
+		}
+	}
+}
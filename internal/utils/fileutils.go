@@ -5,9 +5,11 @@ import (
 	"mime/multipart"
 	"os"
 	"path/filepath"
+	"statements/internal/storage"
 )
 
-// SaveFile сохраняет файл на диск в указанную директорию
+// SaveFile сохраняет файл на диск в указанную директорию под именем, сгенерированным сервером.
+// Имя файла от клиента в пути не используется, поэтому файл не может оказаться вне uploadDir или перезаписать другой
 func SaveFile(fileHeader *multipart.FileHeader, uploadDir string) (string, error) {
 	// Проверка существования и создание директории, если она не существует
 	if err := os.MkdirAll(uploadDir, os.ModePerm); err != nil {
@@ -22,10 +24,14 @@ func SaveFile(fileHeader *multipart.FileHeader, uploadDir string) (string, error
 	defer file.Close()
 
 	// Полный путь до файла
-	filePath := filepath.Join(uploadDir, fileHeader.Filename)
+	name, err := storage.StorageName(fileHeader.Filename)
+	if err != nil {
+		return "", err
+	}
+	filePath := filepath.Join(uploadDir, name)
 
-	// Создание файла на диске
-	out, err := os.Create(filePath)
+	// Создание файла на диске; существующий файл не перезаписывается
+	out, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
 	if err != nil {
 		return "", fmt.Errorf("ошибка создания файла %s: %w", filePath, err)
 	}
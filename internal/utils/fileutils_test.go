@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"bytes"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// uploadedFile формирует multipart-запрос с одним файлом и возвращает его заголовок, как при разборе формы в обработчике
+func uploadedFile(t *testing.T, filename, content string) *multipart.FileHeader {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreateFormFile("file", "upload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write([]byte(content))
+	w.Close()
+
+	form, err := multipart.NewReader(&body, w.Boundary()).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { form.RemoveAll() })
+	files := form.File["file"]
+	if len(files) != 1 {
+		t.Fatalf("в форме %d файлов", len(files))
+	}
+	// Имя задаётся после разбора: mime/multipart отбрасывает путь и не принимает управляющие символы,
+	// а проверяется именно поведение SaveFile на произвольном имени от клиента
+	files[0].Filename = filename
+	return files[0]
+}
+
+func TestSaveFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		wantExt  string
+	}{
+		{"обычное имя", "выписка.txt", ".txt"},
+		{"выход из каталога", "../../app/config.yaml", ".yaml"},
+		{"абсолютный путь", "/etc/passwd", ""},
+		{"путь Windows", `C:\app\config.yaml`, ".yaml"},
+		{"смешанные разделители", `..\../..\config.yaml`, ".yaml"},
+		{"NUL в имени", "statement.txt\x00.sh", ".sh"},
+		{"только точки", "..", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parent := t.TempDir()
+			uploadDir := filepath.Join(parent, "uploads")
+
+			path, err := SaveFile(uploadedFile(t, tt.filename, "содержимое"), uploadDir)
+			if err != nil {
+				t.Fatalf("SaveFile(%q): %v", tt.filename, err)
+			}
+			if filepath.Dir(path) != uploadDir {
+				t.Fatalf("SaveFile(%q) сохранил файл вне каталога загрузки: %s", tt.filename, path)
+			}
+			if ext := filepath.Ext(path); ext != tt.wantExt {
+				t.Errorf("SaveFile(%q) = %s: расширение %q, ожидалось %q", tt.filename, path, ext, tt.wantExt)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil || string(data) != "содержимое" {
+				t.Errorf("содержимое %s: %q, %v", path, data, err)
+			}
+
+			entries, err := os.ReadDir(parent)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 || entries[0].Name() != "uploads" {
+				t.Errorf("вне каталога загрузки созданы файлы: %v", entries)
+			}
+		})
+	}
+}
+
+func TestSaveFileSameName(t *testing.T) {
+	uploadDir := t.TempDir()
+
+	first, err := SaveFile(uploadedFile(t, "выписка.txt", "первая"), uploadDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := SaveFile(uploadedFile(t, "выписка.txt", "вторая"), uploadDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second {
+		t.Fatalf("файлы с одинаковым именем сохранены по одному пути %s", first)
+	}
+	for path, want := range map[string]string{first: "первая", second: "вторая"} {
+		if data, err := os.ReadFile(path); err != nil || string(data) != want {
+			t.Errorf("содержимое %s: %q, %v, ожидалось %q", path, data, err, want)
+		}
+	}
+}
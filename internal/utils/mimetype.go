@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"fmt"
+	"mime/multipart"
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// DetectFileType определяет MIME-тип загруженного файла по содержимому (сигнатурам), а не по расширению и заголовкам клиента
+func DetectFileType(fileHeader *multipart.FileHeader) (*mimetype.MIME, error) {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка открытия файла %s: %w", fileHeader.Filename, err)
+	}
+	defer file.Close()
+
+	mtype, err := mimetype.DetectReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла %s: %w", fileHeader.Filename, err)
+	}
+	return mtype, nil
+}
+
+// TypeAllowed проверяет, что тип или один из его родительских типов входит в список допустимых.
+// Пустой список разрешает любой тип
+func TypeAllowed(mtype *mimetype.MIME, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for m := mtype; m != nil; m = m.Parent() {
+		for _, a := range allowed {
+			if m.Is(a) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// DescribeTypes перечисляет MIME-типы через расширения файлов для сообщений пользователю
+func DescribeTypes(types []string) string {
+	names := make([]string, 0, len(types))
+	for _, t := range types {
+		if m := mimetype.Lookup(t); m != nil && m.Extension() != "" {
+			names = append(names, m.Extension())
+			continue
+		}
+		names = append(names, t)
+	}
+	return strings.Join(names, ", ")
+}
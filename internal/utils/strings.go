@@ -0,0 +1,24 @@
+package utils
+
+import "strings"
+
+// FirstNonEmpty возвращает первую строку, непустую после удаления пробелов по краям
+func FirstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// JoinNonEmpty соединяет разделителем строки, непустые после удаления пробелов по краям
+func JoinNonEmpty(sep string, parts ...string) string {
+	nonEmpty := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			nonEmpty = append(nonEmpty, p)
+		}
+	}
+	return strings.Join(nonEmpty, sep)
+}
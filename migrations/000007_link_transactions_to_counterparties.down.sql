@@ -0,0 +1,15 @@
+BEGIN;
+
+-- Удаление привязки транзакций к контрагентам
+DROP INDEX IF EXISTS idx_transactions_counterparty_c;
+DROP INDEX IF EXISTS idx_transactions_counterparty;
+ALTER TABLE public.transactions
+    DROP COLUMN IF EXISTS counterparty_c_id,
+    DROP COLUMN IF EXISTS counterparty_id;
+
+-- Удаление истории наименований
+DROP TABLE IF EXISTS public.counterparty_names;
+
+DROP INDEX IF EXISTS idx_counterparties_inn_kpp_nulls;
+
+COMMIT;
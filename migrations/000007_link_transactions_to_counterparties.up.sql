@@ -0,0 +1,31 @@
+BEGIN;
+
+-- Уникальность пары ИНН/КПП с учётом пустого КПП (в выписках КПП не передаётся)
+CREATE UNIQUE INDEX IF NOT EXISTS idx_counterparties_inn_kpp_nulls
+    ON public.counterparties (inn, kpp) NULLS NOT DISTINCT;
+
+-- История вариантов наименований контрагентов, встреченных в выписках
+CREATE TABLE IF NOT EXISTS public.counterparty_names (
+    id SERIAL PRIMARY KEY,                                                               -- Первичный ключ
+    counterparty_id INT NOT NULL REFERENCES public.counterparties(id) ON DELETE CASCADE, -- Контрагент
+    name TEXT NOT NULL,                                                                  -- Вариант наименования
+    first_seen_at TIMESTAMPTZ NOT NULL DEFAULT now(),                                    -- Когда встречен впервые
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT now(),                                     -- Когда встречен последний раз
+    occurrences INT NOT NULL DEFAULT 1 CHECK (occurrences > 0),                          -- Количество транзакций с этим наименованием
+    UNIQUE (counterparty_id, name)
+);
+
+COMMENT ON TABLE public.counterparty_names IS 'Варианты наименований контрагентов из банковских выписок';
+
+-- Привязка транзакций к контрагентам по стороне дебета и кредита
+ALTER TABLE public.transactions
+    ADD COLUMN IF NOT EXISTS counterparty_id INT REFERENCES public.counterparties(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS counterparty_c_id INT REFERENCES public.counterparties(id) ON DELETE SET NULL;
+
+COMMENT ON COLUMN public.transactions.counterparty_id IS 'Контрагент по ИНН плательщика (inn)';
+COMMENT ON COLUMN public.transactions.counterparty_c_id IS 'Контрагент по ИНН получателя (inn_c)';
+
+CREATE INDEX IF NOT EXISTS idx_transactions_counterparty ON public.transactions (counterparty_id);
+CREATE INDEX IF NOT EXISTS idx_transactions_counterparty_c ON public.transactions (counterparty_c_id);
+
+COMMIT;
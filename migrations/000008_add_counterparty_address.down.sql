@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_counterparties_name;
+
+-- Удаление адреса контрагента
+ALTER TABLE public.counterparties
+    DROP COLUMN IF EXISTS address;
+
+COMMIT;
@@ -0,0 +1,12 @@
+BEGIN;
+
+-- Юридический адрес контрагента
+ALTER TABLE public.counterparties
+    ADD COLUMN IF NOT EXISTS address TEXT;
+
+COMMENT ON COLUMN public.counterparties.address IS 'Адрес контрагента (опционально)';
+
+-- Индекс для поиска контрагентов по наименованию
+CREATE INDEX IF NOT EXISTS idx_counterparties_name ON public.counterparties (lower(name));
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+-- Удаление журнала объединения контрагентов
+DROP TABLE IF EXISTS public.counterparty_merges;
+
+COMMIT;
@@ -0,0 +1,23 @@
+BEGIN;
+
+-- Журнал объединения дублей контрагентов
+CREATE TABLE IF NOT EXISTS public.counterparty_merges (
+    id SERIAL PRIMARY KEY,                           -- Первичный ключ
+    target_id INT NOT NULL,                          -- Сохранённый контрагент
+    source_id INT NOT NULL,                          -- Удалённый дубль
+    source_name TEXT NOT NULL,                       -- Наименование дубля на момент объединения
+    source_inn VARCHAR(12) NOT NULL,                 -- ИНН дубля
+    source_kpp VARCHAR(9),                           -- КПП дубля
+    source_address TEXT,                             -- Адрес дубля
+    contracts_moved INT NOT NULL DEFAULT 0,          -- Перенесено контрактов
+    transactions_moved INT NOT NULL DEFAULT 0,       -- Перенесено транзакций
+    merged_by TEXT,                                  -- Пользователь, выполнивший объединение
+    merged_at TIMESTAMPTZ NOT NULL DEFAULT now()     -- Время объединения
+);
+
+COMMENT ON TABLE public.counterparty_merges IS 'Журнал объединения дублей контрагентов';
+
+CREATE INDEX IF NOT EXISTS idx_counterparty_merges_target ON public.counterparty_merges (target_id);
+CREATE INDEX IF NOT EXISTS idx_counterparty_merges_source ON public.counterparty_merges (source_id);
+
+COMMIT;
@@ -0,0 +1,10 @@
+BEGIN;
+
+-- Удаление оповещений и счетов контрагентов
+DROP TABLE IF EXISTS public.counterparty_account_alerts;
+DROP TABLE IF EXISTS public.counterparty_accounts;
+
+ALTER TABLE public.transactions
+    DROP COLUMN IF EXISTS bik;
+
+COMMIT;
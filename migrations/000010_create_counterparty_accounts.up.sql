@@ -0,0 +1,55 @@
+BEGIN;
+
+-- БИК банка из строки выписки (в выписках ВТБ — банк контрагента)
+ALTER TABLE public.transactions
+    ADD COLUMN IF NOT EXISTS bik VARCHAR(9);
+
+-- Банковские счета контрагентов, выявленные по платежам
+CREATE TABLE IF NOT EXISTS public.counterparty_accounts (
+    id SERIAL PRIMARY KEY,                                                               -- Первичный ключ
+    counterparty_id INT NOT NULL REFERENCES public.counterparties(id) ON DELETE CASCADE, -- Контрагент
+    account VARCHAR(20) NOT NULL CHECK (char_length(account) = 20),                     -- Расчётный счёт
+    bik VARCHAR(9) CHECK (bik IS NULL OR char_length(bik) = 9),                          -- БИК банка (уточняется по мере появления)
+    first_transaction_id INT REFERENCES public.transactions(id) ON DELETE SET NULL,      -- Первая транзакция со счётом
+    first_seen_date DATE,                                                                -- Дата первого платежа
+    last_seen_date DATE,                                                                 -- Дата последнего платежа
+    occurrences INT NOT NULL DEFAULT 1 CHECK (occurrences > 0),                          -- Количество платежей
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),                                       -- Когда счёт был выявлен
+    UNIQUE (counterparty_id, account)
+);
+
+COMMENT ON TABLE public.counterparty_accounts IS 'Банковские реквизиты контрагентов, выявленные по выпискам';
+
+-- Оповещения о платежах на ранее не встречавшиеся счета контрагентов
+CREATE TABLE IF NOT EXISTS public.counterparty_account_alerts (
+    id SERIAL PRIMARY KEY,                                                               -- Первичный ключ
+    counterparty_id INT NOT NULL REFERENCES public.counterparties(id) ON DELETE CASCADE, -- Контрагент
+    account_id INT NOT NULL REFERENCES public.counterparty_accounts(id) ON DELETE CASCADE, -- Новый счёт
+    transaction_id INT REFERENCES public.transactions(id) ON DELETE SET NULL,            -- Платёж на новый счёт
+    amount NUMERIC(15, 2) NOT NULL DEFAULT 0.00,                                         -- Сумма платежа
+    payment_date DATE,                                                                   -- Дата платежа
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),                                       -- Время создания оповещения
+    resolved_at TIMESTAMPTZ,                                                             -- Время проверки
+    resolved_by TEXT,                                                                    -- Кто проверил
+    resolution_comment TEXT                                                              -- Комментарий проверяющего
+);
+
+COMMENT ON TABLE public.counterparty_account_alerts IS 'Оповещения о платежах контрагентам на новые счета';
+
+CREATE INDEX IF NOT EXISTS idx_counterparty_account_alerts_open
+    ON public.counterparty_account_alerts (created_at) WHERE resolved_at IS NULL;
+
+-- Заполнение счетов по уже привязанным транзакциям (без оповещений)
+INSERT INTO public.counterparty_accounts (counterparty_id, account, first_transaction_id, first_seen_date, last_seen_date, occurrences)
+SELECT counterparty_id, account, MIN(id), MIN(date), MAX(date), COUNT(*)
+FROM (
+    SELECT id, date, counterparty_id, debit_account AS account FROM public.transactions
+    WHERE counterparty_id IS NOT NULL AND char_length(debit_account) = 20
+    UNION ALL
+    SELECT id, date, counterparty_c_id, credit_account FROM public.transactions
+    WHERE counterparty_c_id IS NOT NULL AND char_length(credit_account) = 20
+) AS t
+GROUP BY counterparty_id, account
+ON CONFLICT DO NOTHING;
+
+COMMIT;
@@ -0,0 +1,13 @@
+BEGIN;
+
+-- Удаление регистрационных сведений контрагента
+ALTER TABLE public.counterparties
+    DROP COLUMN IF EXISTS egrul_updated_at,
+    DROP COLUMN IF EXISTS liquidation_date,
+    DROP COLUMN IF EXISTS registration_date,
+    DROP COLUMN IF EXISTS director,
+    DROP COLUMN IF EXISTS ogrn,
+    DROP COLUMN IF EXISTS short_name,
+    DROP COLUMN IF EXISTS full_name;
+
+COMMIT;
@@ -0,0 +1,21 @@
+BEGIN;
+
+-- Регистрационные сведения контрагента из выписки ЕГРЮЛ/ЕГРИП
+ALTER TABLE public.counterparties
+    ADD COLUMN IF NOT EXISTS full_name TEXT,
+    ADD COLUMN IF NOT EXISTS short_name TEXT,
+    ADD COLUMN IF NOT EXISTS ogrn VARCHAR(15) CHECK (ogrn IS NULL OR char_length(ogrn) IN (13, 15)),
+    ADD COLUMN IF NOT EXISTS director TEXT,
+    ADD COLUMN IF NOT EXISTS registration_date DATE,
+    ADD COLUMN IF NOT EXISTS liquidation_date DATE,
+    ADD COLUMN IF NOT EXISTS egrul_updated_at TIMESTAMPTZ;
+
+COMMENT ON COLUMN public.counterparties.full_name IS 'Полное наименование по ЕГРЮЛ/ЕГРИП';
+COMMENT ON COLUMN public.counterparties.short_name IS 'Сокращённое наименование по ЕГРЮЛ';
+COMMENT ON COLUMN public.counterparties.ogrn IS 'ОГРН (13 символов) или ОГРНИП (15 символов)';
+COMMENT ON COLUMN public.counterparties.director IS 'Руководитель: должность и ФИО';
+COMMENT ON COLUMN public.counterparties.registration_date IS 'Дата государственной регистрации';
+COMMENT ON COLUMN public.counterparties.liquidation_date IS 'Дата прекращения деятельности (ликвидации)';
+COMMENT ON COLUMN public.counterparties.egrul_updated_at IS 'Когда сведения последний раз загружались из выписки';
+
+COMMIT;
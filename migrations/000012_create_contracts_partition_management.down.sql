@@ -0,0 +1,6 @@
+BEGIN;
+
+-- Удаление функции создания партиций (созданные партиции остаются)
+DROP FUNCTION IF EXISTS public.ensure_contracts_partition(INT);
+
+COMMIT;
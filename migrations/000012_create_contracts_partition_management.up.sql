@@ -0,0 +1,47 @@
+BEGIN;
+
+-- Партиция по умолчанию для контрактов, дата которых не попала ни в одну годовую партицию
+CREATE TABLE IF NOT EXISTS public.contracts_default PARTITION OF public.contracts DEFAULT;
+
+-- Функция создания годовой партиции contracts.
+-- Строки нужного года, успевшие попасть в партицию по умолчанию, переносятся в новую партицию.
+CREATE OR REPLACE FUNCTION public.ensure_contracts_partition(p_year INT)
+RETURNS BOOLEAN
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    partition_name TEXT := format('contracts_%s', p_year);
+    range_from DATE := make_date(p_year, 1, 1);
+    range_to DATE := make_date(p_year + 1, 1, 1);
+BEGIN
+    -- Сериализуем создание партиций между параллельными вызовами
+    PERFORM pg_advisory_xact_lock(hashtext('public.contracts partitions'));
+
+    IF to_regclass(format('public.%I', partition_name)) IS NOT NULL THEN
+        RETURN FALSE;
+    END IF;
+
+    DROP TABLE IF EXISTS pg_temp.contracts_partition_move;
+    CREATE TEMP TABLE contracts_partition_move ON COMMIT DROP AS
+        SELECT * FROM public.contracts_default
+        WHERE contract_date >= range_from AND contract_date < range_to;
+    DELETE FROM public.contracts_default
+        WHERE contract_date >= range_from AND contract_date < range_to;
+
+    EXECUTE format('CREATE TABLE public.%I PARTITION OF public.contracts FOR VALUES FROM (%L) TO (%L)',
+                   partition_name, range_from, range_to);
+
+    INSERT INTO public.contracts SELECT * FROM contracts_partition_move;
+    DROP TABLE contracts_partition_move;
+
+    RETURN TRUE;
+END;
+$$;
+
+COMMENT ON FUNCTION public.ensure_contracts_partition(INT) IS 'Создаёт годовую партицию contracts, если её ещё нет';
+
+-- Партиции с 2025 года по следующий за текущим
+SELECT public.ensure_contracts_partition(y::int)
+FROM generate_series(2025, extract(year FROM CURRENT_DATE)::int + 1) AS y;
+
+COMMIT;
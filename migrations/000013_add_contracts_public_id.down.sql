@@ -0,0 +1,14 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_contracts_counterparty;
+DROP INDEX IF EXISTS idx_contracts_uid;
+
+-- Удаление публичного идентификатора и служебных полей контракта
+ALTER TABLE public.contracts
+    DROP COLUMN IF EXISTS deleted_by,
+    DROP COLUMN IF EXISTS deleted_at,
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS created_at,
+    DROP COLUMN IF EXISTS uid;
+
+COMMIT;
@@ -0,0 +1,18 @@
+BEGIN;
+
+-- Публичный идентификатор контракта, не зависящий от номера и даты, и служебные поля
+ALTER TABLE public.contracts
+    ADD COLUMN IF NOT EXISTS uid UUID NOT NULL DEFAULT gen_random_uuid(),
+    ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS deleted_by TEXT;
+
+COMMENT ON COLUMN public.contracts.uid IS 'Публичный идентификатор контракта для API';
+COMMENT ON COLUMN public.contracts.deleted_at IS 'Время мягкого удаления контракта';
+
+-- Уникальный индекс партиционированной таблицы обязан включать ключ партиционирования
+CREATE UNIQUE INDEX IF NOT EXISTS idx_contracts_uid ON public.contracts (uid, contract_date);
+CREATE INDEX IF NOT EXISTS idx_contracts_counterparty ON public.contracts (counterparty_id);
+
+COMMIT;
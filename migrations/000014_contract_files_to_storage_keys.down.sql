@@ -0,0 +1,17 @@
+BEGIN;
+
+-- Ключи хранилища снова преобразуются в абсолютные пути внутри каталога загрузки по умолчанию
+UPDATE public.contracts
+SET contract_file_path       = '/app/uploads/' || contract_file_path,
+    memo_file_path           = '/app/uploads/' || memo_file_path,
+    ecp_file_path            = '/app/uploads/' || ecp_file_path,
+    technical_task_file_path = '/app/uploads/' || technical_task_file_path,
+    additional_files_paths   = CASE WHEN additional_files_paths IS NOT NULL
+                                    THEN ARRAY(SELECT '/app/uploads/' || p FROM unnest(additional_files_paths) AS p) END
+WHERE additional_files_paths IS NOT NULL
+   OR contract_file_path IS NOT NULL
+   OR memo_file_path IS NOT NULL
+   OR ecp_file_path IS NOT NULL
+   OR technical_task_file_path IS NOT NULL;
+
+COMMIT;
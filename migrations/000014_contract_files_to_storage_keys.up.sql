@@ -0,0 +1,53 @@
+BEGIN;
+
+-- Пути к файлам контрактов заменяются ключами хранилища документов: путь относительно каталога загрузки.
+-- Каталог загрузки (file_upload.upload_dir) настраивается и может быть относительным, поэтому ключ
+-- определяется не по известному префиксу, а по структуре каталогов, в которых сохранялись файлы контрактов:
+--   <каталог загрузки>/contracts/<uid>/<файл>        → contracts/<uid>/<файл>
+--   <каталог загрузки>/<номер контракта>/<файл>      → <номер контракта>/<файл> (до каталогов по uid)
+-- Путь другой структуры считается путём от каталога по умолчанию /app/uploads
+CREATE FUNCTION pg_temp.contract_file_key(p_path TEXT, p_uid UUID, p_number TEXT) RETURNS TEXT AS $$
+DECLARE
+    v_path TEXT := replace(p_path, E'\\', '/');
+    v_name TEXT := regexp_replace(v_path, '^.*/', '');
+    v_key  TEXT;
+BEGIN
+    IF p_path IS NULL OR p_path = '' THEN
+        RETURN NULL;
+    END IF;
+
+    FOREACH v_key IN ARRAY ARRAY['contracts/' || p_uid::text || '/' || v_name,
+                                 trim(both '/' from p_number) || '/' || v_name] LOOP
+        IF v_path = v_key OR right(v_path, length(v_key) + 1) = '/' || v_key THEN
+            RETURN v_key;
+        END IF;
+    END LOOP;
+    RETURN regexp_replace(v_path, '^/app/uploads/+', '');
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+-- Пустые строки, которые сохранялись вместо отсутствующих файлов, заменяются на NULL
+UPDATE public.contracts
+SET contract_file_path       = pg_temp.contract_file_key(contract_file_path, uid, contract_number),
+    memo_file_path           = pg_temp.contract_file_key(memo_file_path, uid, contract_number),
+    ecp_file_path            = pg_temp.contract_file_key(ecp_file_path, uid, contract_number),
+    technical_task_file_path = pg_temp.contract_file_key(technical_task_file_path, uid, contract_number),
+    additional_files_paths   = CASE WHEN additional_files_paths IS NOT NULL
+                                    THEN ARRAY(SELECT pg_temp.contract_file_key(p, uid, contract_number)
+                                               FROM unnest(additional_files_paths) AS p
+                                               WHERE p <> '') END
+WHERE additional_files_paths IS NOT NULL
+   OR contract_file_path IS NOT NULL
+   OR memo_file_path IS NOT NULL
+   OR ecp_file_path IS NOT NULL
+   OR technical_task_file_path IS NOT NULL;
+
+DROP FUNCTION pg_temp.contract_file_key(TEXT, UUID, TEXT);
+
+COMMENT ON COLUMN public.contracts.contract_file_path IS 'Ключ файла контракта в хранилище документов';
+COMMENT ON COLUMN public.contracts.memo_file_path IS 'Ключ служебной записки в хранилище документов';
+COMMENT ON COLUMN public.contracts.ecp_file_path IS 'Ключ файла ЭЦП в хранилище документов';
+COMMENT ON COLUMN public.contracts.technical_task_file_path IS 'Ключ технического задания в хранилище документов';
+COMMENT ON COLUMN public.contracts.additional_files_paths IS 'Ключи дополнительных файлов в хранилище документов';
+
+COMMIT;
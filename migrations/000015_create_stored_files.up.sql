@@ -0,0 +1,29 @@
+BEGIN;
+
+-- Сведения о файлах в хранилище документов. Файлы хранятся под именами, сгенерированными сервером,
+-- а исходное имя от пользователя сохраняется здесь и используется при скачивании
+CREATE TABLE IF NOT EXISTS public.stored_files (
+    storage_key   TEXT PRIMARY KEY,                    -- Ключ объекта в хранилище
+    original_name TEXT NOT NULL,                       -- Исходное имя файла
+    content_type  TEXT,                                -- MIME-тип файла
+    size          BIGINT NOT NULL CHECK (size >= 0),   -- Размер в байтах
+    uploaded_by   TEXT,                                -- Пользователь, загрузивший файл
+    uploaded_at   TIMESTAMPTZ NOT NULL DEFAULT now()   -- Время загрузки
+);
+
+COMMENT ON TABLE public.stored_files IS 'Метаданные файлов в хранилище документов';
+
+-- Уже загруженные файлы контрактов хранились под исходными именами — переносим их как есть
+INSERT INTO public.stored_files (storage_key, original_name, size)
+SELECT key, regexp_replace(key, '^.*/', ''), 0
+FROM (
+    SELECT contract_file_path AS key FROM public.contracts
+    UNION SELECT memo_file_path FROM public.contracts
+    UNION SELECT ecp_file_path FROM public.contracts
+    UNION SELECT technical_task_file_path FROM public.contracts
+    UNION SELECT unnest(additional_files_paths) FROM public.contracts
+) AS keys
+WHERE key IS NOT NULL AND key <> ''
+ON CONFLICT (storage_key) DO NOTHING;
+
+COMMIT;
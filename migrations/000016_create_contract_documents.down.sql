@@ -0,0 +1,22 @@
+BEGIN;
+
+-- Возврат колонок с файлами; в них переносятся текущие версии документов известных видов
+ALTER TABLE public.contracts
+    ADD COLUMN IF NOT EXISTS contract_file_path TEXT,
+    ADD COLUMN IF NOT EXISTS memo_file_path TEXT,
+    ADD COLUMN IF NOT EXISTS ecp_file_path TEXT,
+    ADD COLUMN IF NOT EXISTS technical_task_file_path TEXT,
+    ADD COLUMN IF NOT EXISTS additional_files_paths TEXT[];
+
+UPDATE public.contracts c
+SET contract_file_path       = (SELECT storage_key FROM public.contract_documents d WHERE d.contract_uid = c.uid AND d.is_current AND d.document_type = 'contract' ORDER BY d.root_id LIMIT 1),
+    memo_file_path           = (SELECT storage_key FROM public.contract_documents d WHERE d.contract_uid = c.uid AND d.is_current AND d.document_type = 'memo' ORDER BY d.root_id LIMIT 1),
+    ecp_file_path            = (SELECT storage_key FROM public.contract_documents d WHERE d.contract_uid = c.uid AND d.is_current AND d.document_type = 'ecp' ORDER BY d.root_id LIMIT 1),
+    technical_task_file_path = (SELECT storage_key FROM public.contract_documents d WHERE d.contract_uid = c.uid AND d.is_current AND d.document_type = 'technical_task' ORDER BY d.root_id LIMIT 1),
+    additional_files_paths   = (SELECT array_agg(storage_key ORDER BY d.root_id) FROM public.contract_documents d
+                                WHERE d.contract_uid = c.uid AND d.is_current
+                                  AND d.document_type NOT IN ('contract', 'memo', 'ecp', 'technical_task'));
+
+DROP TABLE IF EXISTS public.contract_documents;
+
+COMMIT;
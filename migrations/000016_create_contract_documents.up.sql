@@ -0,0 +1,69 @@
+BEGIN;
+
+-- Документы контракта с историей версий. Каждая строка — одна версия файла;
+-- версии одного документа объединяет root_id (идентификатор первой версии)
+CREATE TABLE IF NOT EXISTS public.contract_documents (
+    id            SERIAL PRIMARY KEY,
+    root_id       INT NOT NULL REFERENCES public.contract_documents(id) ON DELETE CASCADE, -- Первая версия документа
+    contract_uid  UUID NOT NULL,                        -- Публичный идентификатор контракта (contracts.uid)
+    document_type VARCHAR(100) NOT NULL,                -- Вид документа: contract, memo, ecp, technical_task, additional, acceptance_act и т.д.
+    version       INT NOT NULL CHECK (version > 0),     -- Номер версии документа
+    storage_key   TEXT NOT NULL UNIQUE REFERENCES public.stored_files(storage_key), -- Ключ файла в хранилище
+    sha256        CHAR(64),                             -- Хеш содержимого файла
+    uploaded_by   TEXT,                                 -- Пользователь, загрузивший версию
+    uploaded_at   TIMESTAMPTZ NOT NULL DEFAULT now(),   -- Время загрузки версии
+    is_current    BOOLEAN NOT NULL DEFAULT TRUE,        -- Текущая (последняя) версия документа
+    UNIQUE (root_id, version)
+);
+
+COMMENT ON TABLE public.contract_documents IS 'Документы контрактов с историей версий';
+
+CREATE INDEX IF NOT EXISTS idx_contract_documents_contract ON public.contract_documents (contract_uid);
+-- У документа ровно одна текущая версия
+CREATE UNIQUE INDEX IF NOT EXISTS idx_contract_documents_current ON public.contract_documents (root_id) WHERE is_current;
+
+-- Перенос файлов из колонок contracts: каждый файл становится первой версией отдельного документа
+INSERT INTO public.stored_files (storage_key, original_name, size)
+SELECT key, regexp_replace(key, '^.*/', ''), 0
+FROM (
+    SELECT contract_file_path AS key FROM public.contracts
+    UNION SELECT memo_file_path FROM public.contracts
+    UNION SELECT ecp_file_path FROM public.contracts
+    UNION SELECT technical_task_file_path FROM public.contracts
+    UNION SELECT unnest(additional_files_paths) FROM public.contracts
+) AS keys
+WHERE key IS NOT NULL AND key <> ''
+ON CONFLICT (storage_key) DO NOTHING;
+
+WITH files AS (
+    SELECT c.uid, d.ord, d.document_type, d.key, c.created_at
+    FROM public.contracts c
+    CROSS JOIN LATERAL (
+        VALUES (1::bigint, 'contract', c.contract_file_path),
+               (2, 'memo', c.memo_file_path),
+               (3, 'ecp', c.ecp_file_path),
+               (4, 'technical_task', c.technical_task_file_path)
+        UNION ALL
+        SELECT 4 + a.idx, 'additional', a.key
+        FROM unnest(c.additional_files_paths) WITH ORDINALITY AS a(key, idx)
+    ) AS d(ord, document_type, key)
+    WHERE COALESCE(d.key, '') <> ''
+), numbered AS (
+    SELECT nextval(pg_get_serial_sequence('public.contract_documents', 'id')) AS id, files.*
+    FROM files
+    ORDER BY uid, ord
+)
+INSERT INTO public.contract_documents (id, root_id, contract_uid, document_type, version, storage_key, uploaded_at)
+SELECT id, id, uid, document_type, 1, key, created_at
+FROM numbered
+ON CONFLICT (storage_key) DO NOTHING;
+
+-- Колонки с путями больше не нужны
+ALTER TABLE public.contracts
+    DROP COLUMN IF EXISTS contract_file_path,
+    DROP COLUMN IF EXISTS memo_file_path,
+    DROP COLUMN IF EXISTS ecp_file_path,
+    DROP COLUMN IF EXISTS technical_task_file_path,
+    DROP COLUMN IF EXISTS additional_files_paths;
+
+COMMIT;
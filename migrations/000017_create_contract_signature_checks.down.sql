@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS public.contract_signature_signers;
+DROP TABLE IF EXISTS public.contract_signature_checks;
+
+COMMIT;
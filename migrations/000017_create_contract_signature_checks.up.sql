@@ -0,0 +1,44 @@
+BEGIN;
+
+-- Результаты проверки ЭЦП контракта: каждая проверка сохраняется, актуальна последняя
+CREATE TABLE IF NOT EXISTS public.contract_signature_checks (
+    id                    SERIAL PRIMARY KEY,
+    contract_uid          UUID NOT NULL,                                  -- Публичный идентификатор контракта (contracts.uid)
+    signature_document_id INT NOT NULL REFERENCES public.contract_documents(id), -- Проверенная версия файла подписи
+    content_document_id   INT NOT NULL REFERENCES public.contract_documents(id), -- Версия файла контракта, для которой проверялась подпись
+    status                VARCHAR(20) NOT NULL CHECK (status IN ('valid', 'expired', 'invalid', 'unsupported', 'malformed')),
+    message               TEXT,                                           -- Пояснение к результату
+    checked_by            TEXT,                                           -- Пользователь, запустивший проверку
+    checked_at            TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+COMMENT ON TABLE public.contract_signature_checks IS 'Проверки отсоединённой ЭЦП контрактов';
+
+CREATE INDEX IF NOT EXISTS idx_contract_signature_checks_contract ON public.contract_signature_checks (contract_uid, id DESC);
+
+-- Подписанты из проверенной подписи с данными сертификата
+CREATE TABLE IF NOT EXISTS public.contract_signature_signers (
+    id                  SERIAL PRIMARY KEY,
+    check_id            INT NOT NULL REFERENCES public.contract_signature_checks(id) ON DELETE CASCADE,
+    status              VARCHAR(20) NOT NULL CHECK (status IN ('valid', 'expired', 'invalid', 'unsupported')),
+    message             TEXT,
+    content_match       BOOLEAN,              -- Подпись относится к файлу контракта; NULL — не проверялось
+    signature_valid     BOOLEAN,              -- Подпись математически верна; NULL — алгоритм не поддерживается
+    certificate_expired BOOLEAN NOT NULL DEFAULT FALSE,
+    subject             TEXT,                 -- Владелец сертификата
+    common_name         TEXT,
+    organization        TEXT,
+    inn                 VARCHAR(12),          -- ИНН организации владельца сертификата
+    ogrn                VARCHAR(15),
+    serial_number       TEXT,                 -- Серийный номер сертификата (hex)
+    issuer              TEXT,                 -- Издатель сертификата
+    valid_from          TIMESTAMPTZ,
+    valid_to            TIMESTAMPTZ,
+    signing_time        TIMESTAMPTZ,          -- Время подписания из подписи
+    digest_algorithm    TEXT,
+    signature_algorithm TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_contract_signature_signers_check ON public.contract_signature_signers (check_id);
+
+COMMIT;
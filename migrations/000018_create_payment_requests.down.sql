@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS public.payment_request_documents;
+DROP TABLE IF EXISTS public.payment_requests;
+
+COMMIT;
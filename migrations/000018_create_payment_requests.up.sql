@@ -0,0 +1,39 @@
+BEGIN;
+
+-- Заявки на оплату по контрактам
+CREATE TABLE IF NOT EXISTS public.payment_requests (
+    id              SERIAL PRIMARY KEY,
+    contract_uid    UUID NOT NULL,                                            -- Публичный идентификатор контракта (contracts.uid)
+    counterparty_id INT NOT NULL REFERENCES public.counterparties(id),        -- Получатель платежа
+    amount          NUMERIC(15, 2) NOT NULL CHECK (amount > 0),               -- Сумма к оплате
+    document_type   VARCHAR(100) NOT NULL,                                    -- Вид документа на оплату: счёт, акт, УПД
+    document_number VARCHAR(50) NOT NULL,                                     -- Номер документа на оплату
+    document_date   DATE NOT NULL,                                            -- Дата документа на оплату
+    payment_purpose TEXT NOT NULL,                                            -- Назначение платежа
+    address         TEXT,                                                     -- Адрес объекта
+    created_by      TEXT,                                                     -- Автор заявки
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    -- Один документ контрагента не может быть предъявлен к оплате дважды
+    UNIQUE (counterparty_id, document_type, document_number, document_date)
+);
+
+COMMENT ON TABLE public.payment_requests IS 'Заявки на оплату по контрактам';
+
+CREATE INDEX IF NOT EXISTS idx_payment_requests_contract ON public.payment_requests (contract_uid);
+CREATE INDEX IF NOT EXISTS idx_payment_requests_document_date ON public.payment_requests (document_date);
+
+-- Файлы заявок на оплату
+CREATE TABLE IF NOT EXISTS public.payment_request_documents (
+    id                 SERIAL PRIMARY KEY,
+    payment_request_id INT NOT NULL REFERENCES public.payment_requests(id) ON DELETE CASCADE,
+    document_type      VARCHAR(20) NOT NULL CHECK (document_type IN ('request', 'supporting')), -- Документ на оплату или дополнительный
+    storage_key        TEXT NOT NULL UNIQUE REFERENCES public.stored_files(storage_key),
+    sha256             CHAR(64),
+    uploaded_by        TEXT,
+    uploaded_at        TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_request_documents_request ON public.payment_request_documents (payment_request_id);
+
+COMMIT;
@@ -0,0 +1,10 @@
+BEGIN;
+
+DROP TABLE IF EXISTS public.payment_request_decisions;
+DROP TABLE IF EXISTS public.payment_request_steps;
+DROP FUNCTION IF EXISTS public.forbid_approval_changes();
+
+DROP INDEX IF EXISTS public.idx_payment_requests_status;
+ALTER TABLE public.payment_requests DROP COLUMN IF EXISTS status;
+
+COMMIT;
@@ -0,0 +1,53 @@
+BEGIN;
+
+-- Статус согласования заявки: pending — на согласовании, approved — согласована, rejected — отклонена
+ALTER TABLE public.payment_requests
+    ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'approved', 'rejected'));
+
+CREATE INDEX IF NOT EXISTS idx_payment_requests_status ON public.payment_requests (status);
+
+-- Этапы согласования заявки. Фиксируются при создании заявки по действующей конфигурации,
+-- поэтому изменение цепочки в конфигурации не влияет на уже поданные заявки
+CREATE TABLE IF NOT EXISTS public.payment_request_steps (
+    id                 SERIAL PRIMARY KEY,
+    payment_request_id INT NOT NULL REFERENCES public.payment_requests(id),
+    step_order         INT NOT NULL CHECK (step_order > 0), -- Порядок этапа в цепочке
+    code               VARCHAR(50) NOT NULL,                -- Код этапа из конфигурации
+    title              TEXT NOT NULL,                       -- Название этапа
+    role               VARCHAR(50),                         -- Роль пользователя, принимающего решение
+    UNIQUE (payment_request_id, step_order),
+    UNIQUE (payment_request_id, code)
+);
+
+-- Решения по этапам согласования. Записи не изменяются и не удаляются
+CREATE TABLE IF NOT EXISTS public.payment_request_decisions (
+    id                 SERIAL PRIMARY KEY,
+    payment_request_id INT NOT NULL REFERENCES public.payment_requests(id),
+    step_id            INT NOT NULL UNIQUE REFERENCES public.payment_request_steps(id), -- По этапу принимается одно решение
+    decision           VARCHAR(10) NOT NULL CHECK (decision IN ('approve', 'reject')),
+    comment            TEXT,
+    decided_by         TEXT NOT NULL,                      -- Пользователь, принявший решение
+    decided_at         TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_request_decisions_request ON public.payment_request_decisions (payment_request_id);
+
+COMMENT ON TABLE public.payment_request_decisions IS 'Журнал решений по согласованию заявок на оплату (только добавление)';
+
+-- Журнал решений и зафиксированные этапы защищены от изменения и удаления
+CREATE OR REPLACE FUNCTION public.forbid_approval_changes() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'Записи % не могут изменяться или удаляться', TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER payment_request_decisions_immutable
+    BEFORE UPDATE OR DELETE ON public.payment_request_decisions
+    FOR EACH ROW EXECUTE FUNCTION public.forbid_approval_changes();
+
+CREATE TRIGGER payment_request_steps_immutable
+    BEFORE UPDATE OR DELETE ON public.payment_request_steps
+    FOR EACH ROW EXECUTE FUNCTION public.forbid_approval_changes();
+
+COMMIT;
@@ -0,0 +1,10 @@
+BEGIN;
+
+DROP TABLE IF EXISTS public.transaction_match_candidates;
+DROP TABLE IF EXISTS public.transaction_match_reviews;
+DROP TABLE IF EXISTS public.transaction_contract_links;
+
+DROP INDEX IF EXISTS public.idx_transactions_contract_unmatched;
+ALTER TABLE public.transactions DROP COLUMN IF EXISTS contract_matched_at;
+
+COMMIT;
@@ -0,0 +1,54 @@
+BEGIN;
+
+-- Время, когда транзакция прошла автоматическое сопоставление с контрактами
+ALTER TABLE public.transactions
+    ADD COLUMN IF NOT EXISTS contract_matched_at TIMESTAMPTZ;
+
+COMMENT ON COLUMN public.transactions.contract_matched_at IS 'Когда транзакция прошла сопоставление с контрактами';
+
+CREATE INDEX IF NOT EXISTS idx_transactions_contract_unmatched
+    ON public.transactions (id) WHERE contract_matched_at IS NULL;
+
+-- Связи транзакций с контрактами
+CREATE TABLE IF NOT EXISTS public.transaction_contract_links (
+    id             SERIAL PRIMARY KEY,
+    transaction_id INT NOT NULL REFERENCES public.transactions(id) ON DELETE CASCADE,
+    contract_uid   UUID NOT NULL,                                            -- Публичный идентификатор контракта (contracts.uid)
+    confidence     NUMERIC(4, 3) NOT NULL CHECK (confidence BETWEEN 0 AND 1), -- Уверенность сопоставления
+    method         VARCHAR(10) NOT NULL CHECK (method IN ('auto', 'manual')),  -- Автоматически или вручную
+    reference      TEXT,                                                     -- Ссылка на контракт из назначения платежа
+    created_by     TEXT,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (transaction_id, contract_uid)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_contract_links_contract ON public.transaction_contract_links (contract_uid);
+
+COMMENT ON TABLE public.transaction_contract_links IS 'Связи банковских транзакций с контрактами';
+
+-- Очередь ручной проверки: ссылки на контракт, которые не удалось однозначно сопоставить
+CREATE TABLE IF NOT EXISTS public.transaction_match_reviews (
+    id                 SERIAL PRIMARY KEY,
+    transaction_id     INT NOT NULL UNIQUE REFERENCES public.transactions(id) ON DELETE CASCADE,
+    reason             VARCHAR(20) NOT NULL CHECK (reason IN ('ambiguous', 'low_confidence', 'not_found')),
+    reference          TEXT,                                                 -- Найденные ссылки на контракт
+    status             VARCHAR(10) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'linked', 'dismissed')),
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT now(),
+    resolved_at        TIMESTAMPTZ,
+    resolved_by        TEXT,
+    resolution_comment TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_match_reviews_pending
+    ON public.transaction_match_reviews (created_at) WHERE status = 'pending';
+
+-- Контракты-кандидаты для ручной проверки
+CREATE TABLE IF NOT EXISTS public.transaction_match_candidates (
+    id           SERIAL PRIMARY KEY,
+    review_id    INT NOT NULL REFERENCES public.transaction_match_reviews(id) ON DELETE CASCADE,
+    contract_uid UUID NOT NULL,
+    confidence   NUMERIC(4, 3) NOT NULL CHECK (confidence BETWEEN 0 AND 1),
+    UNIQUE (review_id, contract_uid)
+);
+
+COMMIT;
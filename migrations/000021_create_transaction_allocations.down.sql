@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP VIEW IF EXISTS public.contract_payment_amounts;
+DROP TABLE IF EXISTS public.transaction_allocations;
+DROP TABLE IF EXISTS public.transaction_allocation_sets;
+
+COMMIT;
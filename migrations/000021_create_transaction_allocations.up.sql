@@ -0,0 +1,54 @@
+BEGIN;
+
+-- Распределения суммы транзакции по контрактам и заявкам на оплату с историей изменений.
+-- Каждое изменение создаёт новую версию; предыдущие версии сохраняются для аудита
+CREATE TABLE IF NOT EXISTS public.transaction_allocation_sets (
+    id             SERIAL PRIMARY KEY,
+    transaction_id INT NOT NULL REFERENCES public.transactions(id) ON DELETE CASCADE,
+    version        INT NOT NULL CHECK (version > 0),   -- Номер версии распределения
+    comment        TEXT,                               -- Причина изменения
+    created_by     TEXT,                               -- Пользователь, изменивший распределение
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    is_current     BOOLEAN NOT NULL DEFAULT TRUE,      -- Действующая версия
+    UNIQUE (transaction_id, version)
+);
+
+COMMENT ON TABLE public.transaction_allocation_sets IS 'Версии распределения транзакций по контрактам';
+
+-- У транзакции не больше одной действующей версии распределения
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transaction_allocation_sets_current
+    ON public.transaction_allocation_sets (transaction_id) WHERE is_current;
+
+-- Строки распределения: часть суммы транзакции, отнесённая на контракт и, при необходимости, на заявку
+CREATE TABLE IF NOT EXISTS public.transaction_allocations (
+    id                 SERIAL PRIMARY KEY,
+    set_id             INT NOT NULL REFERENCES public.transaction_allocation_sets(id) ON DELETE CASCADE,
+    contract_uid       UUID NOT NULL,                                     -- Публичный идентификатор контракта (contracts.uid)
+    payment_request_id INT REFERENCES public.payment_requests(id),        -- Заявка на оплату по контракту
+    amount             NUMERIC(15, 2) NOT NULL CHECK (amount > 0),       -- Отнесённая сумма
+    comment            TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_allocations_set ON public.transaction_allocations (set_id);
+CREATE INDEX IF NOT EXISTS idx_transaction_allocations_contract ON public.transaction_allocations (contract_uid);
+CREATE INDEX IF NOT EXISTS idx_transaction_allocations_request ON public.transaction_allocations (payment_request_id);
+
+-- Суммы платежей, относящиеся к контрактам. Распределённая транзакция учитывается по действующему
+-- распределению; нераспределённая — полной суммой, если она связана ровно с одним контрактом
+CREATE OR REPLACE VIEW public.contract_payment_amounts AS
+SELECT s.transaction_id, a.contract_uid, SUM(a.amount) AS amount
+FROM public.transaction_allocation_sets s
+JOIN public.transaction_allocations a ON a.set_id = s.id
+WHERE s.is_current
+GROUP BY s.transaction_id, a.contract_uid
+UNION ALL
+SELECT l.transaction_id, l.contract_uid, COALESCE(NULLIF(t.debit, 0), t.credit, 0)
+FROM public.transaction_contract_links l
+JOIN public.transactions t ON t.id = l.transaction_id
+WHERE NOT EXISTS (SELECT 1 FROM public.transaction_allocation_sets s WHERE s.transaction_id = l.transaction_id AND s.is_current)
+  AND NOT EXISTS (SELECT 1 FROM public.transaction_contract_links o
+                  WHERE o.transaction_id = l.transaction_id AND o.contract_uid <> l.contract_uid);
+
+COMMENT ON VIEW public.contract_payment_amounts IS 'Суммы платежей по контрактам с учётом распределений';
+
+COMMIT;
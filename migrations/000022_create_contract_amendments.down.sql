@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP FUNCTION IF EXISTS public.contract_amended_terms(UUID, DATE);
+DROP TABLE IF EXISTS public.contract_amendments;
+
+COMMIT;
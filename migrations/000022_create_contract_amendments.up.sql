@@ -0,0 +1,57 @@
+BEGIN;
+
+-- Дополнительные соглашения к контрактам. Строка contracts хранит первоначальные условия,
+-- действующие условия на дату получаются применением соглашений, вступивших в силу к этой дате.
+-- NULL в колонке условия означает, что соглашение его не меняет
+CREATE TABLE IF NOT EXISTS public.contract_amendments (
+    id               SERIAL PRIMARY KEY,
+    contract_uid     UUID NOT NULL,                                  -- Публичный идентификатор контракта (contracts.uid)
+    amendment_number VARCHAR(50) NOT NULL,                           -- Номер дополнительного соглашения
+    amendment_date   DATE NOT NULL,                                  -- Дата заключения соглашения
+    effective_date   DATE NOT NULL,                                  -- Дата вступления изменений в силу
+    amount           NUMERIC(15, 2) CHECK (amount >= 0),             -- Новая сумма контракта
+    execution_period DATE,                                           -- Новый срок исполнения
+    validity_period  DATE,                                           -- Новый срок действия
+    subject          TEXT,                                           -- Новый предмет контракта
+    payment_days     INT CHECK (payment_days >= 0),                  -- Новое количество дней на оплату
+    comment          TEXT,
+    document_id      INT REFERENCES public.contract_documents(id),   -- Документ соглашения (первая версия документа контракта)
+    created_by       TEXT,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (contract_uid, amendment_number),
+    CHECK (amount IS NOT NULL OR execution_period IS NOT NULL OR validity_period IS NOT NULL
+           OR subject IS NOT NULL OR payment_days IS NOT NULL)
+);
+
+COMMENT ON TABLE public.contract_amendments IS 'Дополнительные соглашения к контрактам';
+
+CREATE INDEX IF NOT EXISTS idx_contract_amendments_contract ON public.contract_amendments (contract_uid, effective_date);
+
+-- Условия контракта, изменённые соглашениями, вступившими в силу не позже p_date.
+-- Для каждого условия берётся последнее соглашение, которое его меняет; NULL — условие не менялось.
+-- Всегда возвращает одну строку
+CREATE OR REPLACE FUNCTION public.contract_amended_terms(p_uid UUID, p_date DATE)
+RETURNS TABLE (amount NUMERIC(15, 2), execution_period DATE, validity_period DATE, subject TEXT,
+               payment_days INT, amendments INT)
+LANGUAGE sql STABLE AS $$
+    SELECT
+        (SELECT a.amount FROM public.contract_amendments a
+         WHERE a.contract_uid = p_uid AND a.effective_date <= p_date AND a.amount IS NOT NULL
+         ORDER BY a.effective_date DESC, a.amendment_date DESC, a.id DESC LIMIT 1),
+        (SELECT a.execution_period FROM public.contract_amendments a
+         WHERE a.contract_uid = p_uid AND a.effective_date <= p_date AND a.execution_period IS NOT NULL
+         ORDER BY a.effective_date DESC, a.amendment_date DESC, a.id DESC LIMIT 1),
+        (SELECT a.validity_period FROM public.contract_amendments a
+         WHERE a.contract_uid = p_uid AND a.effective_date <= p_date AND a.validity_period IS NOT NULL
+         ORDER BY a.effective_date DESC, a.amendment_date DESC, a.id DESC LIMIT 1),
+        (SELECT a.subject FROM public.contract_amendments a
+         WHERE a.contract_uid = p_uid AND a.effective_date <= p_date AND a.subject IS NOT NULL
+         ORDER BY a.effective_date DESC, a.amendment_date DESC, a.id DESC LIMIT 1),
+        (SELECT a.payment_days FROM public.contract_amendments a
+         WHERE a.contract_uid = p_uid AND a.effective_date <= p_date AND a.payment_days IS NOT NULL
+         ORDER BY a.effective_date DESC, a.amendment_date DESC, a.id DESC LIMIT 1),
+        (SELECT COUNT(*)::int FROM public.contract_amendments a
+         WHERE a.contract_uid = p_uid AND a.effective_date <= p_date)
+$$;
+
+COMMIT;
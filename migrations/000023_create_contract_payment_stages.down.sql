@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP TABLE IF EXISTS public.contract_payment_stages;
+
+COMMIT;
@@ -0,0 +1,21 @@
+BEGIN;
+
+-- График платежей по контракту: этапы с плановой датой и суммой оплаты.
+-- График заменяется целиком, номера этапов идут по возрастанию плановой даты
+CREATE TABLE IF NOT EXISTS public.contract_payment_stages (
+    id           SERIAL PRIMARY KEY,
+    contract_uid UUID NOT NULL,                                 -- Публичный идентификатор контракта (contracts.uid)
+    stage_number INT NOT NULL CHECK (stage_number > 0),         -- Номер этапа
+    title        TEXT,                                          -- Описание этапа: аванс, поставка, окончательный расчёт и т.д.
+    planned_date DATE NOT NULL,                                 -- Плановая дата оплаты
+    amount       NUMERIC(15, 2) NOT NULL CHECK (amount > 0),   -- Плановая сумма оплаты
+    created_by   TEXT,                                          -- Пользователь, сохранивший график
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (contract_uid, stage_number)
+);
+
+COMMENT ON TABLE public.contract_payment_stages IS 'Графики платежей по контрактам';
+
+CREATE INDEX IF NOT EXISTS idx_contract_payment_stages_date ON public.contract_payment_stages (planned_date);
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP TABLE IF EXISTS public.contract_eaist_status_history;
+DROP FUNCTION IF EXISTS public.forbid_eaist_status_history_changes();
+DROP INDEX IF EXISTS public.idx_contracts_eaist_status;
+
+COMMIT;
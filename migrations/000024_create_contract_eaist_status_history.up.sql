@@ -0,0 +1,38 @@
+BEGIN;
+
+-- История статусов ЕАИСТ контрактов. Каждая смена статуса добавляет запись; записи не изменяются и не удаляются
+CREATE TABLE IF NOT EXISTS public.contract_eaist_status_history (
+    id           SERIAL PRIMARY KEY,
+    contract_uid UUID NOT NULL,                        -- Публичный идентификатор контракта (contracts.uid)
+    old_status   public.eaist_status_enum,             -- Прежний статус; NULL — статус не был задан
+    new_status   public.eaist_status_enum NOT NULL,    -- Новый статус
+    reason       TEXT,                                 -- Причина смены статуса
+    changed_by   TEXT,                                 -- Пользователь, сменивший статус
+    changed_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+COMMENT ON TABLE public.contract_eaist_status_history IS 'История статусов ЕАИСТ контрактов (только добавление)';
+
+CREATE INDEX IF NOT EXISTS idx_contract_eaist_status_history_contract
+    ON public.contract_eaist_status_history (contract_uid, changed_at);
+
+CREATE INDEX IF NOT EXISTS idx_contracts_eaist_status ON public.contracts (eaist_status);
+
+-- Уже заданные статусы переносятся в историю как начальные
+INSERT INTO public.contract_eaist_status_history (contract_uid, new_status, reason, changed_at)
+SELECT uid, eaist_status, 'Статус, заданный до ведения истории', updated_at
+FROM public.contracts
+WHERE eaist_status IS NOT NULL;
+
+-- Собственная функция, чтобы история не зависела от миграций согласования заявок
+CREATE OR REPLACE FUNCTION public.forbid_eaist_status_history_changes() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'Записи % не могут изменяться или удаляться', TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER contract_eaist_status_history_immutable
+    BEFORE UPDATE OR DELETE ON public.contract_eaist_status_history
+    FOR EACH ROW EXECUTE FUNCTION public.forbid_eaist_status_history_changes();
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE public.counterparty_merges
+    DROP COLUMN IF EXISTS payment_requests_moved;
+
+COMMIT;
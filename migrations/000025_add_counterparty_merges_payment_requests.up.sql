@@ -0,0 +1,7 @@
+BEGIN;
+
+-- Количество заявок на оплату, перенесённых при объединении контрагентов
+ALTER TABLE public.counterparty_merges
+    ADD COLUMN IF NOT EXISTS payment_requests_moved INT NOT NULL DEFAULT 0;
+
+COMMIT;